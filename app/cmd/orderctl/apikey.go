@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"test-task/internal/auth"
+	"test-task/internal/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// runAPIKey выдаёт новый ключ API: сам ключ для клиента и запись с его
+// хешем для файла ключей. Ключ больше нигде не сохраняется.
+func runAPIKey(_ context.Context, _ *config.Config, _ *pgxpool.Pool, _ *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	name := fs.String("name", "", "client name")
+	role := fs.String("role", string(auth.RoleViewer), "client role: viewer, support or admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("name is required")
+	}
+	if _, err := auth.ParseRole(*role); err != nil {
+		return err
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	entry, err := yaml.Marshal([]config.APIKey{{Name: *name, Hash: auth.HashKey(key), Role: *role}})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("key: %s\n\n# add to keys in the api keys file:\n%s", key, entry)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"test-task/internal/archive"
+	"test-task/internal/config"
+	"test-task/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func runArchive(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("archive", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", cfg.Archive.OlderThan, "archive orders created earlier than this long ago")
+	dir := fs.String("dir", cfg.Archive.Dir, "archive directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *olderThan <= 0 {
+		return errors.New("older-than must be positive")
+	}
+
+	keys, err := loadKeyring(cfg)
+	if err != nil {
+		return err
+	}
+
+	a := archive.New(repository.NewArchiveRepository(db, keys), *dir, cfg.Archive.BatchSize, log).WithKeyring(keys)
+
+	total, err := a.Archive(ctx, time.Now().UTC().Add(-*olderThan), nil)
+	if err != nil {
+		return err
+	}
+
+	log.Info("archive finished", zap.Int("orders", total), zap.String("dir", *dir))
+	return nil
+}
+
+func runRestore(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	file := fs.String("file", "", "archive file (*.ndjson.gz)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("file is required")
+	}
+
+	keys, err := loadKeyring(cfg)
+	if err != nil {
+		return err
+	}
+
+	a := archive.New(repository.NewArchiveRepository(db, keys), cfg.Archive.Dir, cfg.Archive.BatchSize, log).WithKeyring(keys)
+
+	result, err := a.Restore(ctx, *file)
+	if err != nil {
+		return err
+	}
+
+	log.Info("restore finished",
+		zap.String("file", *file),
+		zap.Int("restored", result.Restored),
+		zap.Int("skipped", result.Skipped),
+	)
+	return nil
+}
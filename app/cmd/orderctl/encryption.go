@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"test-task/internal/config"
+	"test-task/internal/keyring"
+	"test-task/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// runRotateKeys перешифровывает основным ключом keyring доставки,
+// зашифрованные старыми ключами или записанные открыто. После неё старые
+// ключи можно убрать из keyring.
+func runRotateKeys(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	batch := fs.Int("batch", cfg.Encryption.BatchSize, "deliveries per transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return errors.New("batch must be positive")
+	}
+
+	keys, err := loadKeyring(cfg)
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		return errors.New("encryption keyring is not configured")
+	}
+
+	repo := repository.NewDeliveryRepository(db, keys)
+
+	var lastID int64
+	total := 0
+	for {
+		id, n, err := repo.Reencrypt(ctx, lastID, *batch)
+		if err != nil {
+			return fmt.Errorf("after delivery %d: %w", lastID, err)
+		}
+		if n == 0 {
+			break
+		}
+		lastID = id
+		total += n
+		log.Info("deliveries re-encrypted", zap.Int("total", total), zap.Int64("last_id", lastID))
+	}
+
+	log.Info("key rotation finished", zap.Int("deliveries", total), zap.String("primary", keys.Primary()))
+	return nil
+}
+
+// runScrubHistory заменяет слепыми индексами персональные данные доставки,
+// записанные открыто в историю изменений и тела событий вебхуков до
+// включения шифрования. Исходные значения не восстановить, поэтому команда
+// запускается только с -confirm.
+func runScrubHistory(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("scrub-history", flag.ContinueOnError)
+	batch := fs.Int("batch", cfg.Encryption.BatchSize, "rows per transaction")
+	confirm := fs.Bool("confirm", false, "irreversibly replace plaintext values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return errors.New("batch must be positive")
+	}
+	if !*confirm {
+		return errors.New("scrubbing cannot be undone, run with -confirm")
+	}
+
+	keys, err := loadKeyring(cfg)
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		return errors.New("encryption keyring is not configured")
+	}
+
+	repo := repository.NewScrubRepository(db, keys)
+
+	for _, step := range []struct {
+		name  string
+		scrub func(ctx context.Context, afterID int64, limit int) (int64, int, error)
+	}{
+		{"order_audit", repo.ScrubAudit},
+		{"webhook_deliveries", repo.ScrubWebhookPayloads},
+	} {
+		var lastID int64
+		total := 0
+		for {
+			id, n, err := step.scrub(ctx, lastID, *batch)
+			if err != nil {
+				return fmt.Errorf("%s after %d: %w", step.name, lastID, err)
+			}
+			if n == 0 {
+				break
+			}
+			lastID = id
+			total += n
+			log.Info("history scrubbed", zap.String("table", step.name), zap.Int("total", total), zap.Int64("last_id", lastID))
+		}
+		log.Info("history scrub finished", zap.String("table", step.name), zap.Int("rows", total))
+	}
+
+	return nil
+}
+
+// runEncryptionKey выдаёт новый ключ для файла keyring.
+func runEncryptionKey(_ context.Context, _ *config.Config, _ *pgxpool.Pool, _ *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("encryption-key", flag.ContinueOnError)
+	id := fs.String("id", "", "key id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("id is required")
+	}
+
+	key, err := keyring.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("# add to keys in the keyring file and make it primary:\n%s: %s\n", *id, key)
+	return nil
+}
+
+// loadKeyring загружает keyring из конфига или возвращает nil, если
+// шифрование не настроено.
+func loadKeyring(cfg *config.Config) (*keyring.Keyring, error) {
+	if cfg.Encryption.Keyring == "" {
+		return nil, nil
+	}
+	return keyring.Load(cfg.Encryption.Keyring)
+}
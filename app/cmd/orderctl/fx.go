@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"test-task/internal/config"
+	"test-task/internal/fx"
+	"test-task/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func runFXLoad(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("fx-load", flag.ContinueOnError)
+	file := fs.String("file", "", "CSV file with currency,date,rate columns")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rates, err := fx.ReadCSV(f)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.NewFXRepository(db).UpsertRates(ctx, rates); err != nil {
+		return err
+	}
+
+	log.Info("fx rates loaded",
+		zap.String("file", *file),
+		zap.Int("rates", len(rates)),
+		zap.String("base_currency", cfg.FX.BaseCurrency),
+	)
+	return nil
+}
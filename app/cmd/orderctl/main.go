@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"test-task/internal/config"
+	"test-task/internal/database"
+	"test-task/internal/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error
+	// offline — команде не нужны конфиг и база, db и cfg равны nil.
+	offline bool
+}
+
+var commands = map[string]command{
+	"archive":        {usage: "archive [-older-than 720h] [-dir path]", run: runArchive},
+	"restore":        {usage: "restore -file path", run: runRestore},
+	"fx-load":        {usage: "fx-load -file rates.csv", run: runFXLoad},
+	"rollup-rebuild": {usage: "rollup-rebuild [-from YYYY-MM-DD] [-to YYYY-MM-DD]", run: runRollupRebuild},
+	"reconcile":      {usage: "reconcile -provider name -date YYYY-MM-DD -file settlement.csv[.gz]", run: runReconcile},
+	"apikey":         {usage: "apikey -name client -role viewer|support|admin", run: runAPIKey, offline: true},
+	"rotate-keys":    {usage: "rotate-keys [-batch 500]", run: runRotateKeys},
+	"scrub-history":  {usage: "scrub-history -confirm [-batch 500]", run: runScrubHistory},
+	"encryption-key": {usage: "encryption-key -id name", run: runEncryptionKey, offline: true},
+}
+
+func main() {
+	log := logger.NewLogger()
+	defer log.Sync()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if cmd.offline {
+		if err := cmd.run(context.Background(), nil, nil, log, os.Args[2:]); err != nil {
+			log.Error("command failed", zap.String("command", os.Args[1]), zap.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	yamlConfigFilePath := os.Getenv("CONFIG_PATH")
+	if yamlConfigFilePath == "" {
+		log.Fatal("env ConfigPath is empty")
+	}
+	cfg, err := config.Load(yamlConfigFilePath)
+	if err != nil {
+		log.Fatal("error on loading config", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	db, err := database.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("error on connecting to database", zap.Error(err))
+	}
+	defer db.Close()
+
+	if err := cmd.run(ctx, cfg, db, log, os.Args[2:]); err != nil {
+		log.Error("command failed", zap.String("command", os.Args[1]), zap.Error(err))
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: orderctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"test-task/internal/config"
+	"test-task/internal/models"
+	"test-task/internal/reconcile"
+	"test-task/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func runReconcile(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	provider := fs.String("provider", "", "payment provider of the settlement file")
+	dateFlag := fs.String("date", "", "settlement day, YYYY-MM-DD")
+	file := fs.String("file", "", "settlement CSV file, optionally gzipped (*.gz)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *provider == "" || *file == "" || *dateFlag == "" {
+		return errors.New("provider, date and file are required")
+	}
+
+	date, err := time.Parse(time.DateOnly, *dateFlag)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(*file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	src, err := reconcile.NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	run := &models.ReconciliationRun{
+		Provider:       *provider,
+		SettlementDate: date,
+		FileName:       filepath.Base(*file),
+	}
+	if err := repository.NewReconciliationRepository(db).Reconcile(ctx, run, src); err != nil {
+		return err
+	}
+
+	log.Info("reconciliation finished",
+		zap.Int64("run_id", run.ID),
+		zap.String("provider", run.Provider),
+		zap.String("date", *dateFlag),
+		zap.Int64("rows", run.Rows),
+		zap.Int64("matched", run.Matched),
+		zap.Any("discrepancies", run.Discrepancies),
+	)
+	return nil
+}
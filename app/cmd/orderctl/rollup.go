@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"test-task/internal/config"
+	"test-task/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func runRollupRebuild(ctx context.Context, cfg *config.Config, db *pgxpool.Pool, log *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("rollup-rebuild", flag.ContinueOnError)
+	fromFlag := fs.String("from", "1970-01-01", "first day to rebuild, YYYY-MM-DD")
+	toFlag := fs.String("to", time.Now().UTC().Format(time.DateOnly), "last day to rebuild, YYYY-MM-DD")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		return err
+	}
+	to, err := time.Parse(time.DateOnly, *toFlag)
+	if err != nil {
+		return err
+	}
+	if to.Before(from) {
+		return errors.New("to is before from")
+	}
+
+	// to включительно
+	if err := repository.NewRollupRepository(db).Rebuild(ctx, from, to.AddDate(0, 0, 1)); err != nil {
+		return err
+	}
+
+	log.Info("rollups rebuilt",
+		zap.String("from", *fromFlag),
+		zap.String("to", *toFlag),
+	)
+	return nil
+}
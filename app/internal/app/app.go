@@ -4,12 +4,25 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"test-task/internal/archive"
+	"test-task/internal/auth"
 	"test-task/internal/config"
 	"test-task/internal/consumer"
 	"test-task/internal/database"
+	"test-task/internal/events"
+	"test-task/internal/fraud"
+	"test-task/internal/fx"
+	"test-task/internal/gql"
+	"test-task/internal/grpcserver"
 	"test-task/internal/handler"
+	"test-task/internal/openapi"
+	"test-task/internal/partition"
+	"test-task/internal/purge"
 	"test-task/internal/repository"
 	"test-task/internal/service"
+	"test-task/internal/stream"
+	"test-task/internal/webhook"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo"
@@ -24,8 +37,17 @@ type App struct {
 
 	db *pgxpool.Pool
 
-	consumer *consumer.Consumer
-	server   *echo.Echo
+	consumer   *consumer.Consumer
+	fxConsumer *consumer.FXConsumer
+	tracking   *consumer.TrackingConsumer
+	purger     *purge.Purger
+	parts      *partition.Maintainer
+	webhooks   *webhook.Dispatcher
+	service    *service.Service
+	server     *echo.Echo
+	grpc       *grpcserver.Server
+
+	authReloaders []auth.Reloader
 }
 
 func New(ctx context.Context, cfg *config.Config, log *zap.Logger) (*App, error) {
@@ -43,13 +65,30 @@ func New(ctx context.Context, cfg *config.Config, log *zap.Logger) (*App, error)
 
 	e := echo.New()
 
-	e.Use(middleware.Logger())
+	// строка запроса не пишется в лог: в параметрах поиска бывают
+	// персональные данные получателя
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Format: strings.Replace(middleware.DefaultLoggerConfig.Format, `"uri":"${uri}"`, `"path":"${path}"`, 1),
+	}))
 
 	e.Static("/", "public")
 
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OpenAPI.Validate {
+		e.Use(openapi.NewValidator(doc, log).WithResponses(cfg.OpenAPI.ValidateResponses).Middleware)
+	}
+
 	retrier := newServiceRetrier(cfg.Retry, isRetryableFunc)
 
-	repo := repository.NewExtendedOrderRepository(db)
+	keys, err := newKeyring(cfg.Encryption, log)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := repository.NewExtendedOrderRepository(db, keys)
 	service := service.NewService(
 		db,
 		repo,
@@ -57,35 +96,219 @@ func New(ctx context.Context, cfg *config.Config, log *zap.Logger) (*App, error)
 		log,
 	)
 
+	fxRepo := repository.NewFXRepository(db)
+	if cfg.FX.BaseCurrency != "" {
+		service.WithFX(fx.NewConverter(fxRepo, cfg.FX.BaseCurrency))
+	}
+
+	service.WithAnalytics(repository.NewAnalyticsRepository(db))
+	service.WithCustomers(repository.NewCustomerRepository(db, keys))
+	service.WithSearch(repository.NewSearchRepository(db, keys))
+	service.WithTracking(repository.NewDeliveryEventRepository(db))
+	service.WithRefunds(repository.NewRefundRepository(db))
+	service.WithReconciliation(repository.NewReconciliationRepository(db))
+
+	fraudRepo := repository.NewFraudRepository(db, keys)
+	var engine *fraud.Engine
+	if cfg.Fraud.Rules != "" {
+		rules, err := fraud.LoadRules(cfg.Fraud.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fraud rules: %w", err)
+		}
+		engine = fraud.NewEngine(rules, fraudRepo)
+		log.Info("fraud rules loaded", zap.Int("rules", len(rules.Rules)))
+	}
+	service.WithFraud(engine, fraudRepo)
+
+	bus := events.NewBus()
+	service.WithEvents(bus)
+
+	redactor, err := newRedactor(cfg.Redaction, log)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	service.WithWebhooks(webhookRepo)
+
+	var webhooks *webhook.Dispatcher
+	if cfg.Webhooks.Enabled {
+		webhooks = webhook.New(webhookRepo, webhook.Config{
+			Workers:   cfg.Webhooks.Workers,
+			Interval:  cfg.Webhooks.Interval,
+			Timeout:   cfg.Webhooks.Timeout,
+			Lease:     cfg.Webhooks.Lease,
+			QueueSize: cfg.Webhooks.QueueSize,
+		}, log).WithRedactor(redactor)
+		bus.Subscribe(webhooks.Handle)
+	}
+
+	service.WithOrderHub(cfg.WebSocket.ClientBuffer)
+
+	broker := stream.NewBroker(cfg.Stream.Buffer, cfg.Stream.ClientBuffer)
+	bus.Subscribe(broker.Handle)
+
 	if err := service.LoadRecentOrdersToCache(ctx, cfg.Service.CacheSize); err != nil {
 		return nil, fmt.Errorf("failed to load recent orders to cache: %w", err)
 	}
 
+	var fxConsumer *consumer.FXConsumer
+	if cfg.FX.Topic != "" {
+		fxConsumer = consumer.NewFXConsumer(kafka.ReaderConfig{
+			Topic:   cfg.FX.Topic,
+			Brokers: cfg.Kafka.Brokers,
+		}, fxRepo, retrier, log)
+	}
+
+	var tracking *consumer.TrackingConsumer
+	if cfg.Tracking.Topic != "" {
+		tracking = consumer.NewTrackingConsumer(kafka.ReaderConfig{
+			Topic:   cfg.Tracking.Topic,
+			Brokers: cfg.Kafka.Brokers,
+		}, service, retrier, log)
+	}
+
+	schema, err := gql.New(repo, gql.Config{
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		BatchWait:     cfg.GraphQL.BatchWait,
+		Redactor:      redactor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse graphql schema: %w", err)
+	}
+
+	var (
+		authenticator auth.Authenticator
+		authReloaders []auth.Reloader
+	)
+	if cfg.Auth.Enabled {
+		var chain auth.Chain
+		if chain, authReloaders, err = newAuthenticator(cfg.Auth); err != nil {
+			return nil, err
+		}
+		authenticator = chain
+	} else {
+		log.Warn("authentication is disabled, every client has admin access")
+	}
+
 	handler := handler.NewHandler(service, retrier, log)
+	handler.WithAuth(authenticator)
+	handler.WithRedactor(redactor)
+	handler.WithPrivilegedToken(cfg.Search.PrivilegedToken)
+	handler.WithStream(broker, cfg.Stream.Heartbeat)
+	handler.WithGraphQL(schema)
+	handler.WithOpenAPI(doc)
+	handler.WithOrderSocket(cfg.WebSocket.PingInterval, cfg.WebSocket.PongWait, cfg.WebSocket.WriteTimeout)
 	handler.RegisterRoutes(e)
+	var grpcServer *grpcserver.Server
+	if cfg.GRPC.Port != "" {
+		grpcServer = grpcserver.New(service, retrier, log).WithAuth(authenticator).WithRedactor(redactor)
+	}
+
 	consumer := consumer.NewConsumer(kafka.ReaderConfig{
 		Topic:   cfg.Kafka.Topic,
 		Brokers: cfg.Kafka.Brokers,
-	}, service, retrier, log)
+	}, service, retrier, log).WithRedactor(redactor)
+
+	var purger *purge.Purger
+	if cfg.Purge.Enabled {
+		purger = purge.New(
+			repository.NewRetentionRepository(db),
+			purge.Config{
+				Interval:         cfg.Purge.Interval,
+				Retention:        cfg.Purge.Retention,
+				DeletedRetention: cfg.Purge.DeletedRetention,
+				BatchSize:        cfg.Purge.BatchSize,
+			},
+			service.EvictOrders,
+			log,
+		)
+
+		if cfg.Purge.Archive {
+			purger.WithArchiver(archive.New(
+				repository.NewArchiveRepository(db, keys),
+				cfg.Archive.Dir,
+				cfg.Archive.BatchSize,
+				log,
+			).WithKeyring(keys))
+		}
+	}
+
+	var parts *partition.Maintainer
+	if cfg.Partitions.Enabled {
+		parts = partition.New(
+			repository.NewPartitionRepository(db),
+			partition.Config{
+				Interval:  cfg.Partitions.Interval,
+				Ahead:     cfg.Partitions.Ahead,
+				Retention: cfg.Partitions.Retention,
+			},
+			log,
+		)
+	}
 
 	return &App{
-		cfg:      cfg,
-		log:      log,
-		db:       db,
-		consumer: consumer,
-		server:   e,
+		cfg:        cfg,
+		log:        log,
+		db:         db,
+		consumer:   consumer,
+		fxConsumer: fxConsumer,
+		tracking:   tracking,
+		purger:     purger,
+		parts:      parts,
+		webhooks:   webhooks,
+		service:    service,
+		server:     e,
+		grpc:       grpcServer,
+
+		authReloaders: authReloaders,
 	}, nil
 }
 
 func (a *App) Run(ctx context.Context) error {
 	go a.consumer.Run(ctx)
 
+	if a.fxConsumer != nil {
+		go a.fxConsumer.Run(ctx)
+	}
+
+	if a.tracking != nil {
+		go a.tracking.Run(ctx)
+	}
+
+	if a.purger != nil {
+		go a.purger.Run(ctx)
+	}
+
+	if a.parts != nil {
+		go a.parts.Run(ctx)
+	}
+
+	if a.webhooks != nil {
+		go a.webhooks.Run(ctx)
+	}
+
+	go a.service.RunOrderHub(ctx)
+
+	if len(a.authReloaders) > 0 && a.cfg.Auth.ReloadInterval > 0 {
+		go auth.Watch(ctx, a.cfg.Auth.ReloadInterval, a.log, a.authReloaders...)
+	}
+
 	go func() {
 		if err := a.server.Start(":" + a.cfg.App.Port); err != nil && err != http.ErrServerClosed {
 			a.log.Error("failed to start server", zap.Error(err))
 		}
 	}()
 
+	if a.grpc != nil {
+		go func() {
+			if err := a.grpc.Serve(":" + a.cfg.GRPC.Port); err != nil {
+				a.log.Error("failed to start grpc server", zap.Error(err))
+			}
+		}()
+	}
+
 	<-ctx.Done()
 	return a.Shutdown()
 }
@@ -95,12 +318,28 @@ func (a *App) Shutdown() error {
 		return fmt.Errorf("failed to close consumer: %w", err)
 	}
 
+	if a.fxConsumer != nil {
+		if err := a.fxConsumer.Close(); err != nil {
+			return fmt.Errorf("failed to close fx consumer: %w", err)
+		}
+	}
+
+	if a.tracking != nil {
+		if err := a.tracking.Close(); err != nil {
+			return fmt.Errorf("failed to close tracking consumer: %w", err)
+		}
+	}
+
 	ctxTimeout, cancelTimeout := context.WithTimeout(context.Background(), a.cfg.App.ShutdownTimeout)
 	defer cancelTimeout()
 	if err := a.server.Shutdown(ctxTimeout); err != nil {
 		return fmt.Errorf("failed to shutdown echo server: %w", err)
 	}
 
+	if a.grpc != nil {
+		a.grpc.Stop(ctxTimeout)
+	}
+
 	a.db.Close()
 
 	return nil
@@ -2,11 +2,21 @@ package app
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	"test-task/internal/auth"
 	"test-task/internal/config"
+	"test-task/internal/fx"
+	"test-task/internal/keyring"
+	"test-task/internal/money"
+	"test-task/internal/redact"
 	"test-task/internal/repository"
 	"test-task/internal/retry"
+	"test-task/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
 )
 
 func newServiceRetrier(cfg config.Retry, retryableFunc retry.IsRetryableFunc) retry.Retrier {
@@ -36,7 +46,21 @@ func isRetryableFunc(err error) bool {
 		repository.ErrNotFound,
 		repository.ErrInvalidID,
 		repository.ErrForeignKeyViolation,
-		repository.ErrNotFound,
+		fx.ErrUnknownCurrency,
+		fx.ErrRateNotFound,
+		service.ErrFXDisabled,
+		repository.ErrInvalidFilter,
+		service.ErrTrackingDisabled,
+		repository.ErrRefundExceedsPayment,
+		repository.ErrInvalidRefund,
+		repository.ErrInvalidReturn,
+		repository.ErrNoKeyring,
+		keyring.ErrUnknownKey,
+		keyring.ErrDecrypt,
+		repository.ErrNilValue,
+		money.ErrInvalid,
+		money.ErrPrecision,
+		money.ErrOverflow,
 	}
 
 	for _, unretryableErr := range unretryableErrors {
@@ -45,5 +69,98 @@ func isRetryableFunc(err error) bool {
 		}
 	}
 
+	// ошибки проверки входных данных повтором не исправить
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return false
+	}
+
 	return true
 }
+
+// newAuthenticator собирает проверку ключей API и токенов JWT.
+// Reloader'ы перечитывают файлы ключей без перезапуска.
+func newAuthenticator(cfg config.Auth) (auth.Chain, []auth.Reloader, error) {
+	static := make([]auth.APIKey, 0, len(cfg.APIKeys))
+	for _, k := range cfg.APIKeys {
+		static = append(static, auth.APIKey{Name: k.Name, Hash: k.Hash, Role: auth.Role(k.Role)})
+	}
+
+	keys, err := auth.NewKeyStore(static, cfg.KeysFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load api keys: %w", err)
+	}
+	chain := auth.Chain{keys}
+	reloaders := []auth.Reloader{keys}
+
+	if cfg.JWT.JWKSFile != "" {
+		verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+			JWKSFile:  cfg.JWT.JWKSFile,
+			Issuer:    cfg.JWT.Issuer,
+			Audience:  cfg.JWT.Audience,
+			RoleClaim: cfg.JWT.RoleClaim,
+			Leeway:    cfg.JWT.Leeway,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load jwks: %w", err)
+		}
+		chain = append(chain, verifier)
+		reloaders = append(reloaders, verifier)
+	}
+
+	// без ключей и JWKS сервис отвечал бы 401 на каждый запрос
+	if keys.Len() == 0 && cfg.JWT.JWKSFile == "" {
+		return nil, nil, errors.New("authentication is enabled but no api keys or jwks configured")
+	}
+
+	return chain, reloaders, nil
+}
+
+// newKeyring загружает keyring шифрования доставок. Без файла в конфиге
+// возвращает nil, и персональные данные пишутся открыто.
+func newKeyring(cfg config.Encryption, log *zap.Logger) (*keyring.Keyring, error) {
+	if cfg.Keyring == "" {
+		log.Warn("encryption keyring is not configured, delivery PII is stored unencrypted")
+		return nil, nil
+	}
+
+	keys, err := keyring.Load(cfg.Keyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keyring: %w", err)
+	}
+	log.Info("encryption keyring loaded", zap.String("primary", keys.Primary()))
+	return keys, nil
+}
+
+// newRedactor собирает политики скрытия полей заказа из конфига.
+func newRedactor(cfg config.Redaction, log *zap.Logger) (*redact.Redactor, error) {
+	rules := func(paths map[string]string) redact.Rules {
+		if paths == nil {
+			return nil
+		}
+		r := make(redact.Rules, len(paths))
+		for path, action := range paths {
+			r[path] = redact.Action(action)
+		}
+		return r
+	}
+
+	roles := make(map[auth.Role]redact.Rules, len(cfg.Roles))
+	for role, paths := range cfg.Roles {
+		// пустые правила в YAML ({}) — «ничего не скрывать», а не правила по умолчанию
+		if paths == nil {
+			paths = map[string]string{}
+		}
+		roles[auth.Role(role)] = rules(paths)
+	}
+
+	if cfg.HashKey == "" {
+		log.Warn("REDACTION_HASH_KEY is not set, hashed values will not match across restarts")
+	}
+
+	r, err := redact.New(redact.Config{Roles: roles, Log: rules(cfg.Log), HashKey: []byte(cfg.HashKey)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load redaction rules: %w", err)
+	}
+	return r, nil
+}
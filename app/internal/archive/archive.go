@@ -0,0 +1,455 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	dataSuffix     = ".ndjson.gz"
+	manifestSuffix = ".manifest.json"
+)
+
+var ErrChecksumMismatch = errors.New("archive checksum mismatch")
+
+// Manifest описывает один файл архива и лежит рядом с ним.
+type Manifest struct {
+	File      string    `json:"file"`
+	Date      string    `json:"date"`
+	Orders    int       `json:"orders"`
+	Items     int       `json:"items"`
+	Bytes     int64     `json:"bytes"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RestoreResult struct {
+	Restored int `json:"restored"`
+	Skipped  int `json:"skipped"`
+}
+
+type Archiver struct {
+	repo      repository.ArchiveRepository
+	dir       string
+	batchSize int
+	keys      *keyring.Keyring
+	log       *zap.Logger
+	now       func() time.Time
+}
+
+// record — строка файла архива. С keyring персональные данные доставки
+// в ней зашифрованы, а Envelope хранит ключ строки.
+type record struct {
+	*models.ExtendedOrder
+	Envelope *keyring.Envelope `json:"envelope,omitempty"`
+}
+
+func New(repo repository.ArchiveRepository, dir string, batchSize int, log *zap.Logger) *Archiver {
+	return &Archiver{
+		repo:      repo,
+		dir:       dir,
+		batchSize: batchSize,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// WithKeyring включает шифрование персональных данных доставки в файлах
+// архива. Тот же keyring нужен, чтобы восстановить их.
+func (a *Archiver) WithKeyring(keys *keyring.Keyring) *Archiver {
+	a.keys = keys
+	return a
+}
+
+// Archive выгружает заказы, созданные раньше before, в файлы
+// <dir>/date=YYYY-MM-DD/orders-<run>.ndjson.gz и удаляет их из базы только
+// после того, как файл и манифест записаны на диск. onDelete вызывается
+// с id каждой удалённой пачки. Возвращает число заархивированных заказов.
+func (a *Archiver) Archive(ctx context.Context, before time.Time, onDelete func(ids []int64)) (int, error) {
+	release, err := a.repo.TryLock(ctx)
+	if errors.Is(err, repository.ErrLockNotAcquired) {
+		a.log.Info("archive is running on another replica, skipping")
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	ctx = audit.WithActor(ctx, audit.SystemActor("archive"))
+	run := a.now().UTC().Format("20060102T150405Z")
+
+	var (
+		w      *dayWriter
+		cursor repository.ArchiveCursor
+		total  int
+	)
+
+	flush := func() error {
+		if w == nil {
+			return nil
+		}
+		m, err := w.Close(a.now().UTC())
+		if err != nil {
+			return err
+		}
+		if err := a.repo.DeleteExtendedOrders(ctx, w.ids); err != nil {
+			return err
+		}
+		if onDelete != nil {
+			onDelete(w.ids)
+		}
+
+		a.log.Info("orders archived",
+			zap.String("file", m.File),
+			zap.Int("orders", m.Orders),
+			zap.Int("items", m.Items),
+		)
+
+		total += m.Orders
+		w = nil
+		return nil
+	}
+
+	defer func() {
+		if w != nil {
+			w.Abort()
+		}
+	}()
+
+	for {
+		eos, err := a.repo.GetExtendedOrdersBefore(ctx, before, cursor, a.batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, eo := range eos {
+			day := eo.Order.DateCreated.UTC().Format(time.DateOnly)
+			if w != nil && w.day != day {
+				if err := flush(); err != nil {
+					return total, err
+				}
+			}
+			if w == nil {
+				if w, err = newDayWriter(a.dir, day, run); err != nil {
+					return total, err
+				}
+			}
+			rec, err := a.seal(eo)
+			if err != nil {
+				return total, err
+			}
+			if err := w.Write(rec); err != nil {
+				return total, err
+			}
+		}
+
+		if len(eos) < a.batchSize {
+			break
+		}
+
+		last := eos[len(eos)-1]
+		cursor = repository.ArchiveCursor{DateCreated: last.Order.DateCreated, ID: last.Order.ID}
+	}
+
+	if err := flush(); err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+// Restore загружает заказы из файла архива обратно в базу.
+// Если рядом лежит манифест, сначала сверяется контрольная сумма.
+// Уже существующие заказы пропускаются.
+func (a *Archiver) Restore(ctx context.Context, path string) (RestoreResult, error) {
+	var result RestoreResult
+
+	if err := verifyChecksum(path); err != nil {
+		return result, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return result, err
+	}
+	defer gz.Close()
+
+	ctx = audit.WithActor(ctx, audit.SystemActor("restore"))
+
+	dec := json.NewDecoder(gz)
+	for {
+		rec := record{ExtendedOrder: new(models.ExtendedOrder)}
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return result, fmt.Errorf("failed to decode order #%d: %w", result.Restored+result.Skipped+1, err)
+		}
+
+		eo, err := a.open(rec)
+		if err != nil {
+			return result, fmt.Errorf("failed to decrypt order %d: %w", rec.Order.ID, err)
+		}
+
+		restored, err := a.repo.RestoreExtendedOrder(ctx, eo)
+		if err != nil {
+			return result, fmt.Errorf("failed to restore order %d: %w", eo.Order.ID, err)
+		}
+		if restored {
+			result.Restored++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	if result.Restored > 0 {
+		if err := a.repo.SyncSequences(ctx); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// seal шифрует персональные данные доставки копии eo для записи в архив.
+func (a *Archiver) seal(eo *models.ExtendedOrder) (record, error) {
+	if a.keys == nil {
+		return record{ExtendedOrder: eo}, nil
+	}
+
+	c := *eo
+	env, err := a.keys.Seal(deliveryFields(&c.Delivery)...)
+	if err != nil {
+		return record{}, err
+	}
+	return record{ExtendedOrder: &c, Envelope: &env}, nil
+}
+
+func (a *Archiver) open(rec record) (*models.ExtendedOrder, error) {
+	if rec.Envelope == nil {
+		return rec.ExtendedOrder, nil
+	}
+	if a.keys == nil {
+		return nil, repository.ErrNoKeyring
+	}
+	if err := a.keys.Open(*rec.Envelope, deliveryFields(&rec.Delivery)...); err != nil {
+		return nil, err
+	}
+	return rec.ExtendedOrder, nil
+}
+
+func deliveryFields(d *models.Delivery) []keyring.Field {
+	return []keyring.Field{
+		{Name: "name", Value: &d.Name},
+		{Name: "phone", Value: &d.Phone},
+		{Name: "address", Value: &d.Address},
+		{Name: "email", Value: &d.Email},
+	}
+}
+
+func verifyChecksum(path string) error {
+	manifestPath := strings.TrimSuffix(path, dataSuffix) + manifestSuffix
+
+	data, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid manifest %s: %w", manifestPath, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != m.SHA256 {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// dayWriter пишет заказы одного дня во временный файл и при закрытии
+// атомарно переименовывает его, предварительно сделав fsync.
+type dayWriter struct {
+	day   string
+	dir   string
+	name  string
+	tmp   *os.File
+	hash  hash.Hash
+	count *countingWriter
+	gz    *gzip.Writer
+	enc   *json.Encoder
+	ids   []int64
+	items int
+}
+
+func newDayWriter(root, day, run string) (*dayWriter, error) {
+	dir := filepath.Join(root, "date="+day)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := "orders-" + run + dataSuffix
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	count := &countingWriter{w: io.MultiWriter(tmp, h)}
+	gz := gzip.NewWriter(count)
+
+	return &dayWriter{
+		day:   day,
+		dir:   dir,
+		name:  name,
+		tmp:   tmp,
+		hash:  h,
+		count: count,
+		gz:    gz,
+		enc:   json.NewEncoder(gz),
+	}, nil
+}
+
+func (w *dayWriter) Write(rec record) error {
+	if err := w.enc.Encode(rec); err != nil {
+		return err
+	}
+	w.ids = append(w.ids, rec.Order.ID)
+	w.items += len(rec.Items)
+	return nil
+}
+
+func (w *dayWriter) Close(now time.Time) (*Manifest, error) {
+	if err := w.gz.Close(); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	if err := w.tmp.Sync(); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	if err := w.tmp.Close(); err != nil {
+		os.Remove(w.tmp.Name())
+		return nil, err
+	}
+
+	path := filepath.Join(w.dir, w.name)
+	if err := os.Rename(w.tmp.Name(), path); err != nil {
+		os.Remove(w.tmp.Name())
+		return nil, err
+	}
+
+	m := &Manifest{
+		File:      w.name,
+		Date:      w.day,
+		Orders:    len(w.ids),
+		Items:     w.items,
+		Bytes:     w.count.n,
+		SHA256:    hex.EncodeToString(w.hash.Sum(nil)),
+		CreatedAt: now,
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	manifestPath := strings.TrimSuffix(path, dataSuffix) + manifestSuffix
+	if err := writeFileSync(manifestPath, data); err != nil {
+		return nil, err
+	}
+
+	if err := syncDir(w.dir); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (w *dayWriter) Abort() {
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,233 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeArchiveRepository struct {
+	t        *testing.T
+	dir      string
+	orders   []*models.ExtendedOrder
+	deleted  []int64
+	restored []*models.ExtendedOrder
+	synced   bool
+	locked   bool
+}
+
+func (f *fakeArchiveRepository) GetExtendedOrdersBefore(
+	_ context.Context,
+	before time.Time,
+	after repository.ArchiveCursor,
+	limit int,
+) ([]*models.ExtendedOrder, error) {
+	var out []*models.ExtendedOrder
+	for _, eo := range f.orders {
+		if !eo.Order.DateCreated.Before(before) {
+			continue
+		}
+		if eo.Order.DateCreated.Before(after.DateCreated) ||
+			(eo.Order.DateCreated.Equal(after.DateCreated) && eo.Order.ID <= after.ID) {
+			continue
+		}
+		out = append(out, eo)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeArchiveRepository) DeleteExtendedOrders(_ context.Context, ids []int64) error {
+	// файл дня уже должен лежать на диске
+	matches, err := filepath.Glob(filepath.Join(f.dir, "date=*", "orders-*"+dataSuffix))
+	require.NoError(f.t, err)
+	require.NotEmpty(f.t, matches)
+
+	f.deleted = append(f.deleted, ids...)
+	return nil
+}
+
+func (f *fakeArchiveRepository) RestoreExtendedOrder(_ context.Context, eo *models.ExtendedOrder) (bool, error) {
+	for _, r := range f.restored {
+		if r.Order.ID == eo.Order.ID {
+			return false, nil
+		}
+	}
+	f.restored = append(f.restored, eo)
+	return true, nil
+}
+
+func (f *fakeArchiveRepository) SyncSequences(context.Context) error {
+	f.synced = true
+	return nil
+}
+
+func (f *fakeArchiveRepository) TryLock(context.Context) (func(), error) {
+	if f.locked {
+		return nil, repository.ErrLockNotAcquired
+	}
+	f.locked = true
+	return func() { f.locked = false }, nil
+}
+
+func testOrder(id int64, created time.Time) *models.ExtendedOrder {
+	return &models.ExtendedOrder{
+		Order: models.Order{ID: id, OrderUID: "uid", DateCreated: created},
+		Items: []*models.Item{{ID: id * 10, OrderID: id, Name: "item"}},
+	}
+}
+
+func TestArchiver_ArchiveAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2021, time.November, 26, 6, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	repo := &fakeArchiveRepository{
+		t:   t,
+		dir: dir,
+		orders: []*models.ExtendedOrder{
+			testOrder(1, day1),
+			testOrder(2, day1.Add(time.Hour)),
+			testOrder(3, day1.Add(2*time.Hour)),
+			testOrder(4, day2),
+			testOrder(5, day2.Add(48*time.Hour)),
+		},
+	}
+
+	a := New(repo, dir, 2, zap.NewNop())
+	a.now = func() time.Time { return time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC) }
+
+	var evicted []int64
+	total, err := a.Archive(t.Context(), day2.Add(time.Hour), func(ids []int64) {
+		evicted = append(evicted, ids...)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []int64{1, 2, 3, 4}, repo.deleted)
+	assert.Equal(t, repo.deleted, evicted)
+	assert.False(t, repo.locked, "lock must be released")
+
+	files, err := filepath.Glob(filepath.Join(dir, "date=*", "*"))
+	require.NoError(t, err)
+	sort.Strings(files)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "date=2021-11-26", "orders-20250101T000000Z.manifest.json"),
+		filepath.Join(dir, "date=2021-11-26", "orders-20250101T000000Z.ndjson.gz"),
+		filepath.Join(dir, "date=2021-11-27", "orders-20250101T000000Z.manifest.json"),
+		filepath.Join(dir, "date=2021-11-27", "orders-20250101T000000Z.ndjson.gz"),
+	}, files)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var m Manifest
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, "2021-11-26", m.Date)
+	assert.Equal(t, 3, m.Orders)
+	assert.Equal(t, 3, m.Items)
+	assert.Len(t, m.SHA256, 64)
+
+	t.Run("restore", func(t *testing.T) {
+		result, err := a.Restore(t.Context(), files[1])
+		require.NoError(t, err)
+		assert.Equal(t, RestoreResult{Restored: 3}, result)
+		assert.True(t, repo.synced)
+		require.Len(t, repo.restored, 3)
+		assert.Equal(t, repo.orders[0].Order.ID, repo.restored[0].Order.ID)
+		assert.Equal(t, repo.orders[0].Items[0].Name, repo.restored[0].Items[0].Name)
+
+		result, err = a.Restore(t.Context(), files[1])
+		require.NoError(t, err)
+		assert.Equal(t, RestoreResult{Skipped: 3}, result)
+	})
+
+	t.Run("corrupted file", func(t *testing.T) {
+		f, err := os.OpenFile(files[3], os.O_WRONLY|os.O_APPEND, 0)
+		require.NoError(t, err)
+		gz := gzip.NewWriter(f)
+		_, err = gz.Write([]byte("{}\n"))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		require.NoError(t, f.Close())
+
+		_, err = a.Restore(t.Context(), files[3])
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
+
+func TestArchiver_LockedByAnotherReplica(t *testing.T) {
+	repo := &fakeArchiveRepository{t: t, locked: true, orders: []*models.ExtendedOrder{
+		testOrder(1, time.Date(2021, time.November, 26, 0, 0, 0, 0, time.UTC)),
+	}}
+
+	a := New(repo, t.TempDir(), 10, zap.NewNop())
+
+	total, err := a.Archive(t.Context(), time.Now(), nil)
+	assert.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, repo.deleted)
+}
+
+func TestArchiver_Encrypted(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2021, time.November, 26, 6, 0, 0, 0, time.UTC)
+
+	eo := testOrder(1, created)
+	eo.Delivery = models.Delivery{
+		Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin",
+		Address: "Ploshad Mira 15", Email: "test@gmail.com",
+	}
+	want := *eo
+
+	keys, err := keyring.New("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, keyring.KeySize)}, bytes.Repeat([]byte{9}, keyring.KeySize))
+	require.NoError(t, err)
+
+	repo := &fakeArchiveRepository{t: t, dir: dir, orders: []*models.ExtendedOrder{eo}}
+	a := New(repo, dir, 10, zap.NewNop()).WithKeyring(keys)
+
+	total, err := a.Archive(t.Context(), created.Add(time.Hour), nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, &want, eo, "archived order must not change")
+
+	files, err := filepath.Glob(filepath.Join(dir, "date=*", "*"+dataSuffix))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	for _, pii := range []string{"Test Testov", "+9720000000", "Ploshad Mira 15", "test@gmail.com"} {
+		assert.NotContains(t, string(data), pii)
+	}
+	assert.Contains(t, string(data), "Kiryat Mozkin")
+
+	_, err = New(repo, dir, 10, zap.NewNop()).Restore(t.Context(), files[0])
+	assert.ErrorIs(t, err, repository.ErrNoKeyring)
+
+	result, err := a.Restore(t.Context(), files[0])
+	require.NoError(t, err)
+	assert.Equal(t, RestoreResult{Restored: 1}, result)
+	assert.Equal(t, want.Delivery, repo.restored[0].Delivery)
+}
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+
+	"test-task/internal/models"
+)
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor models.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает инициатора изменения.
+// Если он не задан, изменение считается системным.
+func ActorFromContext(ctx context.Context) models.Actor {
+	if actor, ok := ctx.Value(actorKey{}).(models.Actor); ok {
+		return actor
+	}
+	return SystemActor("unknown")
+}
+
+func KafkaActor(topic string, partition int, offset int64) models.Actor {
+	return models.Actor{
+		Type:      models.ActorTypeKafka,
+		Topic:     topic,
+		Partition: partition,
+		Offset:    offset,
+	}
+}
+
+func APIActor(principal string) models.Actor {
+	return models.Actor{
+		Type:      models.ActorTypeAPI,
+		Principal: principal,
+	}
+}
+
+func SystemActor(name string) models.Actor {
+	return models.Actor{
+		Type:      models.ActorTypeSystem,
+		Principal: name,
+	}
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff сравнивает JSON-представления before и after и возвращает
+// изменённые поля в виде {"$.payment.amount": {"before": 1, "after": 2}}.
+// nil означает отсутствие объекта (создание или удаление).
+func Diff(before, after any) (json.RawMessage, error) {
+	b, err := toJSONValue(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toJSONValue(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	diffValues("$", b, a, changes)
+
+	return json.Marshal(changes)
+}
+
+func toJSONValue(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// числа остаются json.Number, чтобы денежные суммы сравнивались без округления
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func diffValues(path string, before, after any, changes map[string]Change) {
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		for key, bv := range b {
+			diffValues(path+"."+key, bv, a[key], changes)
+		}
+		for key, av := range a {
+			if _, ok := b[key]; !ok {
+				diffValues(path+"."+key, nil, av, changes)
+			}
+		}
+		return
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(b) || i < len(a); i++ {
+			var bv, av any
+			if i < len(b) {
+				bv = b[i]
+			}
+			if i < len(a) {
+				av = a[i]
+			}
+			diffValues(path+"["+strconv.Itoa(i)+"]", bv, av, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		changes[path] = Change{Before: before, After: after}
+	}
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"test-task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := &models.ExtendedOrder{
+		Order:   models.Order{ID: 1, DeliveryService: "meest"},
+		Payment: models.Payment{Bank: "alpha"},
+		Items:   []*models.Item{{Name: "a", Status: 1}},
+	}
+	after := &models.ExtendedOrder{
+		Order:   models.Order{ID: 1, DeliveryService: "cdek"},
+		Payment: models.Payment{Bank: "alpha"},
+		Items:   []*models.Item{{Name: "a", Status: 2}, {Name: "b", Status: 2}},
+	}
+
+	t.Run("update", func(t *testing.T) {
+		raw, err := Diff(before, after)
+		require.NoError(t, err)
+
+		var changes map[string]Change
+		require.NoError(t, json.Unmarshal(raw, &changes))
+
+		assert.Equal(t, Change{Before: "meest", After: "cdek"}, changes["$.delivery_service"])
+		assert.Equal(t, Change{Before: float64(1), After: float64(2)}, changes["$.items[0].status"])
+		assert.Nil(t, changes["$.items[1]"].Before)
+		assert.NotNil(t, changes["$.items[1]"].After)
+		assert.NotContains(t, changes, "$.payment.bank")
+		assert.Len(t, changes, 3)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		raw, err := Diff(nil, after)
+		require.NoError(t, err)
+
+		var changes map[string]Change
+		require.NoError(t, json.Unmarshal(raw, &changes))
+
+		assert.Len(t, changes, 1)
+		assert.Nil(t, changes["$"].Before)
+		assert.NotNil(t, changes["$"].After)
+	})
+
+	t.Run("typed nil", func(t *testing.T) {
+		var eo *models.ExtendedOrder
+		raw, err := Diff(eo, eo)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{}`, string(raw))
+	})
+}
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, SystemActor("unknown"), ActorFromContext(t.Context()))
+
+	actor := KafkaActor("orders", 2, 42)
+	ctx := WithActor(t.Context(), actor)
+	assert.Equal(t, actor, ActorFromContext(ctx))
+}
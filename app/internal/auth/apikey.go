@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIKeyHeader — заголовок с ключом API.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix отличает ключи сервиса от других секретов в логах и конфигах.
+const apiKeyPrefix = "osk_"
+
+// APIKey — ключ клиента. Сам ключ не хранится, только SHA-256 от него в hex.
+// У одного клиента может быть несколько ключей: так ключ меняют без простоя.
+type APIKey struct {
+	Name string `yaml:"name"`
+	Hash string `yaml:"hash"`
+	Role Role   `yaml:"role"`
+}
+
+// GenerateKey возвращает новый случайный ключ.
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashKey возвращает SHA-256 ключа в hex. Ключи случайные и длинные,
+// поэтому медленная хеш-функция с солью не нужна.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyStore проверяет ключи из конфига и из файла. Файл перечитывается
+// в Reload, ключи из конфига не меняются.
+type KeyStore struct {
+	static []APIKey
+	file   *watchedFile
+	keys   atomic.Pointer[map[string]APIKey]
+}
+
+// keyFile — формат файла ключей.
+type keyFile struct {
+	Keys []APIKey `yaml:"keys"`
+}
+
+// NewKeyStore загружает ключи. Пустой path — только ключи из конфига.
+func NewKeyStore(static []APIKey, path string) (*KeyStore, error) {
+	s := &KeyStore{static: static}
+
+	if path == "" {
+		keys, err := index(static)
+		if err != nil {
+			return nil, err
+		}
+		s.keys.Store(&keys)
+		return s, nil
+	}
+
+	s.file = &watchedFile{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *KeyStore) Reload() error {
+	if s.file == nil {
+		return nil
+	}
+
+	return s.file.read(func(data []byte) error {
+		var f keyFile
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return err
+		}
+
+		keys, err := index(append(append([]APIKey(nil), s.static...), f.Keys...))
+		if err != nil {
+			return err
+		}
+		s.keys.Store(&keys)
+		return nil
+	})
+}
+
+// Len возвращает число действующих ключей.
+func (s *KeyStore) Len() int {
+	return len(*s.keys.Load())
+}
+
+func (s *KeyStore) Authenticate(header func(string) string) (*Principal, error) {
+	key := header(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	k, ok := (*s.keys.Load())[HashKey(key)]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{Name: k.Name, Role: k.Role, Method: MethodAPIKey}, nil
+}
+
+func index(keys []APIKey) (map[string]APIKey, error) {
+	byHash := make(map[string]APIKey, len(keys))
+	for i, k := range keys {
+		if k.Name == "" {
+			return nil, fmt.Errorf("key %d: name is required", i)
+		}
+		if _, err := ParseRole(string(k.Role)); err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Name, err)
+		}
+
+		hash := strings.ToLower(k.Hash)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("key %q: hash must be sha256 in hex", k.Name)
+		}
+		if _, ok := byHash[hash]; ok {
+			return nil, fmt.Errorf("key %q: duplicate hash", k.Name)
+		}
+		k.Hash = hash
+		byHash[hash] = k
+	}
+	return byHash, nil
+}
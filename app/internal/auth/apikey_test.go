@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apiKeyHeader(key string) func(string) string {
+	return func(name string) string {
+		if name == APIKeyHeader {
+			return key
+		}
+		return ""
+	}
+}
+
+func writeKeys(t *testing.T, path string, modTime time.Time, keys ...APIKey) {
+	t.Helper()
+
+	data := "keys:\n"
+	for _, k := range keys {
+		data += fmt.Sprintf("  - {name: %s, hash: %s, role: %s}\n", k.Name, k.Hash, k.Role)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestKeyStore(t *testing.T) {
+	support, err := GenerateKey()
+	require.NoError(t, err)
+
+	store, err := NewKeyStore([]APIKey{{Name: "ops", Hash: HashKey(support), Role: RoleSupport}}, "")
+	require.NoError(t, err)
+
+	p, err := store.Authenticate(apiKeyHeader(support))
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Name: "ops", Role: RoleSupport, Method: MethodAPIKey}, p)
+
+	_, err = store.Authenticate(apiKeyHeader("osk_wrong"))
+	assert.True(t, errors.Is(err, ErrInvalidCredentials))
+
+	_, err = store.Authenticate(apiKeyHeader(""))
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+}
+
+func TestKeyStore_InvalidKeys(t *testing.T) {
+	hash := HashKey("key")
+
+	tests := map[string][]APIKey{
+		"no name":        {{Hash: hash, Role: RoleViewer}},
+		"unknown role":   {{Name: "a", Hash: hash, Role: "root"}},
+		"plaintext key":  {{Name: "a", Hash: "key", Role: RoleViewer}},
+		"duplicate hash": {{Name: "a", Hash: hash, Role: RoleViewer}, {Name: "b", Hash: hash, Role: RoleAdmin}},
+	}
+	for name, keys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewKeyStore(keys, "")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeyStore_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	start := time.Now().Add(-time.Hour)
+
+	writeKeys(t, path, start, APIKey{Name: "old", Hash: HashKey("old-key"), Role: RoleViewer})
+
+	store, err := NewKeyStore(nil, path)
+	require.NoError(t, err)
+
+	_, err = store.Authenticate(apiKeyHeader("old-key"))
+	require.NoError(t, err)
+
+	// ключ заменён в файле: старый перестаёт действовать без перезапуска
+	writeKeys(t, path, start.Add(time.Minute), APIKey{Name: "new", Hash: HashKey("new-key"), Role: RoleAdmin})
+	require.NoError(t, store.Reload())
+
+	_, err = store.Authenticate(apiKeyHeader("old-key"))
+	assert.True(t, errors.Is(err, ErrInvalidCredentials))
+	p, err := store.Authenticate(apiKeyHeader("new-key"))
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, p.Role)
+
+	// битый файл не сбрасывает действующие ключи
+	require.NoError(t, os.WriteFile(path, []byte("keys: [{name: x, hash: nothex, role: admin}]"), 0o600))
+	require.NoError(t, os.Chtimes(path, start.Add(2*time.Minute), start.Add(2*time.Minute)))
+	assert.Error(t, store.Reload())
+
+	_, err = store.Authenticate(apiKeyHeader("new-key"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Role — уровень доступа клиента. Каждая следующая роль включает права
+// предыдущей: viewer < support < admin.
+type Role string
+
+const (
+	// RoleViewer читает заказы и отчёты без персональных данных получателя.
+	RoleViewer Role = "viewer"
+	// RoleSupport видит персональные данные, меняет заказы и оформляет возвраты.
+	RoleSupport Role = "support"
+	// RoleAdmin дополнительно удаляет заказы и управляет вебхуками.
+	RoleAdmin Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleViewer:  1,
+	RoleSupport: 2,
+	RoleAdmin:   3,
+}
+
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := roleRank[r]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return r, nil
+}
+
+// Allows сообщает, достаточно ли роли r для действия, требующего роль required.
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] > 0 && roleRank[r] >= roleRank[required]
+}
+
+// SeesPII сообщает, видит ли роль персональные данные получателя.
+func (r Role) SeesPII() bool {
+	return r.Allows(RoleSupport)
+}
+
+// Способы аутентификации.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	// MethodNone — аутентификация выключена, клиенту доступно всё.
+	MethodNone = "none"
+)
+
+// Principal — клиент, прошедший аутентификацию.
+type Principal struct {
+	Name   string
+	Role   Role
+	Method string
+}
+
+// Anonymous — клиент при выключенной аутентификации.
+var Anonymous = &Principal{Name: "anonymous", Role: RoleAdmin, Method: MethodNone}
+
+var (
+	// ErrNoCredentials — в запросе нет учётных данных для этого способа.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials — учётные данные есть, но неверны.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// SeesPII сообщает, можно ли показать персональные данные клиенту из ctx.
+// Без клиента в контексте данные скрываются.
+func SeesPII(ctx context.Context) bool {
+	p, ok := PrincipalFromContext(ctx)
+	return ok && p.Role.SeesPII()
+}
+
+// Authenticator проверяет учётные данные из заголовков запроса.
+// header возвращает значение заголовка или пустую строку.
+type Authenticator interface {
+	Authenticate(header func(string) string) (*Principal, error)
+}
+
+// Chain пробует способы аутентификации по очереди. Первый способ, нашедший
+// в запросе свои учётные данные, решает исход.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(header func(string) string) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(header)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Reloader перечитывает ключи, если их файл изменился.
+type Reloader interface {
+	Reload() error
+}
+
+// Watch перечитывает ключи каждые interval до отмены ctx. Ошибка чтения
+// оставляет прежние ключи, чтобы битый файл не закрыл доступ всем.
+func Watch(ctx context.Context, interval time.Duration, log *zap.Logger, reloaders ...Reloader) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, r := range reloaders {
+			if err := r.Reload(); err != nil {
+				log.Error("error on reloading auth keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// watchedFile отслеживает изменение файла по времени изменения и размеру.
+type watchedFile struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// read передаёт commit содержимое файла, если он изменился с прошлого
+// чтения. Изменение запоминается, только если commit вернул nil.
+func (f *watchedFile) read(commit func(data []byte) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	if err := commit(data); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	f.modTime, f.size = info.ModTime(), info.Size()
+	return nil
+}
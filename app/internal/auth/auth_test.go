@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleViewer))
+	assert.True(t, RoleSupport.Allows(RoleSupport))
+	assert.False(t, RoleViewer.Allows(RoleSupport))
+	assert.False(t, Role("root").Allows(RoleViewer))
+
+	assert.False(t, RoleViewer.SeesPII())
+	assert.True(t, RoleSupport.SeesPII())
+
+	_, err := ParseRole("root")
+	assert.Error(t, err)
+}
+
+func TestSeesPII(t *testing.T) {
+	assert.False(t, SeesPII(context.Background()))
+	assert.False(t, SeesPII(WithPrincipal(context.Background(), &Principal{Role: RoleViewer})))
+	assert.True(t, SeesPII(WithPrincipal(context.Background(), &Principal{Role: RoleAdmin})))
+}
+
+type fixedAuth struct {
+	p   *Principal
+	err error
+}
+
+func (a fixedAuth) Authenticate(func(string) string) (*Principal, error) { return a.p, a.err }
+
+func TestChain(t *testing.T) {
+	none := func(string) string { return "" }
+	viewer := &Principal{Name: "v", Role: RoleViewer}
+
+	p, err := Chain{fixedAuth{err: ErrNoCredentials}, fixedAuth{p: viewer}}.Authenticate(none)
+	require.NoError(t, err)
+	assert.Equal(t, viewer, p)
+
+	// неверные учётные данные не передаются следующему способу
+	_, err = Chain{fixedAuth{err: ErrInvalidCredentials}, fixedAuth{p: viewer}}.Authenticate(none)
+	assert.True(t, errors.Is(err, ErrInvalidCredentials))
+
+	_, err = Chain{fixedAuth{err: ErrNoCredentials}}.Authenticate(none)
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+}
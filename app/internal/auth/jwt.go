@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// signatureAlgorithms — допустимые алгоритмы подписи токенов. Симметричные
+// HS* не принимаются: их ключ пришлось бы хранить в JWKS рядом с открытыми.
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// JWTConfig — проверка токенов из заголовка Authorization: Bearer.
+// Пустые Issuer и Audience не проверяются.
+type JWTConfig struct {
+	JWKSFile string
+	Issuer   string
+	Audience string
+	// RoleClaim — утверждение токена с ролью клиента, по умолчанию role.
+	RoleClaim string
+	// Leeway — допустимое расхождение часов при проверке exp и nbf.
+	Leeway time.Duration
+}
+
+// JWTVerifier проверяет подпись токена открытыми ключами из локального
+// JWKS-файла. Ключ выбирается по kid из заголовка токена.
+type JWTVerifier struct {
+	cfg  JWTConfig
+	file *watchedFile
+	keys atomic.Pointer[jose.JSONWebKeySet]
+	now  func() time.Time
+}
+
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if cfg.JWKSFile == "" {
+		return nil, errors.New("jwks file is required")
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "role"
+	}
+
+	v := &JWTVerifier{
+		cfg:  cfg,
+		file: &watchedFile{path: cfg.JWKSFile},
+		now:  time.Now,
+	}
+	if err := v.Reload(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Reload перечитывает JWKS. Закрытые ключи в файле заменяются открытыми.
+func (v *JWTVerifier) Reload() error {
+	return v.file.read(func(data []byte) error {
+		var set jose.JSONWebKeySet
+		if err := json.Unmarshal(data, &set); err != nil {
+			return err
+		}
+
+		public := make([]jose.JSONWebKey, 0, len(set.Keys))
+		for i, k := range set.Keys {
+			if k.KeyID == "" {
+				return fmt.Errorf("key %d: kid is required", i)
+			}
+			if !k.IsPublic() {
+				k = k.Public()
+			}
+			if !k.Valid() || (k.Use != "" && k.Use != "sig") {
+				return fmt.Errorf("key %q: not a public signing key", k.KeyID)
+			}
+			public = append(public, k)
+		}
+
+		v.keys.Store(&jose.JSONWebKeySet{Keys: public})
+		return nil
+	})
+}
+
+func (v *JWTVerifier) Authenticate(header func(string) string) (*Principal, error) {
+	scheme, raw, ok := strings.Cut(header("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	tok, err := jwt.ParseSigned(strings.TrimSpace(raw), signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	keys := v.keys.Load().Key(tok.Headers[0].KeyID)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidCredentials, tok.Headers[0].KeyID)
+	}
+
+	var claims jwt.Claims
+	extra := map[string]any{}
+	if err := tok.Claims(keys[0].Key, &claims, &extra); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	// без exp токен действовал бы вечно
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("%w: exp is required", ErrInvalidCredentials)
+	}
+
+	expected := jwt.Expected{Issuer: v.cfg.Issuer, Time: v.now()}
+	if v.cfg.Audience != "" {
+		expected.AnyAudience = jwt.Audience{v.cfg.Audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, v.cfg.Leeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	claim, _ := extra[v.cfg.RoleClaim].(string)
+	role, err := ParseRole(claim)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	return &Principal{Name: claims.Subject, Role: role, Method: MethodJWT}, nil
+}
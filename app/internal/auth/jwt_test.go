@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jwtNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type signingKey struct {
+	kid string
+	key *ecdsa.PrivateKey
+}
+
+func newSigningKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return signingKey{kid: kid, key: key}
+}
+
+func (k signingKey) sign(t *testing.T, claims jwt.Claims, extra map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: k.key, KeyID: k.kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).Claims(extra).Serialize()
+	require.NoError(t, err)
+	return token
+}
+
+func writeJWKS(t *testing.T, path string, modTime time.Time, keys ...signingKey) {
+	t.Helper()
+
+	set := jose.JSONWebKeySet{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{Key: &k.key.PublicKey, KeyID: k.kid, Algorithm: string(jose.ES256), Use: "sig"})
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func bearer(token string) func(string) string {
+	return func(name string) string {
+		if name == "Authorization" {
+			return "Bearer " + token
+		}
+		return ""
+	}
+}
+
+func newTestVerifier(t *testing.T, keys ...signingKey) (*JWTVerifier, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, jwtNow, keys...)
+
+	v, err := NewJWTVerifier(JWTConfig{JWKSFile: path, Issuer: "https://id.example.com", Audience: "orders", Leeway: time.Minute})
+	require.NoError(t, err)
+	v.now = func() time.Time { return jwtNow }
+	return v, path
+}
+
+func validClaims() jwt.Claims {
+	return jwt.Claims{
+		Subject:  "alice",
+		Issuer:   "https://id.example.com",
+		Audience: jwt.Audience{"orders"},
+		IssuedAt: jwt.NewNumericDate(jwtNow.Add(-time.Minute)),
+		Expiry:   jwt.NewNumericDate(jwtNow.Add(time.Hour)),
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	v, _ := newTestVerifier(t, key)
+
+	p, err := v.Authenticate(bearer(key.sign(t, validClaims(), map[string]any{"role": "support"})))
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Name: "alice", Role: RoleSupport, Method: MethodJWT}, p)
+
+	_, err = v.Authenticate(func(string) string { return "" })
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+}
+
+func TestJWTVerifier_Rejects(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	v, _ := newTestVerifier(t, key)
+
+	expired := validClaims()
+	expired.Expiry = jwt.NewNumericDate(jwtNow.Add(-2 * time.Minute))
+
+	noExpiry := validClaims()
+	noExpiry.Expiry = nil
+
+	otherAudience := validClaims()
+	otherAudience.Audience = jwt.Audience{"billing"}
+
+	otherIssuer := validClaims()
+	otherIssuer.Issuer = "https://evil.example.com"
+
+	role := map[string]any{"role": "admin"}
+
+	tests := map[string]string{
+		"expired":        key.sign(t, expired, role),
+		"no exp":         key.sign(t, noExpiry, role),
+		"other audience": key.sign(t, otherAudience, role),
+		"other issuer":   key.sign(t, otherIssuer, role),
+		"no role":        key.sign(t, validClaims(), nil),
+		"unknown role":   key.sign(t, validClaims(), map[string]any{"role": "root"}),
+		"unknown kid":    newSigningKey(t, "k2").sign(t, validClaims(), role),
+		"wrong key":      signingKey{kid: "k1", key: newSigningKey(t, "k1").key}.sign(t, validClaims(), role),
+		"malformed":      "not.a.token",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Authenticate(bearer(token))
+			assert.True(t, errors.Is(err, ErrInvalidCredentials), "%v", err)
+		})
+	}
+}
+
+func TestJWTVerifier_Reload(t *testing.T) {
+	oldKey, newKey := newSigningKey(t, "old"), newSigningKey(t, "new")
+	v, path := newTestVerifier(t, oldKey)
+
+	role := map[string]any{"role": "viewer"}
+	_, err := v.Authenticate(bearer(newKey.sign(t, validClaims(), role)))
+	require.Error(t, err)
+
+	writeJWKS(t, path, jwtNow.Add(time.Minute), oldKey, newKey)
+	require.NoError(t, v.Reload())
+
+	_, err = v.Authenticate(bearer(newKey.sign(t, validClaims(), role)))
+	assert.NoError(t, err)
+	_, err = v.Authenticate(bearer(oldKey.sign(t, validClaims(), role)))
+	assert.NoError(t, err)
+}
+
+func TestJWTVerifier_RejectsSymmetricKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"kty":"oct","kid":"h","k":"c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"}]}`), 0o600))
+
+	_, err := NewJWTVerifier(JWTConfig{JWKSFile: path})
+	assert.Error(t, err)
+}
@@ -43,6 +43,25 @@ func (c *Cache[K, V]) Get(key K) (V, bool) {
 	return value, ok
 }
 
+func (c *Cache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.cache[key]; !ok {
+		return
+	}
+
+	delete(c.cache, key)
+
+	list := c.list[:0]
+	for _, k := range c.list {
+		if k != key {
+			list = append(list, k)
+		}
+	}
+	c.list = list
+}
+
 func (c *Cache[K, V]) Clear() {
 	c.mu.Lock()
 	c.cache = make(map[K]V)
@@ -52,3 +52,23 @@ func TestCache_DuplicateKey(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, 1, val)
 }
+
+func TestCache_Remove(t *testing.T) {
+	c := New[string, int](2)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Remove("a")
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	c.Add("c", 3)
+
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+}
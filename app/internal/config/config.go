@@ -1,17 +1,37 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"time"
 
+	"test-task/internal/webhook"
+
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	App         App     `yaml:"app"`
-	Retry       Retry   `yaml:"retry"`
-	Service     Service `yaml:"service"`
-	Kafka       Kafka   `yaml:"kafka"`
+	App         App        `yaml:"app"`
+	Retry       Retry      `yaml:"retry"`
+	Service     Service    `yaml:"service"`
+	Kafka       Kafka      `yaml:"kafka"`
+	Purge       Purge      `yaml:"purge"`
+	Archive     Archive    `yaml:"archive"`
+	Partitions  Partitions `yaml:"partitions"`
+	FX          FX         `yaml:"fx"`
+	Search      Search     `yaml:"search"`
+	Tracking    Tracking   `yaml:"tracking"`
+	Fraud       Fraud      `yaml:"fraud"`
+	Webhooks    Webhooks   `yaml:"webhooks"`
+	Stream      Stream     `yaml:"stream"`
+	WebSocket   WebSocket  `yaml:"websocket"`
+	GRPC        GRPC       `yaml:"grpc"`
+	GraphQL     GraphQL    `yaml:"graphql"`
+	OpenAPI     OpenAPI    `yaml:"openapi"`
+	Auth        Auth       `yaml:"auth"`
+	Redaction   Redaction  `yaml:"redaction"`
+	Encryption  Encryption `yaml:"encryption"`
 	DatabaseURL string
 }
 
@@ -21,6 +41,71 @@ type App struct {
 	MirgationDir    string
 }
 
+// GRPC — сервер gRPC рядом с HTTP; пустой порт отключает его.
+type GRPC struct {
+	Port string `yaml:"port"`
+}
+
+type GraphQL struct {
+	MaxComplexity int `yaml:"max_complexity"`
+	MaxDepth      int `yaml:"max_depth"`
+	// BatchWait — сколько загрузчик связанных данных ждёт другие ключи.
+	BatchWait time.Duration `yaml:"batch_wait"`
+}
+
+// OpenAPI — проверка запросов по спецификации; ответы проверяются
+// только с ValidateResponses, несоответствия пишутся в лог.
+type OpenAPI struct {
+	Validate          bool `yaml:"validate"`
+	ValidateResponses bool `yaml:"validate_responses"`
+}
+
+// Auth — ключи API и токены JWT. Файлы ключей перечитываются каждые
+// ReloadInterval, так что ключи меняются без перезапуска.
+type Auth struct {
+	Enabled bool `yaml:"enabled"`
+	// KeysFile — YAML-файл ключей API, в дополнение к APIKeys.
+	KeysFile       string        `yaml:"keys_file"`
+	APIKeys        []APIKey      `yaml:"api_keys"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+	JWT            JWT           `yaml:"jwt"`
+}
+
+// APIKey — ключ клиента: Hash — SHA-256 ключа в hex, Role — viewer, support или admin.
+type APIKey struct {
+	Name string `yaml:"name"`
+	Hash string `yaml:"hash"`
+	Role string `yaml:"role"`
+}
+
+// JWT — проверка токенов по локальному JWKS; пустой JWKSFile отключает токены.
+type JWT struct {
+	JWKSFile  string        `yaml:"jwks_file"`
+	Issuer    string        `yaml:"issuer"`
+	Audience  string        `yaml:"audience"`
+	RoleClaim string        `yaml:"role_claim"`
+	Leeway    time.Duration `yaml:"leeway"`
+}
+
+// Redaction — какие поля заказа скрываются в ответах клиентам каждой роли
+// и в логах: путь поля ($.delivery.phone, $.items[*].name) → mask, hash,
+// drop или keep. Для роли без правил скрываются персональные данные
+// получателя от viewer, в логах они хешируются.
+type Redaction struct {
+	Roles map[string]map[string]string `yaml:"roles"`
+	Log   map[string]string            `yaml:"log"`
+	// HashKey — ключ HMAC для hash, читается из REDACTION_HASH_KEY.
+	HashKey string
+}
+
+// Encryption — шифрование персональных данных доставки в базе. Без файла
+// keyring они пишутся открыто.
+type Encryption struct {
+	Keyring string `yaml:"keyring"`
+	// BatchSize — сколько доставок перешифровывает за раз orderctl rotate-keys.
+	BatchSize int `yaml:"batch_size"`
+}
+
 type Retry struct {
 	Backoff     string  `yaml:"backoff"`
 	MaxAttempts int     `yaml:"max_attempts"`
@@ -36,6 +121,81 @@ type Kafka struct {
 	Topic   string   `yaml:"topic"`
 }
 
+type Purge struct {
+	Enabled          bool          `yaml:"enabled"`
+	Interval         time.Duration `yaml:"interval"`
+	Retention        time.Duration `yaml:"retention"`
+	DeletedRetention time.Duration `yaml:"deleted_retention"`
+	BatchSize        int           `yaml:"batch_size"`
+	// Archive выгружает заказы старше Retention через архиватор,
+	// а не удаляет их сразу.
+	Archive bool `yaml:"archive"`
+}
+
+type Archive struct {
+	Dir       string        `yaml:"dir"`
+	OlderThan time.Duration `yaml:"older_than"`
+	BatchSize int           `yaml:"batch_size"`
+}
+
+type Partitions struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	// Ahead — число месяцев, на которые секции создаются заранее.
+	Ahead     int           `yaml:"ahead"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+type FX struct {
+	// BaseCurrency — валюта, в которой указаны курсы fx_rates и по умолчанию
+	// считаются отчёты.
+	BaseCurrency string `yaml:"base_currency"`
+	// Topic — топик Kafka с курсами, пустой отключает загрузку из Kafka.
+	Topic string `yaml:"topic"`
+}
+
+type Tracking struct {
+	// Topic — топик Kafka с событиями перевозчиков, пустой отключает загрузку.
+	Topic string `yaml:"topic"`
+}
+
+type Fraud struct {
+	// Rules — YAML-файл с правилами проверки заказов, пустой отключает проверку.
+	Rules string `yaml:"rules"`
+}
+
+type Webhooks struct {
+	Enabled   bool          `yaml:"enabled"`
+	Workers   int           `yaml:"workers"`
+	Interval  time.Duration `yaml:"interval"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Lease     time.Duration `yaml:"lease"`
+	QueueSize int           `yaml:"queue_size"`
+}
+
+type Stream struct {
+	// Buffer — сколько последних заказов хранится для продолжения с Last-Event-ID.
+	Buffer int `yaml:"buffer"`
+	// ClientBuffer — очередь клиента, при переполнении клиент отключается.
+	ClientBuffer int           `yaml:"client_buffer"`
+	Heartbeat    time.Duration `yaml:"heartbeat"`
+}
+
+type WebSocket struct {
+	// ClientBuffer — очередь изменений подписчика, при переполнении он отключается.
+	ClientBuffer int           `yaml:"client_buffer"`
+	PingInterval time.Duration `yaml:"ping_interval"`
+	// PongWait должен быть больше PingInterval.
+	PongWait     time.Duration `yaml:"pong_wait"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+type Search struct {
+	// PrivilegedToken открывает поиск по персональным данным получателя,
+	// читается из SEARCH_PRIVILEGED_TOKEN.
+	PrivilegedToken string
+}
+
 func Load(yamlConfigFilePath string) (*Config, error) {
 	cfg := &Config{}
 
@@ -50,6 +210,8 @@ func Load(yamlConfigFilePath string) (*Config, error) {
 
 	cfg.DatabaseURL = os.Getenv("DATABASE_URL")
 	cfg.App.MirgationDir = os.Getenv("MIGRATION_DIR")
+	cfg.Search.PrivilegedToken = os.Getenv("SEARCH_PRIVILEGED_TOKEN")
+	cfg.Redaction.HashKey = os.Getenv("REDACTION_HASH_KEY")
 
 	if len(cfg.Kafka.Brokers) == 0 && os.Getenv("KAFKA_BROKER") != "" {
 		cfg.Kafka.Brokers = []string{os.Getenv("KAFKA_BROKER")}
@@ -58,5 +220,49 @@ func Load(yamlConfigFilePath string) (*Config, error) {
 		cfg.Kafka.Topic = os.Getenv("KAFKA_TOPIC")
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
+
+// validate проверяет периоды и размеры фоновых задач: нулевой период
+// таймера вызывает панику, а без обработчиков или очереди задача молча
+// ничего не делает.
+func (c *Config) validate() error {
+	var errs []error
+	positive := func(name string, v int64) {
+		if v <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+
+	if c.Purge.Enabled {
+		positive("purge.interval", int64(c.Purge.Interval))
+		positive("purge.batch_size", int64(c.Purge.BatchSize))
+		if c.Purge.Archive {
+			positive("archive.batch_size", int64(c.Archive.BatchSize))
+		}
+	}
+	if c.Partitions.Enabled {
+		positive("partitions.interval", int64(c.Partitions.Interval))
+	}
+	if c.Webhooks.Enabled {
+		positive("webhooks.workers", int64(c.Webhooks.Workers))
+		positive("webhooks.interval", int64(c.Webhooks.Interval))
+		positive("webhooks.timeout", int64(c.Webhooks.Timeout))
+		// пока доставка ждёт следующей попытки, её не должна забрать другая реплика
+		if c.Webhooks.Lease <= webhook.MaxBackoff {
+			errs = append(errs, fmt.Errorf("webhooks.lease must be greater than %s", webhook.MaxBackoff))
+		}
+		positive("webhooks.queue_size", int64(c.Webhooks.QueueSize))
+	}
+	positive("stream.heartbeat", int64(c.Stream.Heartbeat))
+	positive("websocket.ping_interval", int64(c.WebSocket.PingInterval))
+	if c.WebSocket.PongWait <= c.WebSocket.PingInterval {
+		errs = append(errs, errors.New("websocket.pong_wait must be greater than websocket.ping_interval"))
+	}
+
+	return errors.Join(errs...)
+}
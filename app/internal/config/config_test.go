@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_RepoConfig(t *testing.T) {
+	_, err := Load(filepath.Join("..", "..", "..", "config.yaml"))
+	require.NoError(t, err)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	valid := "stream:\n  heartbeat: 15s\nwebsocket:\n  ping_interval: 30s\n  pong_wait: 60s\n"
+
+	tests := map[string]string{
+		"purge interval":   valid + "purge:\n  enabled: true\n  batch_size: 500\n",
+		"partitions":       valid + "partitions:\n  enabled: true\n  interval: 0s\n",
+		"webhook workers":  valid + "webhooks:\n  enabled: true\n  interval: 5s\n  timeout: 10s\n  lease: 2h\n  queue_size: 1\n",
+		"webhook lease":    valid + "webhooks:\n  enabled: true\n  workers: 4\n  interval: 5s\n  timeout: 10s\n  lease: 1h\n  queue_size: 1\n",
+		"stream heartbeat": "websocket:\n  ping_interval: 30s\n  pong_wait: 60s\n",
+		"pong wait":        "stream:\n  heartbeat: 15s\nwebsocket:\n  ping_interval: 30s\n  pong_wait: 30s\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+			_, err := Load(path)
+			assert.Error(t, err)
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(valid), 0o600))
+	_, err := Load(path)
+	assert.NoError(t, err)
+}
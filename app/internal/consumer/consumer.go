@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 
+	"test-task/internal/audit"
 	"test-task/internal/models"
+	"test-task/internal/redact"
 	"test-task/internal/retry"
 	"test-task/internal/service"
 
@@ -13,10 +15,11 @@ import (
 )
 
 type Consumer struct {
-	reader  *kafka.Reader
-	service *service.Service
-	retry   retry.Retrier
-	log     *zap.Logger
+	reader   *kafka.Reader
+	service  *service.Service
+	retry    retry.Retrier
+	log      *zap.Logger
+	redactor *redact.Redactor
 }
 
 func NewConsumer(cfg kafka.ReaderConfig, service *service.Service, retry retry.Retrier, log *zap.Logger) *Consumer {
@@ -28,6 +31,13 @@ func NewConsumer(cfg kafka.ReaderConfig, service *service.Service, retry retry.R
 	}
 }
 
+// WithRedactor задаёт, какие поля заказа скрываются в логах. Без него
+// действуют правила по умолчанию.
+func (c *Consumer) WithRedactor(r *redact.Redactor) *Consumer {
+	c.redactor = r
+	return c
+}
+
 func (c *Consumer) Run(ctx context.Context) {
 	for {
 		m, err := c.reader.ReadMessage(ctx)
@@ -42,19 +52,44 @@ func (c *Consumer) Run(ctx context.Context) {
 
 		eo := new(models.ExtendedOrder)
 		if err := json.Unmarshal(m.Value, eo); err != nil {
-			c.log.Warn("invalid json model from message", zap.Error(err), zap.ByteString("json_model", m.Value))
+			c.log.Warn("invalid json model from message", zap.Error(err), c.jsonModel(m.Value))
 			continue
 		}
 
+		// отклонённый заказ больше не читается, поэтому в лог пишется,
+		// где его найти
 		if err := models.Validate(eo); err != nil {
-			c.log.Warn("invalid model", zap.Error(err))
+			c.log.Error("invalid model, message skipped",
+				zap.String("order_uid", eo.Order.OrderUID),
+				zap.String("topic", m.Topic),
+				zap.Int("partition", m.Partition),
+				zap.Int64("offset", m.Offset),
+				zap.Error(err),
+			)
 			continue
 		}
 
+		// Недоступность базы не должна останавливать приём: заказ без оценки
+		// сохраняется, ошибка остаётся в логе.
+		if err := c.retry.Do(ctx, func(attempt int) error {
+			return c.service.ScreenOrder(ctx, eo)
+		}); err != nil {
+			if ctx.Err() != nil {
+				c.log.Info("consumer stopped by context")
+				return
+			}
+			c.log.Error("failed to screen order, saving without risk score",
+				zap.String("order_uid", eo.Order.OrderUID),
+				zap.Error(err),
+			)
+		}
+
 		c.log.Info("creating extended order...", zap.Int64("id", eo.Order.ID))
 
+		msgCtx := audit.WithActor(ctx, audit.KafkaActor(m.Topic, m.Partition, m.Offset))
+
 		if err := c.retry.Do(ctx, func(attempt int) error {
-			if err := c.service.CreateExtendedOrder(ctx, eo); err != nil {
+			if err := c.service.CreateExtendedOrder(msgCtx, eo); err != nil {
 				c.log.Warn("error on creating order",
 					zap.Int64("id", eo.Order.ID),
 					zap.Error(err),
@@ -77,6 +112,16 @@ func (c *Consumer) Run(ctx context.Context) {
 	}
 }
 
+// jsonModel — сообщение для лога со скрытыми полями заказа. Сообщение,
+// которое не разбирается как JSON, не пишется, только его размер.
+func (c *Consumer) jsonModel(value []byte) zap.Field {
+	redacted, err := c.redactor.Log().JSON(value)
+	if err != nil {
+		return zap.Int("json_model_size", len(value))
+	}
+	return zap.ByteString("json_model", redacted)
+}
+
 func (c *Consumer) Close() error {
 	return c.reader.Close()
 }
@@ -0,0 +1,125 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+	"test-task/internal/retry"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// fxMessage — курс в сообщении Kafka:
+// {"currency": "USD", "date": "2025-01-02", "rate": "101.6797"}.
+// Сообщение может содержать и массив таких объектов.
+type fxMessage struct {
+	Currency string     `json:"currency"`
+	Date     string     `json:"date"`
+	Rate     money.Rate `json:"rate"`
+}
+
+// FXConsumer загружает курсы валют из Kafka в fx_rates.
+type FXConsumer struct {
+	reader *kafka.Reader
+	repo   repository.FXRepository
+	retry  retry.Retrier
+	log    *zap.Logger
+}
+
+func NewFXConsumer(cfg kafka.ReaderConfig, repo repository.FXRepository, retry retry.Retrier, log *zap.Logger) *FXConsumer {
+	return &FXConsumer{
+		reader: kafka.NewReader(cfg),
+		repo:   repo,
+		retry:  retry,
+		log:    log,
+	}
+}
+
+func (c *FXConsumer) Run(ctx context.Context) {
+	for {
+		m, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.log.Info("fx consumer stopped by context")
+				return
+			}
+			c.log.Error("error on reading fx message", zap.Error(err))
+			continue
+		}
+
+		rates, err := parseFXMessage(m.Value)
+		if err != nil {
+			// тело сообщения не пишется, по смещению его можно найти в топике
+			c.log.Warn("invalid fx message",
+				zap.String("topic", m.Topic),
+				zap.Int("partition", m.Partition),
+				zap.Int64("offset", m.Offset),
+				zap.Int("message_size", len(m.Value)),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if err := c.retry.Do(ctx, func(attempt int) error {
+			if err := c.repo.UpsertRates(ctx, rates); err != nil {
+				c.log.Warn("error on saving fx rates", zap.Error(err), zap.Int("attempt", attempt))
+				return err
+			}
+			return nil
+		}); err != nil {
+			if ctx.Err() != nil {
+				c.log.Info("fx consumer stopped by context")
+				return
+			}
+			c.log.Error("failed to save fx rates", zap.Error(err))
+			continue
+		}
+
+		c.log.Info("fx rates saved", zap.Int("count", len(rates)))
+	}
+}
+
+func (c *FXConsumer) Close() error {
+	return c.reader.Close()
+}
+
+func parseFXMessage(value []byte) ([]*models.FXRate, error) {
+	var msgs []fxMessage
+	if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &msgs); err != nil {
+			return nil, err
+		}
+	} else {
+		var msg fxMessage
+		if err := json.Unmarshal(trimmed, &msg); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	rates := make([]*models.FXRate, 0, len(msgs))
+	for _, msg := range msgs {
+		date, err := time.Parse(time.DateOnly, msg.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		rate := &models.FXRate{Currency: msg.Currency, Date: date, Rate: msg.Rate}
+		if err := models.Validate(rate); err != nil {
+			return nil, err
+		}
+		if rate.Rate.IsZero() {
+			return nil, money.ErrInvalid
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
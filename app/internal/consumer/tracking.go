@@ -0,0 +1,110 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"test-task/internal/models"
+	"test-task/internal/retry"
+	"test-task/internal/service"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+var errNilEvent = errors.New("null delivery event")
+
+// TrackingConsumer сохраняет события перевозчиков из Kafka. Сообщение —
+// событие или массив событий:
+// {"track_number": "WBILMTESTTRACK", "carrier": "cdek", "status": "in_transit",
+// "location": "Moscow", "occurred_at": "2025-01-02T10:00:00Z"}.
+type TrackingConsumer struct {
+	reader  *kafka.Reader
+	service *service.Service
+	retry   retry.Retrier
+	log     *zap.Logger
+}
+
+func NewTrackingConsumer(cfg kafka.ReaderConfig, service *service.Service, retry retry.Retrier, log *zap.Logger) *TrackingConsumer {
+	return &TrackingConsumer{
+		reader:  kafka.NewReader(cfg),
+		service: service,
+		retry:   retry,
+		log:     log,
+	}
+}
+
+func (c *TrackingConsumer) Run(ctx context.Context) {
+	for {
+		m, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.log.Info("tracking consumer stopped by context")
+				return
+			}
+			c.log.Error("error on reading tracking message", zap.Error(err))
+			continue
+		}
+
+		events, err := parseTrackingMessage(m.Value)
+		if err != nil {
+			// тело сообщения не пишется, по смещению его можно найти в топике
+			c.log.Warn("invalid tracking message",
+				zap.String("topic", m.Topic),
+				zap.Int("partition", m.Partition),
+				zap.Int64("offset", m.Offset),
+				zap.Int("message_size", len(m.Value)),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if err := c.retry.Do(ctx, func(attempt int) error {
+			if err := c.service.AddDeliveryEvents(ctx, events); err != nil {
+				c.log.Warn("error on saving delivery events", zap.Error(err), zap.Int("attempt", attempt))
+				return err
+			}
+			return nil
+		}); err != nil {
+			if ctx.Err() != nil {
+				c.log.Info("tracking consumer stopped by context")
+				return
+			}
+			c.log.Error("failed to save delivery events", zap.Error(err))
+			continue
+		}
+	}
+}
+
+func (c *TrackingConsumer) Close() error {
+	return c.reader.Close()
+}
+
+func parseTrackingMessage(value []byte) ([]*models.DeliveryEvent, error) {
+	var events []*models.DeliveryEvent
+	if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &events); err != nil {
+			return nil, err
+		}
+	} else {
+		event := new(models.DeliveryEvent)
+		if err := json.Unmarshal(trimmed, event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	for _, event := range events {
+		if event == nil {
+			return nil, errNilEvent
+		}
+		event.ID = 0
+		if err := models.Validate(event); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,52 @@
+// Package currency — справочник валют ISO 4217 с числом знаков дробной части.
+package currency
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+)
+
+//go:embed iso4217.csv
+var registryCSV []byte
+
+type Currency struct {
+	Code    string `json:"code"`
+	Numeric string `json:"numeric"`
+	// MinorUnits — число знаков после запятой: 2 для USD, 0 для JPY, 3 для KWD.
+	MinorUnits int    `json:"minor_units"`
+	Name       string `json:"name"`
+}
+
+var registry = mustLoad(registryCSV)
+
+func mustLoad(data []byte) map[string]Currency {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("currency: read registry: %v", err))
+	}
+
+	currencies := make(map[string]Currency, len(records))
+	for _, rec := range records[1:] {
+		minor, err := strconv.Atoi(rec[2])
+		if err != nil {
+			panic(fmt.Sprintf("currency: minor units of %s: %v", rec[0], err))
+		}
+		currencies[rec[0]] = Currency{
+			Code:       rec[0],
+			Numeric:    rec[1],
+			MinorUnits: minor,
+			Name:       rec[3],
+		}
+	}
+
+	return currencies
+}
+
+// Lookup ищет валюту по буквенному коду.
+func Lookup(code string) (Currency, bool) {
+	c, ok := registry[code]
+	return c, ok
+}
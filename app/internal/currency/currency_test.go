@@ -0,0 +1,28 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	cases := map[string]int{
+		"USD": 2,
+		"RUB": 2,
+		"JPY": 0,
+		"KWD": 3,
+	}
+
+	for code, minor := range cases {
+		c, ok := Lookup(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, minor, c.MinorUnits, code)
+	}
+
+	_, ok := Lookup("ABC")
+	assert.False(t, ok)
+
+	_, ok = Lookup("usd")
+	assert.False(t, ok)
+}
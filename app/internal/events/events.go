@@ -0,0 +1,65 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Типы событий заказа.
+const (
+	OrderCreated       = "order.created"
+	OrderUpdated       = "order.updated"
+	OrderStatusChanged = "order.status_changed"
+	OrderDeleted       = "order.deleted"
+	OrderRefunded      = "order.refunded"
+	OrderReturned      = "order.returned"
+)
+
+// Types — все типы событий заказа.
+var Types = []string{
+	OrderCreated,
+	OrderUpdated,
+	OrderStatusChanged,
+	OrderDeleted,
+	OrderRefunded,
+	OrderReturned,
+}
+
+// Event — изменение заказа, уже записанное в базу. Data зависит от типа:
+// заказ целиком для created и updated, {"status": N} для status_changed,
+// возврат для refunded и returned, для deleted пусто.
+type Event struct {
+	Type       string    `json:"type"`
+	OrderID    int64     `json:"order_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data,omitempty"`
+}
+
+// Handler получает события в горутине публикующего и не должен блокироваться.
+type Handler func(Event)
+
+// Bus рассылает события сервиса подписчикам внутри процесса.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, h)
+}
+
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, h := range b.handlers {
+		h(e)
+	}
+}
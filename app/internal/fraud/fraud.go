@@ -0,0 +1,110 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"test-task/internal/models"
+)
+
+const maxScore = 100
+
+// Stats — выборки по уже сохранённым заказам для правил, которым мало
+// самого заказа.
+type Stats interface {
+	// CustomerOrders возвращает число заказов покупателя, созданных в [since, until].
+	CustomerOrders(ctx context.Context, customerID string, since, until time.Time) (int64, error)
+	// ContactCustomers возвращает число покупателей, кроме customerID, в заказах
+	// которых за [since, until] указан тот же email или телефон доставки.
+	ContactCustomers(ctx context.Context, email, phone, customerID string, since, until time.Time) (int64, error)
+}
+
+// Engine проверяет заказы по правилам.
+type Engine struct {
+	rules []*Rule
+	stats Stats
+}
+
+func NewEngine(rules *Rules, stats Stats) *Engine {
+	return &Engine{rules: rules.Rules, stats: stats}
+}
+
+// Check применяет правила к заказу до его сохранения. Окна правил отсчитываются
+// от date_created заказа. Возвращает nil, если ни одно правило не сработало.
+func (e *Engine) Check(ctx context.Context, eo *models.ExtendedOrder) (*models.Risk, error) {
+	var reasons []*models.FraudReason
+	score := 0
+
+	for _, rule := range e.rules {
+		message, err := e.apply(ctx, rule, eo)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		if message == "" {
+			continue
+		}
+
+		reasons = append(reasons, &models.FraudReason{Rule: rule.Name, Score: rule.Score, Message: message})
+		score += rule.Score
+	}
+
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	return &models.Risk{Score: min(score, maxScore), Reasons: reasons}, nil
+}
+
+// apply возвращает описание срабатывания правила или пустую строку.
+func (e *Engine) apply(ctx context.Context, rule *Rule, eo *models.ExtendedOrder) (string, error) {
+	currency := eo.Payment.Currency
+
+	switch rule.Kind {
+	case KindAmountAbove:
+		limit, ok := rule.amounts[currency]
+		if ok && eo.Payment.Amount.Cmp(limit) > 0 {
+			return fmt.Sprintf("payment amount %s %s is above %s", eo.Payment.Amount, currency, limit), nil
+		}
+
+	case KindDeepDiscount:
+		limit, ok := rule.amounts[currency]
+		if !ok {
+			return "", nil
+		}
+		for _, item := range eo.Items {
+			if item.Sale >= rule.Sale && item.Price.Cmp(limit) >= 0 {
+				return fmt.Sprintf("item %d %q with price %s %s is sold at %d%% discount",
+					item.NMID, item.Name, item.Price, currency, item.Sale), nil
+			}
+		}
+
+	case KindCustomerOrders:
+		// заказы без покупателя не относятся к одному покупателю
+		if eo.Order.CustomerID == "" {
+			return "", nil
+		}
+		until := eo.Order.DateCreated
+		n, err := e.stats.CustomerOrders(ctx, eo.Order.CustomerID, until.Add(-rule.Window), until)
+		if err != nil {
+			return "", err
+		}
+		// проверяемый заказ ещё не сохранён
+		if n+1 > rule.Max {
+			return fmt.Sprintf("customer %q has %d orders within %s", eo.Order.CustomerID, n+1, rule.Window), nil
+		}
+
+	case KindSharedContact:
+		until := eo.Order.DateCreated
+		n, err := e.stats.ContactCustomers(ctx, eo.Delivery.Email, eo.Delivery.Phone,
+			eo.Order.CustomerID, until.Add(-rule.Window), until)
+		if err != nil {
+			return "", err
+		}
+		if n >= rule.Max {
+			return fmt.Sprintf("delivery email or phone is used by %d other customers within %s", n, rule.Window), nil
+		}
+	}
+
+	return "", nil
+}
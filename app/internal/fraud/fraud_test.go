@@ -0,0 +1,159 @@
+package fraud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `
+rules:
+  - name: large_amount
+    kind: amount_above
+    score: 40
+    amounts:
+      RUB: 300000
+      usd: "3000.50"
+  - name: customer_velocity
+    kind: customer_orders
+    score: 30
+    max: 3
+    window: 1h
+  - name: shared_contact
+    kind: shared_contact
+    score: 50
+    max: 2
+    window: 720h
+  - name: deep_discount
+    kind: deep_discount
+    score: 40
+    sale: 99
+    amounts:
+      RUB: 10000
+`
+
+type fakeStats struct {
+	orders   int64
+	contacts int64
+
+	since, until time.Time
+}
+
+func (s *fakeStats) CustomerOrders(ctx context.Context, customerID string, since, until time.Time) (int64, error) {
+	s.since, s.until = since, until
+	return s.orders, nil
+}
+
+func (s *fakeStats) ContactCustomers(ctx context.Context, email, phone, customerID string, since, until time.Time) (int64, error) {
+	return s.contacts, nil
+}
+
+func order(currency string, amount int64, sale int) *models.ExtendedOrder {
+	return &models.ExtendedOrder{
+		Order: models.Order{
+			CustomerID:  "test",
+			DateCreated: time.Date(2021, time.November, 26, 6, 22, 19, 0, time.UTC),
+		},
+		Payment: models.Payment{Currency: currency, Amount: money.FromInt(amount)},
+		Delivery: models.Delivery{
+			Email: "test@gmail.com",
+			Phone: "+9720000000",
+		},
+		Items: []*models.Item{{NMID: 2389212, Name: "Mascaras", Price: money.FromInt(amount), Sale: sale}},
+	}
+}
+
+func reasons(risk *models.Risk) []string {
+	var rules []string
+	for _, reason := range risk.Reasons {
+		rules = append(rules, reason.Rule)
+	}
+	return rules
+}
+
+func TestCheck(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	require.NoError(t, err)
+
+	t.Run("Clean", func(t *testing.T) {
+		risk, err := NewEngine(rules, &fakeStats{}).Check(t.Context(), order("RUB", 1000, 30))
+		require.NoError(t, err)
+		assert.Nil(t, risk)
+	})
+
+	t.Run("Amount", func(t *testing.T) {
+		engine := NewEngine(rules, &fakeStats{})
+
+		risk, err := engine.Check(t.Context(), order("USD", 3001, 0))
+		require.NoError(t, err)
+		require.NotNil(t, risk)
+		assert.Equal(t, 40, risk.Score)
+		assert.Equal(t, []string{"large_amount"}, reasons(risk))
+		assert.Contains(t, risk.Reasons[0].Message, "3001 USD")
+
+		// для EUR порога нет
+		risk, err = engine.Check(t.Context(), order("EUR", 1000000, 0))
+		require.NoError(t, err)
+		assert.Nil(t, risk)
+	})
+
+	t.Run("Customer Orders", func(t *testing.T) {
+		stats := &fakeStats{orders: 2}
+		eo := order("RUB", 1000, 0)
+
+		risk, err := NewEngine(rules, stats).Check(t.Context(), eo)
+		require.NoError(t, err)
+		assert.Nil(t, risk)
+		assert.Equal(t, eo.Order.DateCreated, stats.until)
+		assert.Equal(t, eo.Order.DateCreated.Add(-time.Hour), stats.since)
+
+		stats.orders = 3
+		risk, err = NewEngine(rules, stats).Check(t.Context(), eo)
+		require.NoError(t, err)
+		require.NotNil(t, risk)
+		assert.Equal(t, []string{"customer_velocity"}, reasons(risk))
+
+		// заказы без покупателя не считаются
+		eo.Order.CustomerID = ""
+		risk, err = NewEngine(rules, &fakeStats{orders: 10}).Check(t.Context(), eo)
+		require.NoError(t, err)
+		assert.Nil(t, risk)
+	})
+
+	t.Run("Score Is Capped", func(t *testing.T) {
+		risk, err := NewEngine(rules, &fakeStats{orders: 10, contacts: 2}).Check(t.Context(), order("RUB", 500000, 99))
+		require.NoError(t, err)
+		require.NotNil(t, risk)
+		assert.Equal(t, 100, risk.Score)
+		assert.Equal(t, []string{"large_amount", "customer_velocity", "shared_contact", "deep_discount"}, reasons(risk))
+	})
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{"unknown kind", "rules: [{name: a, kind: b, score: 1}]", `rule "a": unknown kind "b"`},
+		{"no score", "rules: [{name: a, kind: amount_above, amounts: {RUB: 1}}]", "score must be positive"},
+		{"no amounts", "rules: [{name: a, kind: amount_above, score: 1}]", "amounts are required"},
+		{"bad amount", "rules: [{name: a, kind: amount_above, score: 1, amounts: {RUB: abc}}]", "amount for RUB"},
+		{"no window", "rules: [{name: a, kind: customer_orders, score: 1, max: 1}]", "window must be positive"},
+		{"sale", "rules: [{name: a, kind: deep_discount, score: 1, sale: 100, amounts: {RUB: 1}}]", "sale must be between"},
+		{"duplicate", "rules: [{name: a, kind: customer_orders, score: 1, max: 1, window: 1h}, {name: a, kind: customer_orders, score: 1, max: 1, window: 1h}]", "duplicate name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tt.rules))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
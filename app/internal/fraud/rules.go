@@ -0,0 +1,118 @@
+package fraud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"test-task/internal/money"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Виды правил.
+const (
+	// KindAmountAbove — сумма оплаты больше порога для её валюты.
+	KindAmountAbove = "amount_above"
+	// KindCustomerOrders — у покупателя больше Max заказов за Window,
+	// считая проверяемый.
+	KindCustomerOrders = "customer_orders"
+	// KindSharedContact — email или телефон доставки за Window встречались
+	// у Max и более других покупателей.
+	KindSharedContact = "shared_contact"
+	// KindDeepDiscount — позиция со скидкой не меньше Sale и ценой не ниже
+	// порога для валюты оплаты.
+	KindDeepDiscount = "deep_discount"
+)
+
+// Rule — правило проверки. Какие поля нужны, зависит от Kind.
+type Rule struct {
+	Name  string `yaml:"name"`
+	Kind  string `yaml:"kind"`
+	Score int    `yaml:"score"`
+	// Amounts — пороги сумм по валютам, для валют не из списка правило
+	// не применяется.
+	Amounts map[string]string `yaml:"amounts"`
+	Max     int64             `yaml:"max"`
+	Window  time.Duration     `yaml:"window"`
+	Sale    int               `yaml:"sale"`
+
+	amounts map[string]money.Amount
+}
+
+type Rules struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// LoadRules читает и проверяет правила из YAML-файла.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+func ParseRules(data []byte) (*Rules, error) {
+	rules := &Rules{}
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		if rule == nil {
+			return nil, fmt.Errorf("rule %d is empty", i)
+		}
+		if err := rule.prepare(); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	return rules, nil
+}
+
+func (r *Rule) prepare() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.Score <= 0 {
+		return errors.New("score must be positive")
+	}
+
+	switch r.Kind {
+	case KindAmountAbove, KindDeepDiscount:
+		if len(r.Amounts) == 0 {
+			return errors.New("amounts are required")
+		}
+		if r.Kind == KindDeepDiscount && (r.Sale <= 0 || r.Sale > 99) {
+			return errors.New("sale must be between 1 and 99")
+		}
+	case KindCustomerOrders, KindSharedContact:
+		if r.Max <= 0 {
+			return errors.New("max must be positive")
+		}
+		if r.Window <= 0 {
+			return errors.New("window must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+
+	r.amounts = make(map[string]money.Amount, len(r.Amounts))
+	for code, raw := range r.Amounts {
+		amount, err := money.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("amount for %s: %w", code, err)
+		}
+		r.amounts[strings.ToUpper(code)] = amount
+	}
+
+	return nil
+}
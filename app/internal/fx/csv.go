@@ -0,0 +1,74 @@
+package fx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+)
+
+// ReadCSV читает курсы из CSV с заголовком currency,date,rate:
+//
+//	currency,date,rate
+//	USD,2025-01-02,101.6797
+func ReadCSV(r io.Reader) ([]*models.FXRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if strings.Join(header, ",") != "currency,date,rate" {
+		return nil, fmt.Errorf("unexpected header %q, want currency,date,rate", strings.Join(header, ","))
+	}
+
+	var rates []*models.FXRate
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		rate, err := parseRecord(rec)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseRecord(rec []string) (*models.FXRate, error) {
+	date, err := time.Parse(time.DateOnly, rec[1])
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := money.ParseRate(rec[2])
+	if err != nil {
+		return nil, err
+	}
+
+	fxRate := &models.FXRate{
+		Currency: strings.ToUpper(rec[0]),
+		Date:     date,
+		Rate:     rate,
+	}
+
+	if err := models.Validate(fxRate); err != nil {
+		return nil, err
+	}
+
+	return fxRate, nil
+}
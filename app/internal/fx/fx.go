@@ -0,0 +1,152 @@
+// Package fx пересчитывает суммы заказов между валютами по курсам из fx_rates.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"test-task/internal/currency"
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrRateNotFound    = errors.New("fx rate not found")
+)
+
+// Converter считает кросс-курсы через базовую валюту:
+// все курсы в fx_rates указаны в ней.
+type Converter struct {
+	repo repository.FXRepository
+	base string
+}
+
+func NewConverter(repo repository.FXRepository, base string) *Converter {
+	return &Converter{repo: repo, base: base}
+}
+
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Rate возвращает курс from → to на дату at и дату самого старого из
+// использованных курсов.
+func (c *Converter) Rate(ctx context.Context, from, to string, at time.Time) (money.Rate, time.Time, error) {
+	for _, code := range []string{from, to} {
+		if _, ok := currency.Lookup(code); !ok {
+			return money.Rate{}, time.Time{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+		}
+	}
+
+	if from == to {
+		return money.One(), at, nil
+	}
+
+	fromRate, fromDate, err := c.baseRate(ctx, from, at)
+	if err != nil {
+		return money.Rate{}, time.Time{}, err
+	}
+
+	toRate, toDate, err := c.baseRate(ctx, to, at)
+	if err != nil {
+		return money.Rate{}, time.Time{}, err
+	}
+
+	rateDate := fromDate
+	if toDate.Before(rateDate) {
+		rateDate = toDate
+	}
+
+	return fromRate.Div(toRate), rateDate, nil
+}
+
+func (c *Converter) baseRate(ctx context.Context, code string, at time.Time) (money.Rate, time.Time, error) {
+	if code == c.base {
+		return money.One(), at, nil
+	}
+
+	rate, err := c.repo.GetRate(ctx, code, at)
+	if errors.Is(err, repository.ErrNotFound) {
+		return money.Rate{}, time.Time{}, fmt.Errorf("%w: %s on %s", ErrRateNotFound, code, at.Format(time.DateOnly))
+	}
+	if err != nil {
+		return money.Rate{}, time.Time{}, err
+	}
+
+	return rate.Rate, rate.Date, nil
+}
+
+// ConvertPayment пересчитывает суммы оплаты в валюту to (пустая строка —
+// базовая валюта) по курсу на дату оплаты.
+func (c *Converter) ConvertPayment(ctx context.Context, p models.Payment, to string) (models.Payment, error) {
+	if to == "" {
+		to = c.base
+	}
+	if p.Currency == to {
+		return p, nil
+	}
+
+	at := time.Unix(p.PaymentDate, 0).UTC()
+	rate, rateDate, err := c.Rate(ctx, p.Currency, to, at)
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	cur, _ := currency.Lookup(to)
+
+	converted := p
+	for _, field := range []*money.Amount{
+		&converted.Amount,
+		&converted.DeliveryCost,
+		&converted.GoodsTotal,
+		&converted.CustomFee,
+		&converted.Refunded,
+	} {
+		if *field, err = field.Convert(rate, cur.MinorUnits); err != nil {
+			return models.Payment{}, err
+		}
+	}
+
+	converted.Currency = to
+	converted.Conversion = &models.FXConversion{
+		From:     p.Currency,
+		Rate:     rate,
+		RateDate: rateDate,
+	}
+
+	return converted, nil
+}
+
+// ConvertOrder возвращает копию заказа с оплатой и ценами позиций в валюте to.
+func (c *Converter) ConvertOrder(ctx context.Context, eo *models.ExtendedOrder, to string) (*models.ExtendedOrder, error) {
+	payment, err := c.ConvertPayment(ctx, eo.Payment, to)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := *eo
+	converted.Payment = payment
+	if payment.Conversion == nil {
+		return &converted, nil
+	}
+
+	cur, _ := currency.Lookup(payment.Currency)
+
+	converted.Items = make([]*models.Item, 0, len(eo.Items))
+	for _, item := range eo.Items {
+		it := *item
+		if it.Price, err = item.Price.Convert(payment.Conversion.Rate, cur.MinorUnits); err != nil {
+			return nil, err
+		}
+		if it.TotalPrice, err = item.TotalPrice.Convert(payment.Conversion.Rate, cur.MinorUnits); err != nil {
+			return nil, err
+		}
+		converted.Items = append(converted.Items, &it)
+	}
+
+	return &converted, nil
+}
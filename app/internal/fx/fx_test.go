@@ -0,0 +1,129 @@
+package fx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	rates []*models.FXRate
+}
+
+func (r *fakeRepo) UpsertRates(ctx context.Context, rates []*models.FXRate) error {
+	r.rates = append(r.rates, rates...)
+	return nil
+}
+
+func (r *fakeRepo) GetRate(ctx context.Context, code string, date time.Time) (*models.FXRate, error) {
+	var found *models.FXRate
+	for _, rate := range r.rates {
+		if rate.Currency == code && !rate.Date.After(date) && (found == nil || rate.Date.After(found.Date)) {
+			found = rate
+		}
+	}
+	if found == nil {
+		return nil, repository.ErrNotFound
+	}
+	return found, nil
+}
+
+func day(d int) time.Time {
+	return time.Date(2021, time.November, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newRepo(t *testing.T) *fakeRepo {
+	rates, err := ReadCSV(strings.NewReader(`currency,date,rate
+USD,2021-11-25,72.5
+USD,2021-11-26,73
+EUR,2021-11-26,82.5
+JPY,2021-11-26,0.64
+`))
+	require.NoError(t, err)
+	return &fakeRepo{rates: rates}
+}
+
+func payment() models.Payment {
+	return models.Payment{
+		Currency:     "USD",
+		Amount:       money.FromInt(1817),
+		PaymentDate:  day(26).Add(6 * time.Hour).Unix(),
+		DeliveryCost: money.FromInt(1500),
+		GoodsTotal:   money.FromInt(317),
+	}
+}
+
+func TestConvertPaymentToBase(t *testing.T) {
+	c := NewConverter(newRepo(t), "RUB")
+
+	got, err := c.ConvertPayment(context.Background(), payment(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "RUB", got.Currency)
+	assert.Equal(t, money.FromInt(132641), got.Amount)
+	assert.Equal(t, money.FromInt(109500), got.DeliveryCost)
+	assert.Equal(t, money.FromInt(23141), got.GoodsTotal)
+	require.NotNil(t, got.Conversion)
+	assert.Equal(t, "USD", got.Conversion.From)
+	assert.Equal(t, day(26), got.Conversion.RateDate)
+}
+
+func TestConvertPaymentCrossRate(t *testing.T) {
+	c := NewConverter(newRepo(t), "RUB")
+
+	got, err := c.ConvertPayment(context.Background(), payment(), "JPY")
+	require.NoError(t, err)
+
+	// 1817 * 73 / 0.64, без дробной части
+	assert.Equal(t, money.FromInt(207252), got.Amount)
+	assert.Zero(t, got.Amount.Places())
+}
+
+func TestConvertPaymentErrors(t *testing.T) {
+	c := NewConverter(newRepo(t), "RUB")
+
+	_, err := c.ConvertPayment(context.Background(), payment(), "ABC")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+
+	p := payment()
+	p.PaymentDate = day(1).Unix()
+	_, err = c.ConvertPayment(context.Background(), p, "RUB")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestConvertOrderKeepsSource(t *testing.T) {
+	c := NewConverter(newRepo(t), "RUB")
+
+	eo := &models.ExtendedOrder{
+		Payment: payment(),
+		Items:   []*models.Item{{Price: money.FromInt(453), TotalPrice: money.FromInt(317)}},
+	}
+
+	got, err := c.ConvertOrder(context.Background(), eo, "EUR")
+	require.NoError(t, err)
+
+	assert.Equal(t, "EUR", got.Payment.Currency)
+	assert.Equal(t, money.MustParse("280.5"), got.Items[0].TotalPrice)
+
+	assert.Equal(t, "USD", eo.Payment.Currency)
+	assert.Equal(t, money.FromInt(317), eo.Items[0].TotalPrice)
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("code,day,value\n"))
+	assert.Error(t, err)
+
+	_, err = ReadCSV(strings.NewReader("currency,date,rate\nABC,2021-11-26,1\n"))
+	assert.Error(t, err)
+
+	_, err = ReadCSV(strings.NewReader("currency,date,rate\nUSD,26.11.2021,1\n"))
+	assert.Error(t, err)
+}
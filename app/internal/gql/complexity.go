@@ -0,0 +1,146 @@
+package gql
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// itemsPerOrder — оценка числа позиций заказа для подсчёта сложности.
+const itemsPerOrder = 10
+
+// listSizes — ожидаемое число элементов списков без аргумента limit.
+var listSizes = map[string]int{
+	"items": itemsPerOrder,
+}
+
+// Complexity оценивает стоимость запроса: каждое поле стоит 1, а стоимость
+// вложенных полей списка умножается на limit или ожидаемый размер списка.
+// Запрос, который не разбирается, оценивается в 0 — ошибку вернёт выполнение.
+func Complexity(query, operationName string, variables map[string]any) (int, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, nil
+	}
+
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return 0, nil
+	}
+
+	c := &complexity{
+		fragments: doc.Fragments,
+		variables: variables,
+		defaults:  make(map[string]*ast.Value),
+		visiting:  make(map[string]bool),
+	}
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue != nil {
+			c.defaults[def.Variable] = def.DefaultValue
+		}
+	}
+
+	return c.selectionSet(op.SelectionSet)
+}
+
+type complexity struct {
+	fragments ast.FragmentDefinitionList
+	variables map[string]any
+	defaults  map[string]*ast.Value
+	visiting  map[string]bool
+}
+
+func (c *complexity) selectionSet(set ast.SelectionSet) (int, error) {
+	total := 0
+	for _, sel := range set {
+		var cost int
+		var err error
+
+		switch sel := sel.(type) {
+		case *ast.Field:
+			cost, err = c.field(sel)
+		case *ast.InlineFragment:
+			cost, err = c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			cost, err = c.fragment(sel.Name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+	}
+	return total, nil
+}
+
+func (c *complexity) fragment(name string) (int, error) {
+	def := c.fragments.ForName(name)
+	if def == nil {
+		return 0, nil
+	}
+	if c.visiting[name] {
+		return 0, fmt.Errorf("fragment %q spreads itself", name)
+	}
+
+	c.visiting[name] = true
+	defer delete(c.visiting, name)
+
+	return c.selectionSet(def.SelectionSet)
+}
+
+func (c *complexity) field(f *ast.Field) (int, error) {
+	if len(f.SelectionSet) == 0 {
+		return 1, nil
+	}
+
+	children, err := c.selectionSet(f.SelectionSet)
+	if err != nil {
+		return 0, err
+	}
+
+	return 1 + c.listSize(f)*children, nil
+}
+
+func (c *complexity) listSize(f *ast.Field) int {
+	if size, ok := listSizes[f.Name]; ok {
+		return size
+	}
+
+	arg := f.Arguments.ForName("limit")
+	if arg == nil {
+		if f.Name == "orders" {
+			return defaultLimit
+		}
+		return 1
+	}
+
+	value := arg.Value
+	if value.Kind == ast.Variable {
+		if v, ok := c.variables[value.Raw]; ok {
+			return clampLimit(v)
+		}
+		value = c.defaults[value.Raw]
+	}
+
+	v, err := value.Value(nil)
+	if err != nil {
+		return maxLimit
+	}
+	return clampLimit(v)
+}
+
+// clampLimit приводит limit к тому, что применит резолвер.
+func clampLimit(v any) int {
+	var n int
+	switch v := v.(type) {
+	case int64:
+		n = int(v)
+	case float64:
+		n = int(v)
+	case nil:
+		return defaultLimit
+	default:
+		return maxLimit
+	}
+	return max(1, min(n, maxLimit))
+}
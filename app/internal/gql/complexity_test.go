@@ -0,0 +1,85 @@
+package gql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      int
+	}{
+		{
+			name:  "single order",
+			query: `{ order(id: 1) { id orderUid } }`,
+			want:  3,
+		},
+		{
+			name:  "items are estimated",
+			query: `{ order(id: 1) { id items { name price } } }`,
+			want:  1 + 1 + 1 + itemsPerOrder*2,
+		},
+		{
+			name:  "default limit",
+			query: `{ orders { nodes { id } } }`,
+			want:  1 + defaultLimit*(1+1),
+		},
+		{
+			name:  "literal limit",
+			query: `{ orders(limit: 5) { hasNextPage nodes { id } } }`,
+			want:  1 + 5*(1+1+1),
+		},
+		{
+			name:      "variable limit",
+			query:     `query($n: Int) { orders(limit: $n) { nodes { id } } }`,
+			variables: map[string]any{"n": float64(50)},
+			want:      1 + 50*(1+1),
+		},
+		{
+			name:  "variable default",
+			query: `query($n: Int = 3) { orders(limit: $n) { nodes { id } } }`,
+			want:  1 + 3*(1+1),
+		},
+		{
+			name:  "limit is clamped",
+			query: `{ orders(limit: 100000) { nodes { id } } }`,
+			want:  1 + maxLimit*(1+1),
+		},
+		{
+			name: "fragments",
+			query: `
+				query { orders(limit: 2) { nodes { ...order } } }
+				fragment order on Order { id ... on Order { entry } }
+			`,
+			want: 1 + 2*(1+1*2),
+		},
+		{
+			name:  "unparsable query is left to execution",
+			query: `{ orders(`,
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Complexity(tt.query, "", tt.variables)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestComplexityFragmentCycle(t *testing.T) {
+	_, err := Complexity(`
+		{ order(id: 1) { ...a } }
+		fragment a on Order { ...b }
+		fragment b on Order { ...a }
+	`, "", nil)
+
+	assert.Error(t, err)
+}
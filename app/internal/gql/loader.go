@@ -0,0 +1,124 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FetchFunc загружает значения по ключам одним запросом. Ключей,
+// которых нет в ответе, не существует.
+type FetchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader собирает ключи, запрошенные резолверами за время wait, и загружает
+// их одним вызовом fetch. Загруженные значения запоминаются до конца запроса,
+// поэтому Loader создаётся на каждый запрос.
+type Loader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    FetchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending *batch[K]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+type batch[K comparable] struct {
+	keys []K
+	once sync.Once
+}
+
+func NewLoader[K comparable, V any](ctx context.Context, fetch FetchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  make(map[K]*result[V]),
+	}
+}
+
+// Load возвращает значение по ключу; found — false, если его нет.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (value V, found bool, err error) {
+	l.mu.Lock()
+	r := l.enqueue(key)
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.found, r.err
+	case <-ctx.Done():
+		return value, false, ctx.Err()
+	}
+}
+
+// Prime добавляет ключи в очередную пачку, не дожидаясь загрузки. Резолвер
+// списка вызывает его, когда заранее знает, что понадобится каждому элементу.
+func (l *Loader[K, V]) Prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		l.enqueue(key)
+	}
+}
+
+// enqueue вызывается под l.mu.
+func (l *Loader[K, V]) enqueue(key K) *result[V] {
+	if r, ok := l.results[key]; ok {
+		return r
+	}
+
+	r := &result[V]{done: make(chan struct{})}
+	l.results[key] = r
+
+	if l.pending == nil {
+		b := &batch[K]{}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+
+	b := l.pending
+	b.keys = append(b.keys, key)
+	if len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.dispatch(b)
+	}
+
+	return r
+}
+
+func (l *Loader[K, V]) dispatch(b *batch[K]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+
+		values, err := l.fetch(l.ctx, b.keys)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for _, key := range b.keys {
+			r := l.results[key]
+			if err != nil {
+				r.err = err
+				// ошибку не запоминаем, следующий Load повторит загрузку
+				delete(l.results, key)
+			} else {
+				r.value, r.found = values[key]
+			}
+			close(r.done)
+		}
+	})
+}
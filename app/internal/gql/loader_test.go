@@ -0,0 +1,111 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderBatchesConcurrentLoads(t *testing.T) {
+	var calls atomic.Int32
+	l := NewLoader(t.Context(), func(_ context.Context, keys []int) (map[int]string, error) {
+		calls.Add(1)
+		values := make(map[int]string)
+		for _, k := range keys {
+			if k%2 == 0 {
+				values[k] = "even"
+			}
+		}
+		return values, nil
+	}, 10*time.Millisecond, 100)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, found, err := l.Load(t.Context(), i)
+			assert.NoError(t, err)
+			assert.Equal(t, i%2 == 0, found)
+			if found {
+				assert.Equal(t, "even", v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	// загруженное значение запоминается
+	_, _, err := l.Load(t.Context(), 4)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLoaderPrime(t *testing.T) {
+	var batches [][]int
+	var mu sync.Mutex
+	l := NewLoader(t.Context(), func(_ context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		batches = append(batches, append([]int(nil), keys...))
+		mu.Unlock()
+		values := make(map[int]int)
+		for _, k := range keys {
+			values[k] = k * k
+		}
+		return values, nil
+	}, time.Millisecond, 100)
+
+	l.Prime(1, 2, 3)
+
+	for _, k := range []int{3, 2, 1} {
+		v, found, err := l.Load(t.Context(), k)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, k*k, v)
+	}
+
+	assert.Equal(t, [][]int{{1, 2, 3}}, batches)
+}
+
+func TestLoaderMaxBatch(t *testing.T) {
+	var calls atomic.Int32
+	l := NewLoader(t.Context(), func(_ context.Context, keys []int) (map[int]int, error) {
+		calls.Add(1)
+		assert.LessOrEqual(t, len(keys), 2)
+		return map[int]int{}, nil
+	}, time.Hour, 2)
+
+	l.Prime(1, 2, 3, 4)
+
+	for _, k := range []int{1, 4} {
+		_, found, err := l.Load(t.Context(), k)
+		require.NoError(t, err)
+		assert.False(t, found)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestLoaderErrorIsNotCached(t *testing.T) {
+	var calls atomic.Int32
+	l := NewLoader(t.Context(), func(_ context.Context, keys []int) (map[int]int, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return map[int]int{1: 1}, nil
+	}, time.Millisecond, 100)
+
+	_, _, err := l.Load(t.Context(), 1)
+	assert.Error(t, err)
+
+	v, found, err := l.Load(t.Context(), 1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 1, v)
+}
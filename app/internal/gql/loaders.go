@@ -0,0 +1,58 @@
+package gql
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+)
+
+type loadersKey struct{}
+
+// loaders — загрузчики связанных с заказом данных на один запрос.
+type loaders struct {
+	deliveries *Loader[int64, *models.Delivery]
+	payments   *Loader[int64, *models.Payment]
+	items      *Loader[int64, []*models.Item]
+}
+
+func newLoaders(ctx context.Context, repo repository.ExtendedOrderRepository, wait time.Duration) *loaders {
+	return &loaders{
+		deliveries: NewLoader(ctx, func(ctx context.Context, ids []int64) (map[int64]*models.Delivery, error) {
+			deliveries, err := repo.Delivery().GetByOrderIDs(ctx, nil, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int64]*models.Delivery, len(deliveries))
+			for _, d := range deliveries {
+				byID[d.ID] = d
+			}
+			return byID, nil
+		}, wait, maxLimit),
+
+		payments: NewLoader(ctx, func(ctx context.Context, ids []int64) (map[int64]*models.Payment, error) {
+			payments, err := repo.Payment().GetByOrderIDs(ctx, nil, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int64]*models.Payment, len(payments))
+			for _, p := range payments {
+				byID[p.ID] = p
+			}
+			return byID, nil
+		}, wait, maxLimit),
+
+		items: NewLoader(ctx, func(ctx context.Context, ids []int64) (map[int64][]*models.Item, error) {
+			return repo.Items().GetByOrderIDs(ctx, nil, ids)
+		}, wait, maxLimit),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,267 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/redact"
+	"test-task/internal/repository"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type resolver struct {
+	repo     repository.ExtendedOrderRepository
+	redactor *redact.Redactor
+}
+
+func (r *resolver) Order(ctx context.Context, args struct {
+	ID       *graphql.ID
+	OrderUID *string
+}) (*orderResolver, error) {
+	var order *models.Order
+	var err error
+
+	switch {
+	case args.ID != nil:
+		id, perr := parseID(*args.ID)
+		if perr != nil {
+			return nil, perr
+		}
+		order, err = r.repo.Orders().Get(ctx, nil, id)
+	case args.OrderUID != nil:
+		order, err = r.repo.Orders().GetByUID(ctx, nil, *args.OrderUID)
+	default:
+		return nil, errors.New("id or orderUid is required")
+	}
+
+	if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrInvalidID) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newOrderResolver(order, r.redactor.For(ctx), loadersFrom(ctx)), nil
+}
+
+type orderFilter struct {
+	CustomerID      *string
+	DeliveryService *string
+	From            *graphql.Time
+	To              *graphql.Time
+}
+
+func (r *resolver) Orders(ctx context.Context, args struct {
+	Filter *orderFilter
+	Limit  int32
+	After  *graphql.ID
+}) (*connectionResolver, error) {
+	f := models.OrderListFilter{Limit: min(max(int(args.Limit), 1), maxLimit)}
+
+	if args.After != nil {
+		after, err := parseID(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		f.AfterID = after
+	}
+	if args.Filter != nil {
+		if args.Filter.CustomerID != nil {
+			f.CustomerID = *args.Filter.CustomerID
+		}
+		if args.Filter.DeliveryService != nil {
+			f.DeliveryService = *args.Filter.DeliveryService
+		}
+		if args.Filter.From != nil {
+			f.From = args.Filter.From.Time
+		}
+		if args.Filter.To != nil {
+			f.To = args.Filter.To.Time
+		}
+	}
+
+	// лишний заказ показывает, есть ли следующая страница
+	limit := f.Limit
+	f.Limit++
+
+	orders, err := r.repo.Orders().List(ctx, nil, f)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &connectionResolver{hasNext: len(orders) > limit}
+	if conn.hasNext {
+		orders = orders[:limit]
+	}
+
+	l := loadersFrom(ctx)
+	policy := r.redactor.For(ctx)
+	conn.nodes = make([]*orderResolver, 0, len(orders))
+	for _, o := range orders {
+		conn.nodes = append(conn.nodes, newOrderResolver(o, policy, l))
+	}
+
+	// связанные данные всей страницы загружаются одной пачкой, даже если
+	// резолверы заказов выполняются не одновременно
+	primeDeliveries := graphql.HasSelectedField(ctx, "nodes.delivery")
+	primePayments := graphql.HasSelectedField(ctx, "nodes.payment")
+	primeItems := graphql.HasSelectedField(ctx, "nodes.items")
+	for _, o := range orders {
+		if primeDeliveries {
+			l.deliveries.Prime(o.DeliveryID)
+		}
+		if primePayments {
+			l.payments.Prime(o.PaymentID)
+		}
+		if primeItems {
+			l.items.Prime(o.ID)
+		}
+	}
+
+	return conn, nil
+}
+
+type connectionResolver struct {
+	nodes   []*orderResolver
+	hasNext bool
+}
+
+func (c *connectionResolver) Nodes() []*orderResolver { return c.nodes }
+
+func (c *connectionResolver) EndCursor() *graphql.ID {
+	if len(c.nodes) == 0 {
+		return nil
+	}
+	id := graphql.ID(strconv.FormatInt(c.nodes[len(c.nodes)-1].order.ID, 10))
+	return &id
+}
+
+func (c *connectionResolver) HasNextPage() bool { return c.hasNext }
+
+// orderResolver отдаёт поля заказа, скрытые политикой клиента; связанные
+// данные загружаются по ключам исходного заказа.
+type orderResolver struct {
+	order   *models.Order
+	shown   *models.Order
+	policy  *redact.Policy
+	loaders *loaders
+}
+
+func newOrderResolver(order *models.Order, policy *redact.Policy, l *loaders) *orderResolver {
+	shown := policy.Order(&models.ExtendedOrder{Order: *order}).Order
+	return &orderResolver{order: order, shown: &shown, policy: policy, loaders: l}
+}
+
+func (r *orderResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.shown.ID, 10))
+}
+
+func (r *orderResolver) OrderUID() string          { return r.shown.OrderUID }
+func (r *orderResolver) TrackNumber() string       { return r.shown.TrackNumber }
+func (r *orderResolver) Entry() string             { return r.shown.Entry }
+func (r *orderResolver) Locale() string            { return r.shown.Locale }
+func (r *orderResolver) InternalSignature() string { return r.shown.InternalSignature }
+func (r *orderResolver) CustomerID() string        { return r.shown.CustomerID }
+func (r *orderResolver) DeliveryService() string   { return r.shown.DeliveryService }
+func (r *orderResolver) ShardKey() string          { return r.shown.ShardKey }
+func (r *orderResolver) SmID() int32               { return int32(r.shown.SMID) }
+func (r *orderResolver) OofShard() string          { return r.shown.OOFShard }
+
+func (r *orderResolver) DateCreated() graphql.Time {
+	return graphql.Time{Time: r.shown.DateCreated}
+}
+
+func (r *orderResolver) Delivery(ctx context.Context) (*deliveryResolver, error) {
+	d, found, err := r.loaders.deliveries.Load(ctx, r.order.DeliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("delivery %d of order %d not found", r.order.DeliveryID, r.order.ID)
+	}
+	shown := r.policy.Order(&models.ExtendedOrder{Delivery: *d}).Delivery
+	return &deliveryResolver{&shown}, nil
+}
+
+func (r *orderResolver) Payment(ctx context.Context) (*paymentResolver, error) {
+	p, found, err := r.loaders.payments.Load(ctx, r.order.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("payment %d of order %d not found", r.order.PaymentID, r.order.ID)
+	}
+	shown := r.policy.Order(&models.ExtendedOrder{Payment: *p}).Payment
+	return &paymentResolver{&shown}, nil
+}
+
+func (r *orderResolver) Items(ctx context.Context) ([]*itemResolver, error) {
+	items, _, err := r.loaders.items.Load(ctx, r.order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items = r.policy.Order(&models.ExtendedOrder{Items: items}).Items
+	res := make([]*itemResolver, 0, len(items))
+	for _, it := range items {
+		res = append(res, &itemResolver{it})
+	}
+	return res, nil
+}
+
+type deliveryResolver struct{ d *models.Delivery }
+
+func (r *deliveryResolver) Name() string    { return r.d.Name }
+func (r *deliveryResolver) Phone() string   { return r.d.Phone }
+func (r *deliveryResolver) Zip() string     { return r.d.Zip }
+func (r *deliveryResolver) City() string    { return r.d.City }
+func (r *deliveryResolver) Address() string { return r.d.Address }
+func (r *deliveryResolver) Region() string  { return r.d.Region }
+func (r *deliveryResolver) Email() string   { return r.d.Email }
+
+type paymentResolver struct{ p *models.Payment }
+
+func (r *paymentResolver) Transaction() string  { return r.p.Transaction }
+func (r *paymentResolver) RequestID() string    { return r.p.RequestID }
+func (r *paymentResolver) Currency() string     { return r.p.Currency }
+func (r *paymentResolver) Provider() string     { return r.p.Provider }
+func (r *paymentResolver) Amount() string       { return r.p.Amount.String() }
+func (r *paymentResolver) Bank() string         { return r.p.Bank }
+func (r *paymentResolver) DeliveryCost() string { return r.p.DeliveryCost.String() }
+func (r *paymentResolver) GoodsTotal() string   { return r.p.GoodsTotal.String() }
+func (r *paymentResolver) CustomFee() string    { return r.p.CustomFee.String() }
+
+func (r *paymentResolver) PaymentDt() graphql.Time {
+	return graphql.Time{Time: time.Unix(r.p.PaymentDate, 0).UTC()}
+}
+
+type itemResolver struct{ it *models.Item }
+
+func (r *itemResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.it.ID, 10))
+}
+
+func (r *itemResolver) ChrtID() int32       { return int32(r.it.ChrtID) }
+func (r *itemResolver) TrackNumber() string { return r.it.TrackNumber }
+func (r *itemResolver) Price() string       { return r.it.Price.String() }
+func (r *itemResolver) Rid() string         { return r.it.RID }
+func (r *itemResolver) Name() string        { return r.it.Name }
+func (r *itemResolver) Sale() int32         { return int32(r.it.Sale) }
+func (r *itemResolver) Size() string        { return r.it.Size }
+func (r *itemResolver) TotalPrice() string  { return r.it.TotalPrice.String() }
+func (r *itemResolver) NmID() int32         { return int32(r.it.NMID) }
+func (r *itemResolver) Brand() string       { return r.it.Brand }
+func (r *itemResolver) Status() int32       { return int32(r.it.Status) }
+
+func parseID(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+	return n, nil
+}
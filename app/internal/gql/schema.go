@@ -0,0 +1,77 @@
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"test-task/internal/redact"
+	"test-task/internal/repository"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Config struct {
+	// MaxComplexity — предельная оценка запроса по Complexity.
+	MaxComplexity int
+	MaxDepth      int
+	// BatchWait — сколько загрузчик ждёт другие ключи перед запросом в базу.
+	BatchWait time.Duration
+	// Redactor скрывает поля заказов по роли клиента.
+	Redactor *redact.Redactor
+}
+
+// ComplexityError — запрос отклонён до выполнения из-за оценки сложности.
+type ComplexityError struct {
+	Complexity int
+	Max        int
+}
+
+func (e *ComplexityError) Error() string {
+	return fmt.Sprintf("query complexity %d exceeds limit %d", e.Complexity, e.Max)
+}
+
+// Schema — GraphQL-схема заказов поверх репозиториев заказа, доставки,
+// оплаты и позиций.
+type Schema struct {
+	schema *graphql.Schema
+	repo   repository.ExtendedOrderRepository
+	cfg    Config
+}
+
+func New(repo repository.ExtendedOrderRepository, cfg Config) (*Schema, error) {
+	schema, err := graphql.ParseSchema(schemaString, &resolver{repo: repo, redactor: cfg.Redactor},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(cfg.MaxDepth),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{schema: schema, repo: repo, cfg: cfg}, nil
+}
+
+// Exec выполняет запрос. Ошибки выполнения возвращаются в Response,
+// ошибка — только *ComplexityError.
+func (s *Schema) Exec(ctx context.Context, query, operationName string, variables map[string]any) (*graphql.Response, error) {
+	complexity, err := Complexity(query, operationName, variables)
+	if err != nil {
+		return nil, err
+	}
+	if complexity > s.cfg.MaxComplexity {
+		return nil, &ComplexityError{Complexity: complexity, Max: s.cfg.MaxComplexity}
+	}
+
+	ctx = withLoaders(ctx, newLoaders(ctx, s.repo, s.cfg.BatchWait))
+
+	return s.schema.Exec(ctx, query, operationName, variables), nil
+}
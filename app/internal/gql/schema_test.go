@@ -0,0 +1,213 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	repository.ExtendedOrderRepository
+
+	orders   *fakeOrders
+	items    *fakeItems
+	delivery *fakeDelivery
+	payment  *fakePayment
+}
+
+func (r *fakeRepo) Orders() repository.OrdersRepository     { return r.orders }
+func (r *fakeRepo) Items() repository.ItemsRepository       { return r.items }
+func (r *fakeRepo) Delivery() repository.DeliveryRepository { return r.delivery }
+func (r *fakeRepo) Payment() repository.PaymentRepository   { return r.payment }
+
+type fakeOrders struct {
+	repository.OrdersRepository
+	orders []*models.Order
+	filter models.OrderListFilter
+}
+
+func (r *fakeOrders) Get(_ context.Context, _ pgx.Tx, id int64) (*models.Order, error) {
+	for _, o := range r.orders {
+		if o.ID == id {
+			return o, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeOrders) List(_ context.Context, _ pgx.Tx, f models.OrderListFilter) ([]*models.Order, error) {
+	r.filter = f
+	var res []*models.Order
+	for _, o := range r.orders {
+		if o.ID > f.AfterID && len(res) < f.Limit {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+type fakeItems struct {
+	repository.ItemsRepository
+	calls atomic.Int32
+}
+
+func (r *fakeItems) GetByOrderIDs(_ context.Context, _ pgx.Tx, ids []int64) (map[int64][]*models.Item, error) {
+	r.calls.Add(1)
+	items := make(map[int64][]*models.Item)
+	for _, id := range ids {
+		items[id] = []*models.Item{{ID: id * 10, OrderID: id, Name: "Mascaras", Price: money.MustParse("4.53")}}
+	}
+	return items, nil
+}
+
+type fakeDelivery struct {
+	repository.DeliveryRepository
+	calls atomic.Int32
+}
+
+func (r *fakeDelivery) GetByOrderIDs(_ context.Context, _ pgx.Tx, ids []int64) ([]*models.Delivery, error) {
+	r.calls.Add(1)
+	var res []*models.Delivery
+	for _, id := range ids {
+		res = append(res, &models.Delivery{ID: id, City: "Moscow"})
+	}
+	return res, nil
+}
+
+type fakePayment struct {
+	repository.PaymentRepository
+	calls atomic.Int32
+}
+
+func (r *fakePayment) GetByOrderIDs(_ context.Context, _ pgx.Tx, ids []int64) ([]*models.Payment, error) {
+	r.calls.Add(1)
+	var res []*models.Payment
+	for _, id := range ids {
+		res = append(res, &models.Payment{ID: id, Currency: "USD", Amount: money.MustParse("18.17")})
+	}
+	return res, nil
+}
+
+func newTestSchema(t *testing.T, n int) (*Schema, *fakeRepo) {
+	t.Helper()
+
+	repo := &fakeRepo{
+		orders:   &fakeOrders{},
+		items:    &fakeItems{},
+		delivery: &fakeDelivery{},
+		payment:  &fakePayment{},
+	}
+	for i := 1; i <= n; i++ {
+		repo.orders.orders = append(repo.orders.orders, &models.Order{
+			ID:          int64(i),
+			OrderUID:    "order",
+			DeliveryID:  int64(100 + i),
+			PaymentID:   int64(200 + i),
+			DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		})
+	}
+
+	schema, err := New(repo, Config{MaxComplexity: 5000, MaxDepth: 6, BatchWait: time.Millisecond})
+	require.NoError(t, err)
+
+	return schema, repo
+}
+
+func TestOrdersBatchesRelatedData(t *testing.T) {
+	schema, repo := newTestSchema(t, 30)
+
+	resp, err := schema.Exec(t.Context(), `
+		query($after: ID) {
+			orders(limit: 25, after: $after, filter: {customerId: "test"}) {
+				nodes { id items { name price } payment { amount currency } delivery { city } }
+				endCursor
+				hasNextPage
+			}
+		}`, "", map[string]any{"after": "2"})
+	require.NoError(t, err)
+	require.Empty(t, resp.Errors)
+
+	var data struct {
+		Orders struct {
+			Nodes []struct {
+				ID    string
+				Items []struct {
+					Name  string
+					Price string
+				}
+				Payment struct {
+					Amount string
+				}
+				Delivery struct {
+					City string
+				}
+			}
+			EndCursor   string
+			HasNextPage bool
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+
+	require.Len(t, data.Orders.Nodes, 25)
+	assert.Equal(t, "3", data.Orders.Nodes[0].ID)
+	assert.Equal(t, "4.53", data.Orders.Nodes[0].Items[0].Price)
+	assert.Equal(t, "18.17", data.Orders.Nodes[0].Payment.Amount)
+	assert.Equal(t, "Moscow", data.Orders.Nodes[0].Delivery.City)
+	assert.Equal(t, "27", data.Orders.EndCursor)
+	assert.True(t, data.Orders.HasNextPage)
+
+	assert.Equal(t, "test", repo.orders.filter.CustomerID)
+	assert.Equal(t, int64(2), repo.orders.filter.AfterID)
+
+	assert.Equal(t, int32(1), repo.items.calls.Load())
+	assert.Equal(t, int32(1), repo.payment.calls.Load())
+	assert.Equal(t, int32(1), repo.delivery.calls.Load())
+}
+
+func TestOrdersSkipsUnselectedData(t *testing.T) {
+	schema, repo := newTestSchema(t, 3)
+
+	resp, err := schema.Exec(t.Context(), `{ orders { nodes { orderUid items { name } } hasNextPage } }`, "", nil)
+	require.NoError(t, err)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"orders": {"nodes": [
+		{"orderUid": "order", "items": [{"name": "Mascaras"}]},
+		{"orderUid": "order", "items": [{"name": "Mascaras"}]},
+		{"orderUid": "order", "items": [{"name": "Mascaras"}]}
+	], "hasNextPage": false}}`, string(resp.Data))
+
+	assert.Equal(t, int32(1), repo.items.calls.Load())
+	assert.Zero(t, repo.payment.calls.Load())
+	assert.Zero(t, repo.delivery.calls.Load())
+}
+
+func TestOrder(t *testing.T) {
+	schema, _ := newTestSchema(t, 1)
+
+	resp, err := schema.Exec(t.Context(), `{ found: order(id: 1) { id dateCreated } missing: order(id: 5) { id } }`, "", nil)
+	require.NoError(t, err)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"found": {"id": "1", "dateCreated": "2021-11-26T06:22:19Z"}, "missing": null}`, string(resp.Data))
+}
+
+func TestComplexityLimit(t *testing.T) {
+	schema, repo := newTestSchema(t, 1)
+	schema.cfg.MaxComplexity = 100
+
+	_, err := schema.Exec(t.Context(), `{ orders(limit: 100) { nodes { id items { name } } } }`, "", nil)
+
+	var complexity *ComplexityError
+	require.ErrorAs(t, err, &complexity)
+	assert.Greater(t, complexity.Complexity, 100)
+	assert.Zero(t, repo.orders.filter.Limit, "query must not reach the database")
+}
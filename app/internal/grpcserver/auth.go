@@ -0,0 +1,104 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	ordersv1 "test-task/api/orders/v1"
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/redact"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodRoles — роли, нужные для методов OrderService. Health и reflection
+// доступны без аутентификации.
+var methodRoles = map[string]auth.Role{
+	ordersv1.OrderService_GetOrder_FullMethodName:      auth.RoleViewer,
+	ordersv1.OrderService_GetOrderByUID_FullMethodName: auth.RoleViewer,
+	ordersv1.OrderService_ListOrders_FullMethodName:    auth.RoleViewer,
+	ordersv1.OrderService_CreateOrder_FullMethodName:   auth.RoleSupport,
+}
+
+// WithAuth включает проверку ключей API и токенов из метаданных вызова:
+// x-api-key и authorization, как заголовки HTTP API.
+func (s *Server) WithAuth(a auth.Authenticator) *Server {
+	s.auth = a
+	return s
+}
+
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	role, ok := methodRoles[method]
+	if !ok {
+		return ctx, nil
+	}
+
+	p := auth.Anonymous
+	if s.auth != nil {
+		md, _ := metadata.FromIncomingContext(ctx)
+		header := func(name string) string {
+			if v := md.Get(name); len(v) > 0 {
+				return v[0]
+			}
+			return ""
+		}
+
+		var err error
+		if p, err = s.auth.Authenticate(header); err != nil {
+			if !errors.Is(err, auth.ErrNoCredentials) {
+				s.log.Warn("authentication failed", zap.String("method", method), zap.Error(err))
+			}
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+	}
+
+	if !p.Role.Allows(role) {
+		s.log.Warn("access denied", zap.String("principal", p.Name), zap.String("role", string(p.Role)), zap.String("method", method))
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+func (s *Server) unaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authStream подменяет контекст потока контекстом с клиентом.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// WithRedactor задаёт, какие поля заказа скрываются от клиентов каждой
+// роли. Без него действуют правила по умолчанию.
+func (s *Server) WithRedactor(r *redact.Redactor) *Server {
+	s.redactor = r
+	return s
+}
+
+// visible скрывает поля заказа по политике роли клиента.
+func (s *Server) visible(ctx context.Context, eo *models.ExtendedOrder) *models.ExtendedOrder {
+	return s.redactor.For(ctx).Order(eo)
+}
@@ -0,0 +1,182 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+
+	ordersv1 "test-task/api/orders/v1"
+	"test-task/internal/models"
+	"test-task/internal/money"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var errMissingOrder = errors.New("order is required")
+
+func toProto(eo *models.ExtendedOrder) *ordersv1.ExtendedOrder {
+	o, d, p := eo.Order, eo.Delivery, eo.Payment
+
+	items := make([]*ordersv1.Item, 0, len(eo.Items))
+	for _, it := range eo.Items {
+		items = append(items, &ordersv1.Item{
+			Id:          it.ID,
+			OrderId:     it.OrderID,
+			ChrtId:      int32(it.ChrtID),
+			TrackNumber: it.TrackNumber,
+			Price:       it.Price.String(),
+			Rid:         it.RID,
+			Name:        it.Name,
+			Sale:        int32(it.Sale),
+			Size:        it.Size,
+			TotalPrice:  it.TotalPrice.String(),
+			NmId:        int32(it.NMID),
+			Brand:       it.Brand,
+			Status:      int32(it.Status),
+		})
+	}
+
+	return &ordersv1.ExtendedOrder{
+		Order: &ordersv1.Order{
+			Id:                o.ID,
+			OrderUid:          o.OrderUID,
+			TrackNumber:       o.TrackNumber,
+			Entry:             o.Entry,
+			DeliveryId:        o.DeliveryID,
+			PaymentId:         o.PaymentID,
+			Locale:            o.Locale,
+			InternalSignature: o.InternalSignature,
+			CustomerId:        o.CustomerID,
+			DeliveryService:   o.DeliveryService,
+			Shardkey:          o.ShardKey,
+			SmId:              int32(o.SMID),
+			DateCreated:       timestamppb.New(o.DateCreated),
+			OofShard:          o.OOFShard,
+		},
+		Delivery: &ordersv1.Delivery{
+			Id:      d.ID,
+			Name:    d.Name,
+			Phone:   d.Phone,
+			Zip:     d.Zip,
+			City:    d.City,
+			Address: d.Address,
+			Region:  d.Region,
+			Email:   d.Email,
+		},
+		Payment: &ordersv1.Payment{
+			Id:           p.ID,
+			Transaction:  p.Transaction,
+			RequestId:    p.RequestID,
+			Currency:     p.Currency,
+			Provider:     p.Provider,
+			Amount:       p.Amount.String(),
+			PaymentDt:    p.PaymentDate,
+			Bank:         p.Bank,
+			DeliveryCost: p.DeliveryCost.String(),
+			GoodsTotal:   p.GoodsTotal.String(),
+			CustomFee:    p.CustomFee.String(),
+			Refunded:     p.Refunded.String(),
+			NetAmount:    p.NetAmount().String(),
+		},
+		Items: items,
+	}
+}
+
+// fromProto собирает заказ для создания. Идентификаторы и поля только
+// для чтения игнорируются.
+func fromProto(pb *ordersv1.ExtendedOrder) (*models.ExtendedOrder, error) {
+	if pb == nil || pb.GetOrder() == nil {
+		return nil, errMissingOrder
+	}
+
+	o, d, p := pb.GetOrder(), pb.GetDelivery(), pb.GetPayment()
+
+	eo := &models.ExtendedOrder{
+		Order: models.Order{
+			OrderUID:          o.GetOrderUid(),
+			TrackNumber:       o.GetTrackNumber(),
+			Entry:             o.GetEntry(),
+			Locale:            o.GetLocale(),
+			InternalSignature: o.GetInternalSignature(),
+			CustomerID:        o.GetCustomerId(),
+			DeliveryService:   o.GetDeliveryService(),
+			ShardKey:          o.GetShardkey(),
+			SMID:              int(o.GetSmId()),
+			OOFShard:          o.GetOofShard(),
+		},
+		Delivery: models.Delivery{
+			Name:    d.GetName(),
+			Phone:   d.GetPhone(),
+			Zip:     d.GetZip(),
+			City:    d.GetCity(),
+			Address: d.GetAddress(),
+			Region:  d.GetRegion(),
+			Email:   d.GetEmail(),
+		},
+		Payment: models.Payment{
+			Transaction: p.GetTransaction(),
+			RequestID:   p.GetRequestId(),
+			Currency:    p.GetCurrency(),
+			Provider:    p.GetProvider(),
+			PaymentDate: p.GetPaymentDt(),
+			Bank:        p.GetBank(),
+		},
+	}
+	if o.GetDateCreated() != nil {
+		eo.Order.DateCreated = o.GetDateCreated().AsTime()
+	}
+
+	if err := parseAmounts(map[string]amountField{
+		"payment.amount":        {p.GetAmount(), &eo.Payment.Amount},
+		"payment.delivery_cost": {p.GetDeliveryCost(), &eo.Payment.DeliveryCost},
+		"payment.goods_total":   {p.GetGoodsTotal(), &eo.Payment.GoodsTotal},
+		"payment.custom_fee":    {p.GetCustomFee(), &eo.Payment.CustomFee},
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, it := range pb.GetItems() {
+		item := &models.Item{
+			ChrtID:      int(it.GetChrtId()),
+			TrackNumber: it.GetTrackNumber(),
+			RID:         it.GetRid(),
+			Name:        it.GetName(),
+			Sale:        int(it.GetSale()),
+			Size:        it.GetSize(),
+			NMID:        int(it.GetNmId()),
+			Brand:       it.GetBrand(),
+			Status:      int(it.GetStatus()),
+		}
+
+		if err := parseAmounts(map[string]amountField{
+			fmt.Sprintf("items[%d].price", i):       {it.GetPrice(), &item.Price},
+			fmt.Sprintf("items[%d].total_price", i): {it.GetTotalPrice(), &item.TotalPrice},
+		}); err != nil {
+			return nil, err
+		}
+
+		eo.Items = append(eo.Items, item)
+	}
+
+	return eo, nil
+}
+
+type amountField struct {
+	value string
+	dst   *money.Amount
+}
+
+// parseAmounts разбирает десятичные суммы. Пустая сумма остаётся нулевой,
+// обязательность проверяет валидация.
+func parseAmounts(fields map[string]amountField) error {
+	for name, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		amount, err := money.Parse(f.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*f.dst = amount
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+
+	ordersv1 "test-task/api/orders/v1"
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/redact"
+	"test-task/internal/retry"
+	"test-task/internal/service"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Server — gRPC API заказов поверх того же service.Service, что и HTTP.
+type Server struct {
+	ordersv1.UnimplementedOrderServiceServer
+
+	service *service.Service
+	retry   retry.Retrier
+	log     *zap.Logger
+
+	grpc   *grpc.Server
+	health *health.Server
+
+	auth     auth.Authenticator
+	redactor *redact.Redactor
+}
+
+func New(service *service.Service, retry retry.Retrier, log *zap.Logger) *Server {
+	s := &Server{
+		service: service,
+		retry:   retry,
+		log:     log,
+		health:  health.NewServer(),
+	}
+	s.grpc = grpc.NewServer(
+		grpc.UnaryInterceptor(s.unaryAuth),
+		grpc.StreamInterceptor(s.streamAuth),
+	)
+
+	ordersv1.RegisterOrderServiceServer(s.grpc, s)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+
+	s.health.SetServingStatus(ordersv1.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	return s
+}
+
+// Serve принимает соединения на addr до вызова Stop.
+func (s *Server) Serve(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("grpc server started", zap.String("addr", lis.Addr().String()))
+
+	return s.grpc.Serve(lis)
+}
+
+// Stop дожидается завершения текущих вызовов, но не дольше ctx.
+func (s *Server) Stop(ctx context.Context) {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.ExtendedOrder, error) {
+	var eo *models.ExtendedOrder
+	if err := s.retry.Do(ctx, func(attempt int) error {
+		var err error
+		eo, err = s.service.GetExtendedOrder(ctx, req.GetId())
+		if err != nil {
+			s.log.Warn("error on getting order", zap.Int64("id", req.GetId()), zap.Error(err), zap.Int("attempt", attempt))
+		}
+		return err
+	}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(s.visible(ctx, eo)), nil
+}
+
+func (s *Server) GetOrderByUID(ctx context.Context, req *ordersv1.GetOrderByUIDRequest) (*ordersv1.ExtendedOrder, error) {
+	if req.GetOrderUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_uid is required")
+	}
+
+	var eo *models.ExtendedOrder
+	if err := s.retry.Do(ctx, func(attempt int) error {
+		var err error
+		eo, err = s.service.GetExtendedOrderByUID(ctx, req.GetOrderUid())
+		if err != nil {
+			s.log.Warn("error on getting order by uid", zap.String("order_uid", req.GetOrderUid()), zap.Error(err), zap.Int("attempt", attempt))
+		}
+		return err
+	}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(s.visible(ctx, eo)), nil
+}
+
+// ListOrders не повторяет чтение после отправки первых заказов: клиент
+// продолжает поток сам, передав after_id последнего полученного заказа.
+func (s *Server) ListOrders(req *ordersv1.ListOrdersRequest, stream grpc.ServerStreamingServer[ordersv1.ExtendedOrder]) error {
+	if req.GetLimit() < 0 || req.GetAfterId() < 0 {
+		return status.Error(codes.InvalidArgument, "limit and after_id must not be negative")
+	}
+
+	f := models.OrderListFilter{
+		CustomerID:      req.GetCustomerId(),
+		DeliveryService: req.GetDeliveryService(),
+		AfterID:         req.GetAfterId(),
+		Limit:           int(req.GetLimit()),
+	}
+	if req.GetFrom() != nil {
+		f.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		f.To = req.GetTo().AsTime()
+	}
+
+	err := s.service.EachOrder(stream.Context(), f, func(eo *models.ExtendedOrder) error {
+		return stream.Send(toProto(s.visible(stream.Context(), eo)))
+	})
+	if err != nil {
+		s.log.Warn("error on listing orders", zap.Error(err))
+		return toStatus(err)
+	}
+
+	return nil
+}
+
+func (s *Server) CreateOrder(ctx context.Context, req *ordersv1.CreateOrderRequest) (*ordersv1.ExtendedOrder, error) {
+	eo, err := fromProto(req.GetOrder())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := models.Validate(eo); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// как и при приёме из Kafka, заказ без оценки риска всё равно сохраняется
+	if err := s.retry.Do(ctx, func(attempt int) error {
+		return s.service.ScreenOrder(ctx, eo)
+	}); err != nil {
+		s.log.Error("failed to screen order, saving without risk score",
+			zap.String("order_uid", eo.Order.OrderUID),
+			zap.Error(err),
+		)
+	}
+
+	if err := s.retry.Do(ctx, func(attempt int) error {
+		err := s.service.CreateExtendedOrder(ctx, eo)
+		if err != nil {
+			s.log.Warn("error on creating order", zap.String("order_uid", eo.Order.OrderUID), zap.Error(err), zap.Int("attempt", attempt))
+		}
+		return err
+	}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(s.visible(ctx, eo)), nil
+}
@@ -0,0 +1,212 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	ordersv1 "test-task/api/orders/v1"
+	"test-task/internal/mocks"
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+	"test-task/internal/retry"
+	"test-task/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, repo repository.ExtendedOrderRepository) *grpc.ClientConn {
+	t.Helper()
+
+	svc := service.NewService(nil, repo, 10, zap.NewNop())
+	srv := New(svc, retry.New(retry.WithMaxAttempts(1)), zap.NewNop())
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.grpc.Serve(lis)
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func testOrder(id int64) *models.ExtendedOrder {
+	return &models.ExtendedOrder{
+		Order: models.Order{
+			ID:              id,
+			OrderUID:        "b563feb7b2b84b6test",
+			TrackNumber:     "WBILMTESTTRACK",
+			Entry:           "WBIL",
+			Locale:          "en",
+			CustomerID:      "test",
+			DeliveryService: "meest",
+			ShardKey:        "9",
+			SMID:            99,
+			DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+			OOFShard:        "1",
+		},
+		Delivery: models.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: models.Payment{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay",
+			Amount: money.MustParse("18.17"), PaymentDate: 1637907727, Bank: "alpha",
+			DeliveryCost: money.MustParse("15"), GoodsTotal: money.MustParse("3.17"),
+		},
+		Items: []*models.Item{{
+			ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: money.MustParse("4.53"),
+			RID: "ab4219087a764ae0btest", Name: "Mascaras", Sale: 30, Size: "0",
+			TotalPrice: money.MustParse("3.17"), NMID: 2389212, Brand: "Vivienne Sabo", Status: 202,
+		}},
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockExtendedOrderRepository(ctrl)
+	client := ordersv1.NewOrderServiceClient(newTestClient(t, repo))
+
+	repo.EXPECT().GetExtendedOrder(gomock.Any(), int64(1)).Return(testOrder(1), nil)
+	repo.EXPECT().GetExtendedOrder(gomock.Any(), int64(2)).Return(nil, repository.ErrNotFound)
+
+	got, err := client.GetOrder(t.Context(), &ordersv1.GetOrderRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "b563feb7b2b84b6test", got.GetOrder().GetOrderUid())
+	assert.Equal(t, "18.17", got.GetPayment().GetAmount())
+	assert.Equal(t, "18.17", got.GetPayment().GetNetAmount())
+	require.Len(t, got.GetItems(), 1)
+	assert.Equal(t, "4.53", got.GetItems()[0].GetPrice())
+
+	_, err = client.GetOrder(t.Context(), &ordersv1.GetOrderRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetOrderByUID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockExtendedOrderRepository(ctrl)
+	client := ordersv1.NewOrderServiceClient(newTestClient(t, repo))
+
+	repo.EXPECT().GetExtendedOrderByUID(gomock.Any(), "b563feb7b2b84b6test").Return(testOrder(1), nil)
+
+	got, err := client.GetOrderByUID(t.Context(), &ordersv1.GetOrderByUIDRequest{OrderUid: "b563feb7b2b84b6test"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.GetOrder().GetId())
+
+	_, err = client.GetOrderByUID(t.Context(), &ordersv1.GetOrderByUIDRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockExtendedOrderRepository(ctrl)
+	client := ordersv1.NewOrderServiceClient(newTestClient(t, repo))
+
+	repo.EXPECT().
+		ListExtendedOrders(gomock.Any(), models.OrderListFilter{CustomerID: "test", AfterID: 10, Limit: 2}).
+		Return([]*models.ExtendedOrder{testOrder(11), testOrder(12)}, nil)
+
+	stream, err := client.ListOrders(t.Context(), &ordersv1.ListOrdersRequest{CustomerId: "test", AfterId: 10, Limit: 2})
+	require.NoError(t, err)
+
+	var ids []int64
+	for {
+		eo, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, eo.GetOrder().GetId())
+	}
+	assert.Equal(t, []int64{11, 12}, ids)
+}
+
+func TestListOrdersError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockExtendedOrderRepository(ctrl)
+	client := ordersv1.NewOrderServiceClient(newTestClient(t, repo))
+
+	repo.EXPECT().ListExtendedOrders(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection reset"))
+
+	stream, err := client.ListOrders(t.Context(), &ordersv1.ListOrdersRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestListOrdersInvalidFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockExtendedOrderRepository(ctrl)
+	client := ordersv1.NewOrderServiceClient(newTestClient(t, repo))
+
+	repo.EXPECT().ListExtendedOrders(gomock.Any(), gomock.Any()).Return(nil, repository.ErrInvalidFilter)
+
+	stream, err := client.ListOrders(t.Context(), &ordersv1.ListOrdersRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockExtendedOrderRepository(ctrl)
+	client := ordersv1.NewOrderServiceClient(newTestClient(t, repo))
+
+	order := toProto(testOrder(0))
+
+	repo.EXPECT().
+		CreateExtendedOrder(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, eo *models.ExtendedOrder) error {
+			assert.Equal(t, money.MustParse("18.17"), eo.Payment.Amount)
+			assert.Equal(t, money.MustParse("4.53"), eo.Items[0].Price)
+			eo.Order.ID = 42
+			return nil
+		})
+
+	got, err := client.CreateOrder(t.Context(), &ordersv1.CreateOrderRequest{Order: order})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), got.GetOrder().GetId())
+
+	order.Payment.Amount = "abc"
+	_, err = client.CreateOrder(t.Context(), &ordersv1.CreateOrderRequest{Order: order})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	order.Payment.Amount = "18.17"
+	order.Delivery.Email = "not an email"
+	_, err = client.CreateOrder(t.Context(), &ordersv1.CreateOrderRequest{Order: order})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateOrder(t.Context(), &ordersv1.CreateOrderRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHealth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := healthpb.NewHealthClient(newTestClient(t, mocks.NewMockExtendedOrderRepository(ctrl)))
+
+	resp, err := client.Check(t.Context(), &healthpb.HealthCheckRequest{Service: ordersv1.OrderService_ServiceDesc.ServiceName})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
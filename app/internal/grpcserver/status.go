@@ -0,0 +1,43 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"test-task/internal/repository"
+	"test-task/internal/retry"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus переводит ошибки сервиса и репозитория в коды gRPC.
+// Текст внутренних ошибок клиенту не передаётся.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	// текст ошибки — без пометки повторов retry.Do
+	msg := retry.Cause(err).Error()
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, repository.ErrNoRowsAffected):
+		return status.Error(codes.NotFound, "order not found")
+	case errors.Is(err, repository.ErrInvalidID), errors.Is(err, repository.ErrNilValue),
+		errors.Is(err, repository.ErrInvalidFilter):
+		return status.Error(codes.InvalidArgument, msg)
+	case errors.Is(err, repository.ErrDuplicate):
+		return status.Error(codes.AlreadyExists, "order already exists")
+	case errors.Is(err, repository.ErrForeignKeyViolation):
+		return status.Error(codes.FailedPrecondition, msg)
+	case errors.Is(err, repository.ErrLockNotAcquired):
+		return status.Error(codes.Aborted, msg)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, msg)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, msg)
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAnalyticsPeriod = 30 * 24 * time.Hour
+	defaultTopLimit        = 10
+	maxTopLimit            = 100
+)
+
+// analyticsFilter читает from и to (YYYY-MM-DD, обе даты включительно)
+// и currency. По умолчанию — последние 30 дней в базовой валюте.
+func analyticsFilter(c echo.Context) (models.AnalyticsFilter, error) {
+	now := time.Now().UTC()
+	f := models.AnalyticsFilter{
+		From:     now.Add(-defaultAnalyticsPeriod).Truncate(24 * time.Hour),
+		To:       now.Truncate(24*time.Hour).AddDate(0, 0, 1),
+		Currency: strings.ToUpper(c.QueryParam("currency")),
+	}
+
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return f, errors.New("from must be a date in YYYY-MM-DD format")
+		}
+		f.From = t
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return f, errors.New("to must be a date in YYYY-MM-DD format")
+		}
+		f.To = t.AddDate(0, 0, 1)
+	}
+
+	if !f.From.Before(f.To) {
+		return f, errors.New("from must not be after to")
+	}
+
+	return f, nil
+}
+
+func topLimit(c echo.Context) (int, error) {
+	raw := c.QueryParam("limit")
+	if raw == "" {
+		return defaultTopLimit, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > maxTopLimit {
+		return 0, errors.New("limit must be between 1 and 100")
+	}
+	return limit, nil
+}
+
+// analytics выполняет отчёт report с повторами и отдаёт его результат.
+func (h *Handler) analytics(c echo.Context, name string, report func(f models.AnalyticsFilter) (any, error)) error {
+	f, err := analyticsFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var result any
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if result, err = report(f); err != nil {
+			h.log.Warn("error on building report", zap.String("report", name), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, repository.ErrInvalidFilter) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		h.log.Error("error on building report", zap.String("report", name), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) Revenue(c echo.Context) error {
+	interval := c.QueryParam("interval")
+	if interval == "" {
+		interval = "day"
+	}
+
+	return h.analytics(c, "revenue", func(f models.AnalyticsFilter) (any, error) {
+		return h.service.Revenue(c.Request().Context(), f, interval)
+	})
+}
+
+func (h *Handler) TopBrands(c echo.Context) error {
+	limit, err := topLimit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return h.analytics(c, "top_brands", func(f models.AnalyticsFilter) (any, error) {
+		return h.service.TopBrands(c.Request().Context(), f, limit)
+	})
+}
+
+func (h *Handler) TopProducts(c echo.Context) error {
+	limit, err := topLimit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return h.analytics(c, "top_products", func(f models.AnalyticsFilter) (any, error) {
+		return h.service.TopProducts(c.Request().Context(), f, limit)
+	})
+}
+
+func (h *Handler) Basket(c echo.Context) error {
+	return h.analytics(c, "basket", func(f models.AnalyticsFilter) (any, error) {
+		return h.service.Basket(c.Request().Context(), f)
+	})
+}
+
+func (h *Handler) RevenueSplit(c echo.Context) error {
+	by := c.QueryParam("by")
+
+	return h.analytics(c, "revenue_split", func(f models.AnalyticsFilter) (any, error) {
+		return h.service.RevenueSplit(c.Request().Context(), f, by)
+	})
+}
+
+func (h *Handler) SaleDistribution(c echo.Context) error {
+	return h.analytics(c, "sales", func(f models.AnalyticsFilter) (any, error) {
+		return h.service.SaleDistribution(c.Request().Context(), f)
+	})
+}
+
+func (h *Handler) registerAnalyticsRoutes(e *echo.Echo) {
+	g := e.Group("/analytics", h.require(auth.RoleViewer))
+	g.GET("/revenue", h.Revenue)
+	g.GET("/top-brands", h.TopBrands)
+	g.GET("/top-products", h.TopProducts)
+	g.GET("/basket", h.Basket)
+	g.GET("/revenue-split", h.RevenueSplit)
+	g.GET("/sales", h.SaleDistribution)
+}
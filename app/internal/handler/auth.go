@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/redact"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+// WithAuth включает проверку ключей API и токенов. Без неё каждому
+// клиенту доступно всё, как с ролью admin.
+func (h *Handler) WithAuth(a auth.Authenticator) *Handler {
+	h.auth = a
+	return h
+}
+
+// require пропускает только клиентов с ролью не ниже role и кладёт
+// клиента в контекст запроса.
+func (h *Handler) require(role auth.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := auth.Anonymous
+			if h.auth != nil {
+				var err error
+				if p, err = h.auth.Authenticate(c.Request().Header.Get); err != nil {
+					if !errors.Is(err, auth.ErrNoCredentials) {
+						h.log.Warn("authentication failed", zap.String("remote_addr", c.RealIP()), zap.Error(err))
+					}
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+					return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Unauthorized"})
+				}
+			}
+
+			if !p.Role.Allows(role) {
+				h.log.Warn("access denied",
+					zap.String("principal", p.Name),
+					zap.String("role", string(p.Role)),
+					zap.String("required", string(role)),
+					zap.String("path", c.Path()),
+				)
+				return c.JSON(http.StatusForbidden, map[string]string{"message": "Forbidden"})
+			}
+
+			c.SetRequest(c.Request().WithContext(auth.WithPrincipal(c.Request().Context(), p)))
+			return next(c)
+		}
+	}
+}
+
+// WithRedactor задаёт, какие поля заказа скрываются от клиентов каждой
+// роли. Без него действуют правила по умолчанию.
+func (h *Handler) WithRedactor(r *redact.Redactor) *Handler {
+	h.redactor = r
+	return h
+}
+
+// visible скрывает поля заказа по политике роли клиента.
+func (h *Handler) visible(c echo.Context, eo *models.ExtendedOrder) *models.ExtendedOrder {
+	return h.redactor.For(c.Request().Context()).Order(eo)
+}
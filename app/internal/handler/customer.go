@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pagination читает limit (по умолчанию 20, не больше 100) и offset.
+func pagination(c echo.Context) (limit, offset int, err error) {
+	limit = defaultPageLimit
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return 0, 0, errors.New("limit must be between 1 and 100")
+		}
+	}
+
+	if raw := c.QueryParam("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func (h *Handler) CustomerOrders(c echo.Context) error {
+	customerID := c.Param("customer_id")
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var page *models.CustomerOrders
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if page, err = h.service.GetCustomerOrders(c.Request().Context(), customerID, limit, offset); err != nil {
+			h.log.Warn("error on getting customer orders", zap.String("customer_id", customerID), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		h.log.Error("error on getting customer orders", zap.String("customer_id", customerID), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	shown := *page
+	shown.Orders = make([]*models.ExtendedOrder, 0, len(page.Orders))
+	for _, eo := range page.Orders {
+		shown.Orders = append(shown.Orders, h.visible(c, eo))
+	}
+
+	return c.JSON(http.StatusOK, &shown)
+}
+
+func (h *Handler) CustomerSummary(c echo.Context) error {
+	customerID := c.Param("customer_id")
+
+	var summary *models.CustomerSummary
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if summary, err = h.service.GetCustomerSummary(c.Request().Context(), customerID); err != nil {
+			h.log.Warn("error on getting customer summary", zap.String("customer_id", customerID), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": "Customer has no orders"})
+		}
+		h.log.Error("error on getting customer summary", zap.String("customer_id", customerID), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+func (h *Handler) registerCustomerRoutes(e *echo.Echo) {
+	g := e.Group("/customers", h.require(auth.RoleViewer))
+	g.GET("/:customer_id/orders", h.CustomerOrders)
+	g.GET("/:customer_id/summary", h.CustomerSummary)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"test-task/internal/models"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) FlaggedOrders(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	minScore := 1
+	if raw := c.QueryParam("min_score"); raw != "" {
+		minScore, err = strconv.Atoi(raw)
+		if err != nil || minScore < 1 || minScore > 100 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "min_score must be between 1 and 100"})
+		}
+	}
+
+	var page *models.FlaggedOrders
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if page, err = h.service.ListFlaggedOrders(c.Request().Context(), minScore, limit, offset); err != nil {
+			h.log.Warn("error on listing flagged orders", zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		h.log.Error("error on listing flagged orders", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"test-task/internal/gql"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+// WithGraphQL подключает эндпоинт /graphql.
+func (h *Handler) WithGraphQL(schema *gql.Schema) *Handler {
+	h.graphql = schema
+	return h
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQL выполняет запрос к схеме заказов. Ошибки выполнения возвращаются
+// в поле errors с кодом 200, как принято в GraphQL.
+func (h *Handler) GraphQL(c echo.Context) error {
+	if h.graphql == nil {
+		return c.JSON(http.StatusNotImplemented, map[string]string{"message": "GraphQL is disabled"})
+	}
+
+	var req graphQLRequest
+	if err := c.Bind(&req); err != nil || req.Query == "" {
+		return c.JSON(http.StatusBadRequest, graphQLError("Invalid request body"))
+	}
+
+	resp, err := h.graphql.Exec(c.Request().Context(), req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		var complexity *gql.ComplexityError
+		if errors.As(err, &complexity) {
+			h.log.Warn("graphql query rejected", zap.Int("complexity", complexity.Complexity), zap.String("remote_addr", c.RealIP()))
+		}
+		return c.JSON(http.StatusBadRequest, graphQLError(err.Error()))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func graphQLError(msg string) map[string]any {
+	return map[string]any{
+		"errors": []map[string]string{{"message": msg}},
+	}
+}
@@ -4,11 +4,19 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"test-task/internal/audit"
+	"test-task/internal/auth"
+	"test-task/internal/fx"
+	"test-task/internal/gql"
 	"test-task/internal/models"
+	"test-task/internal/redact"
 	"test-task/internal/repository"
 	"test-task/internal/retry"
 	"test-task/internal/service"
+	"test-task/internal/stream"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo"
 	"go.uber.org/zap"
 )
@@ -17,6 +25,20 @@ type Handler struct {
 	service *service.Service
 	retry   retry.Retrier
 	log     *zap.Logger
+
+	privilegedToken string
+
+	stream    *stream.Broker
+	heartbeat time.Duration
+
+	ws *wsConfig
+
+	graphql *gql.Schema
+
+	openapi *openapi3.T
+
+	auth     auth.Authenticator
+	redactor *redact.Redactor
 }
 
 func NewHandler(service *service.Service, retry retry.Retrier, log *zap.Logger) *Handler {
@@ -36,12 +58,18 @@ func (h *Handler) Get(c echo.Context) error {
 	}
 
 	eo := new(models.ExtendedOrder)
+	currency := c.QueryParam("currency")
 
 	h.log.Info("geting order", zap.Int64("id", id))
 
 	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
 		var err error
-		if eo, err = h.service.GetExtendedOrder(c.Request().Context(), id); err != nil {
+		if currency != "" {
+			eo, err = h.service.GetExtendedOrderInCurrency(c.Request().Context(), id, currency)
+		} else {
+			eo, err = h.service.GetExtendedOrder(c.Request().Context(), id)
+		}
+		if err != nil {
 			h.log.Warn("error on getting order", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
 			return err
 		}
@@ -51,16 +79,255 @@ func (h *Handler) Get(c echo.Context) error {
 		if errors.Is(err, repository.ErrNotFound) {
 			h.log.Warn("order not found", zap.Int64("id", id))
 			return c.JSON(http.StatusNotFound, map[string]string{"message": "Order not found"})
+		} else if isFXError(err) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"message": retry.Cause(err).Error()})
 		} else {
 			h.log.Error("error on getting order", zap.Int64("id", id), zap.Error(err))
 			return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
 		}
 	}
 
-	return c.JSON(http.StatusOK, eo)
+	return c.JSON(http.StatusOK, h.visible(c, eo))
+}
+
+func (h *Handler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	eo := new(models.ExtendedOrder)
+	if err := c.Bind(eo); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON body"})
+	}
+	eo.Order.ID = id
+
+	if err := models.Validate(eo); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	ctx := audit.WithActor(c.Request().Context(), apiActor(c))
+
+	h.log.Info("updating order", zap.Int64("id", id))
+
+	// изменение не повторяется: первая попытка уже проставляет id новым
+	// позициям в eo, и повтор после неясного исхода коммита применил бы его дважды
+	if err := h.service.UpdateExtendedOrder(ctx, eo); err != nil {
+		h.log.Warn("error on updating order", zap.Int64("id", id), zap.Error(err))
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, h.visible(c, eo))
+}
+
+func (h *Handler) UpdateStatus(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var req struct {
+		Status *int `json:"status"`
+	}
+	if err := c.Bind(&req); err != nil || req.Status == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Field status is required"})
+	}
+
+	ctx := audit.WithActor(c.Request().Context(), apiActor(c))
+
+	h.log.Info("updating order status", zap.Int64("id", id), zap.Int("status", *req.Status))
+
+	if err := h.retry.Do(ctx, func(attempt int) error {
+		if err := h.service.UpdateOrderStatus(ctx, id, *req.Status); err != nil {
+			h.log.Warn("error on updating order status", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	ctx := audit.WithActor(c.Request().Context(), apiActor(c))
+
+	h.log.Info("deleting order", zap.Int64("id", id))
+
+	if err := h.retry.Do(ctx, func(attempt int) error {
+		if err := h.service.DeleteExtendedOrder(ctx, id); err != nil {
+			h.log.Warn("error on deleting order", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) History(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var entries []*models.AuditEntry
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if entries, err = h.service.GetOrderHistory(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on getting order history", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.errorResponse(c, id, err)
+	}
+
+	policy := h.redactor.For(c.Request().Context())
+	shown := make([]*models.AuditEntry, 0, len(entries))
+	for _, e := range entries {
+		entry := *e
+		entry.Diff = policy.Changes(e.Diff)
+		shown = append(shown, &entry)
+	}
+
+	return c.JSON(http.StatusOK, shown)
+}
+
+func (h *Handler) Tracking(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var events []*models.DeliveryEvent
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if events, err = h.service.GetOrderTracking(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on getting order tracking", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+func (h *Handler) Track(c echo.Context) error {
+	track := c.Param("track_number")
+
+	var tracked []*models.TrackedOrder
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if tracked, err = h.service.GetOrdersByTrack(c.Request().Context(), track); err != nil {
+			h.log.Warn("error on getting orders by track", zap.String("track_number", track), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": "Order not found"})
+		}
+		h.log.Error("error on getting orders by track", zap.String("track_number", track), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	shown := make([]*models.TrackedOrder, 0, len(tracked))
+	for _, t := range tracked {
+		shown = append(shown, &models.TrackedOrder{Order: h.visible(c, t.Order), Items: t.Items})
+	}
+
+	return c.JSON(http.StatusOK, shown)
+}
+
+func (h *Handler) errorResponse(c echo.Context, id int64, err error) error {
+	// клиенту показывается ошибка сервиса без пометки повторов
+	msg := retry.Cause(err).Error()
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, repository.ErrNoRowsAffected):
+		h.log.Warn("order not found", zap.Int64("id", id))
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Order not found"})
+	case errors.Is(err, repository.ErrInvalidID), errors.Is(err, repository.ErrDuplicate):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": msg})
+	case errors.Is(err, repository.ErrInvalidRefund), errors.Is(err, repository.ErrInvalidReturn):
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": msg})
+	case isFXError(err), errors.Is(err, repository.ErrRefundExceedsPayment):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"message": msg})
+	case errors.Is(err, service.ErrTrackingDisabled):
+		return c.JSON(http.StatusNotImplemented, map[string]string{"message": msg})
+	default:
+		h.log.Error("request failed", zap.Int64("id", id), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+}
+
+// isFXError — ошибки пересчёта валюты, которые зависят от запроса, а не от сервера.
+func isFXError(err error) bool {
+	return errors.Is(err, fx.ErrUnknownCurrency) ||
+		errors.Is(err, fx.ErrRateNotFound) ||
+		errors.Is(err, service.ErrFXDisabled)
+}
+
+// apiActor определяет инициатора изменения, пришедшего через HTTP API:
+// клиента, прошедшего аутентификацию, или адрес, если она выключена.
+func apiActor(c echo.Context) models.Actor {
+	if p, ok := auth.PrincipalFromContext(c.Request().Context()); ok && p.Method != auth.MethodNone {
+		return audit.APIActor(p.Method + ":" + p.Name)
+	}
+	return audit.APIActor(c.RealIP())
 }
 
 func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	viewer := h.require(auth.RoleViewer)
+	support := h.require(auth.RoleSupport)
+	admin := h.require(auth.RoleAdmin)
+
 	g := e.Group("/order")
-	g.GET("/:id", h.Get)
+	g.GET("/:id", h.Get, viewer)
+	g.PUT("/:id", h.Update, support)
+	g.DELETE("/:id", h.Delete, admin)
+	g.PATCH("/:id/status", h.UpdateStatus, support)
+	g.GET("/:id/history", h.History, support)
+	g.GET("/:id/tracking", h.Tracking, viewer)
+	g.GET("/:id/refunds", h.Refunds, viewer)
+	g.POST("/:id/refunds", h.CreateRefund, support)
+	g.POST("/:id/returns", h.CreateReturn, support)
+
+	e.GET("/track/:track_number", h.Track, viewer)
+	e.GET("/search", h.Search, viewer)
+	e.GET("/fraud/flags", h.FlaggedOrders, support)
+	e.GET("/orders/stream", h.OrderStream, viewer)
+	e.GET("/ws/order/:id", h.OrderSocket, viewer)
+	e.POST("/graphql", h.GraphQL, viewer)
+	e.GET("/openapi.json", h.OpenAPI)
+	e.GET("/docs", h.Docs)
+
+	h.registerAnalyticsRoutes(e)
+	h.registerCustomerRoutes(e)
+	h.registerReconciliationRoutes(e)
+	h.registerWebhookRoutes(e)
 }
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo"
+)
+
+// docsPage — Swagger UI поверх /openapi.json.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Order Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>`
+
+// WithOpenAPI подключает /openapi.json и страницу документации /docs.
+func (h *Handler) WithOpenAPI(doc *openapi3.T) *Handler {
+	h.openapi = doc
+	return h
+}
+
+func (h *Handler) OpenAPI(c echo.Context) error {
+	if h.openapi == nil {
+		return c.JSON(http.StatusNotImplemented, map[string]string{"message": "OpenAPI spec is disabled"})
+	}
+	return c.JSON(http.StatusOK, h.openapi)
+}
+
+func (h *Handler) Docs(c echo.Context) error {
+	if h.openapi == nil {
+		return c.JSON(http.StatusNotImplemented, map[string]string{"message": "OpenAPI spec is disabled"})
+	}
+	return c.HTML(http.StatusOK, docsPage)
+}
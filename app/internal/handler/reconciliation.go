@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+var reconciliationStatuses = map[string]bool{
+	models.ReconMissingPayment:    true,
+	models.ReconMissingSettlement: true,
+	models.ReconDuplicate:         true,
+	models.ReconAmountMismatch:    true,
+	models.ReconCurrencyMismatch:  true,
+}
+
+func (h *Handler) ReconciliationRuns(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var runs []*models.ReconciliationRun
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if runs, err = h.service.ListReconciliationRuns(c.Request().Context(), limit, offset); err != nil {
+			h.log.Warn("error on listing reconciliation runs", zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		h.log.Error("error on listing reconciliation runs", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, runs)
+}
+
+func (h *Handler) ReconciliationRun(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var run *models.ReconciliationRun
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if run, err = h.service.GetReconciliationRun(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on getting reconciliation run", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": "Reconciliation run not found"})
+		}
+		h.log.Error("error on getting reconciliation run", zap.Int64("id", id), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, run)
+}
+
+func (h *Handler) ReconciliationResults(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	status := c.QueryParam("status")
+	if status != "" && !reconciliationStatuses[status] {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown status " + strconv.Quote(status)})
+	}
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var results []*models.ReconciliationResult
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if results, err = h.service.ListReconciliationResults(c.Request().Context(), id, status, limit, offset); err != nil {
+			h.log.Warn("error on listing reconciliation results", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		h.log.Error("error on listing reconciliation results", zap.Int64("id", id), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *Handler) registerReconciliationRoutes(e *echo.Echo) {
+	g := e.Group("/reconciliation", h.require(auth.RoleSupport))
+	g.GET("/runs", h.ReconciliationRuns)
+	g.GET("/runs/:id", h.ReconciliationRun)
+	g.GET("/runs/:id/results", h.ReconciliationResults)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"test-task/internal/audit"
+	"test-task/internal/models"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) CreateRefund(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	refund := new(models.Refund)
+	if err := c.Bind(refund); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON body"})
+	}
+	refund.ID = 0
+	refund.OrderID = id
+
+	if err := models.Validate(refund); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	ctx := audit.WithActor(c.Request().Context(), apiActor(c))
+
+	h.log.Info("creating refund", zap.Int64("id", id))
+
+	// возврат не идемпотентен: при повторе после неясного исхода коммита
+	// деньги вернулись бы дважды, поэтому запрос выполняется один раз
+	if err := h.service.CreateRefund(ctx, refund); err != nil {
+		h.log.Warn("error on creating refund", zap.Int64("id", id), zap.Error(err))
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusCreated, refund)
+}
+
+func (h *Handler) CreateReturn(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	req := new(models.ReturnRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON body"})
+	}
+
+	if err := models.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	ctx := audit.WithActor(c.Request().Context(), apiActor(c))
+
+	h.log.Info("creating return", zap.Int64("id", id), zap.Int("items", len(req.ItemIDs)))
+
+	// как и возврат денег, выполняется один раз
+	ret, err := h.service.CreateReturn(ctx, id, req)
+	if err != nil {
+		h.log.Warn("error on creating return", zap.Int64("id", id), zap.Error(err))
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusCreated, ret)
+}
+
+func (h *Handler) Refunds(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var refunds *models.OrderRefunds
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if refunds, err = h.service.GetOrderRefunds(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on getting refunds", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.errorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, refunds)
+}
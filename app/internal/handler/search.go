@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+const (
+	// privilegedTokenHeader — заголовок с токеном, открывающим поиск
+	// по персональным данным получателя.
+	privilegedTokenHeader = "X-Privileged-Token"
+
+	minSearchLength = 2
+	maxSearchLength = 200
+)
+
+// WithPrivilegedToken задаёт токен привилегированных клиентов.
+// Пустой токен запрещает поиск по персональным данным всем.
+func (h *Handler) WithPrivilegedToken(token string) *Handler {
+	h.privilegedToken = token
+	return h
+}
+
+// privileged разрешает поиск по персональным данным клиентам, которым
+// они видны, а при выключенной аутентификации — по токену.
+func (h *Handler) privileged(c echo.Context) bool {
+	if p, ok := auth.PrincipalFromContext(c.Request().Context()); ok && p.Method != auth.MethodNone {
+		return p.Role.SeesPII()
+	}
+
+	token := c.Request().Header.Get(privilegedTokenHeader)
+	return h.privilegedToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.privilegedToken)) == 1
+}
+
+func (h *Handler) Search(c echo.Context) error {
+	text := strings.TrimSpace(c.QueryParam("q"))
+	if n := utf8.RuneCountInString(text); n < minSearchLength || n > maxSearchLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "q must be from 2 to 200 characters"})
+	}
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	q := models.SearchQuery{
+		Text:    text,
+		Limit:   limit,
+		Offset:  offset,
+		Private: h.privileged(c),
+	}
+
+	var hits []*models.SearchHit
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if hits, err = h.service.Search(c.Request().Context(), q); err != nil {
+			h.log.Warn("error on searching orders", zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, repository.ErrInvalidFilter) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		h.log.Error("error on searching orders", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, h.visibleHits(c, hits))
+}
+
+// visibleHits скрывает совпавшие значения по политике роли клиента:
+// изменённое значение показывается без подсветки, удалённое — не показывается.
+func (h *Handler) visibleHits(c echo.Context, hits []*models.SearchHit) []*models.SearchHit {
+	policy := h.redactor.For(c.Request().Context())
+
+	shown := make([]*models.SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		matches := make([]*models.SearchMatch, 0, len(hit.Matches))
+		for _, m := range hit.Matches {
+			value, ok := policy.Value(matchPath(m.Field), m.Value)
+			if !ok {
+				continue
+			}
+			if value != m.Value {
+				m = &models.SearchMatch{Field: m.Field, Value: value, Highlight: value}
+			}
+			matches = append(matches, m)
+		}
+
+		redacted := *hit
+		redacted.Matches = matches
+		shown = append(shown, &redacted)
+	}
+	return shown
+}
+
+// matchPath переводит поле совпадения (delivery.city, item.name) в путь
+// поля заказа.
+func matchPath(field string) string {
+	if name, ok := strings.CutPrefix(field, "item."); ok {
+		return "$.items[*]." + name
+	}
+	return "$." + field
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/stream"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+// WithStream подключает поток новых заказов. heartbeat — период
+// комментариев, которые не дают прокси закрыть простаивающее соединение.
+func (h *Handler) WithStream(broker *stream.Broker, heartbeat time.Duration) *Handler {
+	h.stream = broker
+	h.heartbeat = heartbeat
+	return h
+}
+
+// OrderStream отдаёт новые заказы как Server-Sent Events. Фильтры
+// delivery_service и entry сравниваются точно. С заголовком Last-Event-ID
+// сначала отправляются пропущенные события, ещё лежащие в буфере.
+func (h *Handler) OrderStream(c echo.Context) error {
+	if h.stream == nil {
+		return c.JSON(http.StatusNotImplemented, map[string]string{"message": "Order stream is disabled"})
+	}
+
+	deliveryService := c.QueryParam("delivery_service")
+	entry := c.QueryParam("entry")
+	match := func(o models.OrderSummary) bool {
+		return (deliveryService == "" || o.DeliveryService == deliveryService) &&
+			(entry == "" || o.Entry == entry)
+	}
+
+	var lastID uint64
+	if raw := c.Request().Header.Get("Last-Event-ID"); raw != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Last-Event-ID"})
+		}
+	}
+
+	sub, backlog := h.stream.Subscribe(lastID)
+	defer sub.Close()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range backlog {
+		if match(e.Order) {
+			if err := writeStreamEvent(w, e); err != nil {
+				return nil
+			}
+		}
+	}
+	w.Flush()
+
+	h.log.Info("order stream client connected", zap.String("remote_addr", c.RealIP()), zap.Int("backlog", len(backlog)))
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+
+		case e, ok := <-sub.Events:
+			if !ok {
+				// клиент не успевал читать; переподключится с Last-Event-ID
+				h.log.Warn("order stream client is too slow, disconnected", zap.String("remote_addr", c.RealIP()))
+				return nil
+			}
+			if !match(e.Order) {
+				continue
+			}
+			if err := writeStreamEvent(w, e); err != nil {
+				return nil
+			}
+			w.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w *echo.Response, e stream.Event) error {
+	data, err := json.Marshal(e.Order)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: order\ndata: %s\n\n", e.ID, data)
+	return err
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+var webhookStatuses = map[string]bool{
+	models.WebhookPending:   true,
+	models.WebhookSending:   true,
+	models.WebhookDelivered: true,
+	models.WebhookFailed:    true,
+}
+
+// bindWebhook читает подписку из тела запроса. Не указанные поля получают
+// значения по умолчанию: 5 попыток, пауза от 10 секунд, подписка активна.
+func bindWebhook(c echo.Context) (*models.WebhookSubscription, error) {
+	sub := &models.WebhookSubscription{
+		MaxAttempts:    5,
+		BackoffSeconds: 10,
+		Active:         true,
+	}
+	if err := c.Bind(sub); err != nil {
+		return nil, errors.New("Invalid JSON body")
+	}
+
+	if err := models.Validate(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// webhookErrorResponse отвечает 404 на отсутствующую подписку или доставку.
+func (h *Handler) webhookErrorResponse(c echo.Context, id int64, err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Webhook not found"})
+	}
+	h.log.Error("error on webhook request", zap.Int64("id", id), zap.Error(err))
+	return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+}
+
+func (h *Handler) CreateWebhook(c echo.Context) error {
+	sub, err := bindWebhook(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		if err := h.service.CreateWebhook(c.Request().Context(), sub); err != nil {
+			h.log.Warn("error on creating webhook", zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, 0, err)
+	}
+
+	return c.JSON(http.StatusCreated, sub)
+}
+
+func (h *Handler) UpdateWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	sub, err := bindWebhook(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	sub.ID = id
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		if err := h.service.UpdateWebhook(c.Request().Context(), sub); err != nil {
+			h.log.Warn("error on updating webhook", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, sub)
+}
+
+func (h *Handler) DeleteWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		if err := h.service.DeleteWebhook(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on deleting webhook", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, id, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) GetWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var sub *models.WebhookSubscription
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if sub, err = h.service.GetWebhook(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on getting webhook", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, sub)
+}
+
+func (h *Handler) ListWebhooks(c echo.Context) error {
+	var subs []*models.WebhookSubscription
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if subs, err = h.service.ListWebhooks(c.Request().Context()); err != nil {
+			h.log.Warn("error on listing webhooks", zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, 0, err)
+	}
+
+	return c.JSON(http.StatusOK, subs)
+}
+
+func (h *Handler) WebhookDeliveries(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	status := c.QueryParam("status")
+	if status != "" && !webhookStatuses[status] {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown status " + strconv.Quote(status)})
+	}
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var deliveries []*models.WebhookDelivery
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if deliveries, err = h.service.ListWebhookDeliveries(c.Request().Context(), id, status, limit, offset); err != nil {
+			h.log.Warn("error on listing webhook deliveries", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *Handler) RedeliverWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var delivery *models.WebhookDelivery
+
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		if delivery, err = h.service.RedeliverWebhook(c.Request().Context(), id); err != nil {
+			h.log.Warn("error on redelivering webhook", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+			return err
+		}
+		return nil
+	}); err != nil {
+		return h.webhookErrorResponse(c, id, err)
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+func (h *Handler) registerWebhookRoutes(e *echo.Echo) {
+	g := e.Group("/webhooks", h.require(auth.RoleAdmin))
+	g.POST("", h.CreateWebhook)
+	g.GET("", h.ListWebhooks)
+	g.GET("/:id", h.GetWebhook)
+	g.PUT("/:id", h.UpdateWebhook)
+	g.DELETE("/:id", h.DeleteWebhook)
+	g.GET("/:id/deliveries", h.WebhookDeliveries)
+	g.POST("/deliveries/:id/redeliver", h.RedeliverWebhook)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"test-task/internal/repository"
+	"test-task/internal/service"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+// wsReadLimit — клиент ничего не присылает, кроме управляющих кадров.
+const wsReadLimit = 512
+
+type wsConfig struct {
+	pingInterval time.Duration
+	pongWait     time.Duration
+	writeTimeout time.Duration
+}
+
+// WithOrderSocket включает подписку на изменения заказа по WebSocket.
+// Клиент, не ответивший на ping за pongWait, отключается.
+func (h *Handler) WithOrderSocket(pingInterval, pongWait, writeTimeout time.Duration) *Handler {
+	h.ws = &wsConfig{
+		pingInterval: pingInterval,
+		pongWait:     pongWait,
+		writeTimeout: writeTimeout,
+	}
+	return h
+}
+
+// upgrader проверяет Origin по умолчанию: подключаться можно только
+// со страниц того же хоста.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// OrderSocket отправляет снимок заказа при подключении, а затем изменения
+// после каждой правки заказа. Соединение закрывается при удалении заказа
+// и если клиент не успевает читать.
+func (h *Handler) OrderSocket(c echo.Context) error {
+	if h.ws == nil {
+		return c.JSON(http.StatusNotImplemented, map[string]string{"message": "Order subscriptions are disabled"})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid ID format",
+		})
+	}
+
+	// подписка до Upgrade, чтобы на несуществующий заказ ответить 404
+	var sub *service.OrderSubscription
+	if err := h.retry.Do(c.Request().Context(), func(attempt int) error {
+		var err error
+		sub, err = h.service.SubscribeOrder(c.Request().Context(), id)
+		if err != nil {
+			h.log.Warn("error on subscribing to order", zap.Int64("id", id), zap.Error(err), zap.Int("attempt", attempt))
+		}
+		return err
+	}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"message": "Order not found",
+			})
+		}
+		if errors.Is(err, service.ErrOrderHubDisabled) {
+			return c.JSON(http.StatusNotImplemented, map[string]string{"message": "Order subscriptions are disabled"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": "Failed to subscribe to order",
+		})
+	}
+	defer sub.Close()
+
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// Upgrade уже ответил клиенту
+		h.log.Warn("websocket upgrade failed", zap.Int64("id", id), zap.Error(err))
+		return nil
+	}
+	defer conn.Close()
+
+	h.log.Info("order subscriber connected", zap.Int64("id", id), zap.String("remote_addr", c.RealIP()))
+
+	policy := h.redactor.For(c.Request().Context())
+
+	closed := make(chan struct{})
+	go h.readOrderSocket(conn, closed)
+
+	ping := time.NewTicker(h.ws.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+
+		case update, ok := <-sub.Updates:
+			if !ok {
+				if sub.Overflowed() {
+					h.log.Warn("order subscriber is too slow, disconnected", zap.Int64("id", id), zap.String("remote_addr", c.RealIP()))
+					h.closeOrderSocket(conn, websocket.CloseTryAgainLater, "too slow")
+				} else {
+					h.closeOrderSocket(conn, websocket.CloseNormalClosure, "")
+				}
+				return nil
+			}
+
+			update = update.Redact(policy)
+
+			conn.SetWriteDeadline(time.Now().Add(h.ws.writeTimeout))
+			if err := conn.WriteJSON(update); err != nil {
+				return nil
+			}
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(h.ws.writeTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// readOrderSocket читает управляющие кадры, чтобы обрабатывались pong
+// и закрытие соединения клиентом. closed закрывается при ошибке чтения.
+func (h *Handler) readOrderSocket(conn *websocket.Conn, closed chan<- struct{}) {
+	defer close(closed)
+
+	conn.SetReadLimit(wsReadLimit)
+	conn.SetReadDeadline(time.Now().Add(h.ws.pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(h.ws.pongWait))
+	})
+
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *Handler) closeOrderSocket(conn *websocket.Conn, code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(h.ws.writeTimeout))
+}
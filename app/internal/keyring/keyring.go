@@ -0,0 +1,228 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeySize — длина ключей в байтах (AES-256, HMAC-SHA256).
+const KeySize = 32
+
+var (
+	// ErrUnknownKey — запись зашифрована ключом, которого нет в keyring.
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrDecrypt — шифртекст повреждён или зашифрован другим ключом.
+	ErrDecrypt = errors.New("failed to decrypt")
+)
+
+// Keyring — ключи шифрования записей. Каждая запись шифруется своим
+// случайным ключом (DEK), а он — основным ключом keyring (KEK). Старые
+// ключи нужны, чтобы читать записи, ещё не перешифрованные основным.
+type Keyring struct {
+	primary  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// file — формат файла keyring, ключи в base64.
+type file struct {
+	Primary       string            `yaml:"primary"`
+	Keys          map[string]string `yaml:"keys"`
+	BlindIndexKey string            `yaml:"blind_index_key"`
+}
+
+// Load читает keyring из YAML-файла.
+func Load(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make(map[string][]byte, len(f.Keys))
+	for id, encoded := range f.Keys {
+		if keys[id], err = decodeKey(encoded); err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", path, id, err)
+		}
+	}
+	indexKey, err := decodeKey(f.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: blind_index_key: %w", path, err)
+	}
+
+	k, err := New(f.Primary, keys, indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+func New(primary string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primary)
+	}
+	if len(indexKey) != KeySize {
+		return nil, fmt.Errorf("blind index key must be %d bytes", KeySize)
+	}
+
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys)), indexKey: indexKey}
+	for id, key := range keys {
+		if id == "" {
+			return nil, errors.New("key id is required")
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes", id, KeySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// GenerateKey возвращает новый случайный ключ в base64 для файла keyring.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Primary возвращает id ключа, которым шифруются новые записи.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Envelope — ключ записи (DEK), зашифрованный ключом KeyID.
+type Envelope struct {
+	KeyID string `json:"key_id"`
+	DEK   []byte `json:"dek"`
+}
+
+// Field — шифруемое поле записи. Имя входит в AAD, поэтому шифртекст
+// нельзя подставить в другое поле.
+type Field struct {
+	Name  string
+	Value *string
+}
+
+// Seal шифрует значения полей на месте новым ключом записи и возвращает
+// его конверт. Шифртекст — nonce и AES-GCM в base64.
+func (k *Keyring) Seal(fields ...Field) (Envelope, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return Envelope{}, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	for _, f := range fields {
+		sealed, err := seal(aead, []byte(*f.Value), []byte(f.Name))
+		if err != nil {
+			return Envelope{}, err
+		}
+		*f.Value = base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	wrapped, err := seal(k.keys[k.primary], dek, []byte(k.primary))
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{KeyID: k.primary, DEK: wrapped}, nil
+}
+
+// Open расшифровывает значения полей на месте.
+func (k *Keyring) Open(env Envelope, fields ...Field) error {
+	kek, ok := k.keys[env.KeyID]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, env.KeyID)
+	}
+	dek, err := open(kek, env.DEK, []byte(env.KeyID))
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		sealed, err := base64.StdEncoding.DecodeString(*f.Value)
+		if err != nil {
+			return fmt.Errorf("%w %s: %v", ErrDecrypt, f.Name, err)
+		}
+		plain, err := open(aead, sealed, []byte(f.Name))
+		if err != nil {
+			return fmt.Errorf("%w %s", err, f.Name)
+		}
+		*f.Value = string(plain)
+	}
+	return nil
+}
+
+// BlindIndex возвращает HMAC-SHA256 значения поля name: по нему ищутся
+// точные совпадения без расшифровки. Значение нормализует вызывающий.
+func (k *Keyring) BlindIndex(name, value string) []byte {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plain, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("must be %d bytes in base64", KeySize)
+	}
+	return key, nil
+}
@@ -0,0 +1,133 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func newTestKeyring(t *testing.T, primary string) *Keyring {
+	t.Helper()
+	k, err := New(primary, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, testKey(9))
+	require.NoError(t, err)
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	k := newTestKeyring(t, "k1")
+
+	name, phone := "Test Testov", "+9720000000"
+	env, err := k.Seal(Field{"name", &name}, Field{"phone", &phone})
+	require.NoError(t, err)
+
+	assert.Equal(t, "k1", env.KeyID)
+	assert.NotEqual(t, "Test Testov", name)
+	assert.NotContains(t, name+phone, "Test")
+
+	require.NoError(t, k.Open(env, Field{"name", &name}, Field{"phone", &phone}))
+	assert.Equal(t, "Test Testov", name)
+	assert.Equal(t, "+9720000000", phone)
+}
+
+func TestSeal_FreshKeyPerRecord(t *testing.T) {
+	k := newTestKeyring(t, "k1")
+
+	a, b := "same", "same"
+	envA, err := k.Seal(Field{"name", &a})
+	require.NoError(t, err)
+	envB, err := k.Seal(Field{"name", &b})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	assert.NotEqual(t, envA.DEK, envB.DEK)
+}
+
+func TestOpen_Errors(t *testing.T) {
+	k := newTestKeyring(t, "k1")
+
+	name := "Test Testov"
+	env, err := k.Seal(Field{"name", &name})
+	require.NoError(t, err)
+
+	t.Run("swapped field", func(t *testing.T) {
+		v := name
+		assert.True(t, errors.Is(k.Open(env, Field{"phone", &v}), ErrDecrypt))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		v := name
+		other, err := New("k3", map[string][]byte{"k3": testKey(3)}, testKey(9))
+		require.NoError(t, err)
+		assert.True(t, errors.Is(other.Open(env, Field{"name", &v}), ErrUnknownKey))
+	})
+
+	t.Run("wrong key id", func(t *testing.T) {
+		v := name
+		assert.True(t, errors.Is(k.Open(Envelope{KeyID: "k2", DEK: env.DEK}, Field{"name", &v}), ErrDecrypt))
+	})
+
+	t.Run("not base64", func(t *testing.T) {
+		v := "Test Testov"
+		assert.True(t, errors.Is(k.Open(env, Field{"name", &v}), ErrDecrypt))
+	})
+}
+
+func TestRotation(t *testing.T) {
+	old := newTestKeyring(t, "k1")
+	rotated := newTestKeyring(t, "k2")
+
+	name := "Test Testov"
+	env, err := old.Seal(Field{"name", &name})
+	require.NoError(t, err)
+
+	// после смены основного ключа старые записи читаются, новые шифруются k2
+	require.NoError(t, rotated.Open(env, Field{"name", &name}))
+	env, err = rotated.Seal(Field{"name", &name})
+	require.NoError(t, err)
+	assert.Equal(t, "k2", env.KeyID)
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := newTestKeyring(t, "k1")
+	other := newTestKeyring(t, "k2")
+
+	assert.Equal(t, k.BlindIndex("email", "test@gmail.com"), other.BlindIndex("email", "test@gmail.com"))
+	assert.NotEqual(t, k.BlindIndex("email", "test@gmail.com"), k.BlindIndex("phone", "test@gmail.com"))
+	assert.Len(t, k.BlindIndex("email", "test@gmail.com"), 32)
+}
+
+func TestLoad(t *testing.T) {
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString(testKey(b)) }
+	write := func(t *testing.T, data string) string {
+		path := filepath.Join(t.TempDir(), "keyring.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+
+	k, err := Load(write(t, "primary: k2\nkeys:\n  k1: "+key(1)+"\n  k2: "+key(2)+"\nblind_index_key: "+key(9)+"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", k.Primary())
+
+	tests := map[string]string{
+		"no primary":   "primary: k3\nkeys:\n  k1: " + key(1) + "\nblind_index_key: " + key(9),
+		"short key":    "primary: k1\nkeys:\n  k1: " + base64.StdEncoding.EncodeToString([]byte("short")) + "\nblind_index_key: " + key(9),
+		"no index key": "primary: k1\nkeys:\n  k1: " + key(1),
+		"not base64":   "primary: k1\nkeys:\n  k1: '!!!'\nblind_index_key: " + key(9),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(write(t, data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -42,6 +42,20 @@ func (m *MockExtendedOrderRepository) EXPECT() *MockExtendedOrderRepositoryMockR
 	return m.recorder
 }
 
+// Audit mocks base method.
+func (m *MockExtendedOrderRepository) Audit() repository.AuditRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audit")
+	ret0, _ := ret[0].(repository.AuditRepository)
+	return ret0
+}
+
+// Audit indicates an expected call of Audit.
+func (mr *MockExtendedOrderRepositoryMockRecorder) Audit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audit", reflect.TypeOf((*MockExtendedOrderRepository)(nil).Audit))
+}
+
 // CreateExtendedOrder mocks base method.
 func (m *MockExtendedOrderRepository) CreateExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) error {
 	m.ctrl.T.Helper()
@@ -56,6 +70,20 @@ func (mr *MockExtendedOrderRepositoryMockRecorder) CreateExtendedOrder(ctx, eo a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExtendedOrder", reflect.TypeOf((*MockExtendedOrderRepository)(nil).CreateExtendedOrder), ctx, eo)
 }
 
+// DeleteExtendedOrder mocks base method.
+func (m *MockExtendedOrderRepository) DeleteExtendedOrder(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExtendedOrder", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExtendedOrder indicates an expected call of DeleteExtendedOrder.
+func (mr *MockExtendedOrderRepositoryMockRecorder) DeleteExtendedOrder(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExtendedOrder", reflect.TypeOf((*MockExtendedOrderRepository)(nil).DeleteExtendedOrder), ctx, id)
+}
+
 // Delivery mocks base method.
 func (m *MockExtendedOrderRepository) Delivery() repository.DeliveryRepository {
 	m.ctrl.T.Helper()
@@ -85,6 +113,36 @@ func (mr *MockExtendedOrderRepositoryMockRecorder) GetExtendedOrder(ctx, id any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExtendedOrder", reflect.TypeOf((*MockExtendedOrderRepository)(nil).GetExtendedOrder), ctx, id)
 }
 
+// GetExtendedOrderByUID mocks base method.
+func (m *MockExtendedOrderRepository) GetExtendedOrderByUID(ctx context.Context, uid string) (*models.ExtendedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExtendedOrderByUID", ctx, uid)
+	ret0, _ := ret[0].(*models.ExtendedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExtendedOrderByUID indicates an expected call of GetExtendedOrderByUID.
+func (mr *MockExtendedOrderRepositoryMockRecorder) GetExtendedOrderByUID(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExtendedOrderByUID", reflect.TypeOf((*MockExtendedOrderRepository)(nil).GetExtendedOrderByUID), ctx, uid)
+}
+
+// GetExtendedOrdersByTrack mocks base method.
+func (m *MockExtendedOrderRepository) GetExtendedOrdersByTrack(ctx context.Context, track string, limit int) ([]*models.ExtendedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExtendedOrdersByTrack", ctx, track, limit)
+	ret0, _ := ret[0].([]*models.ExtendedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExtendedOrdersByTrack indicates an expected call of GetExtendedOrdersByTrack.
+func (mr *MockExtendedOrderRepositoryMockRecorder) GetExtendedOrdersByTrack(ctx, track, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExtendedOrdersByTrack", reflect.TypeOf((*MockExtendedOrderRepository)(nil).GetExtendedOrdersByTrack), ctx, track, limit)
+}
+
 // GetLastExtendedOrders mocks base method.
 func (m *MockExtendedOrderRepository) GetLastExtendedOrders(ctx context.Context, limit int) ([]*models.ExtendedOrder, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +172,21 @@ func (mr *MockExtendedOrderRepositoryMockRecorder) Items() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Items", reflect.TypeOf((*MockExtendedOrderRepository)(nil).Items))
 }
 
+// ListExtendedOrders mocks base method.
+func (m *MockExtendedOrderRepository) ListExtendedOrders(ctx context.Context, f models.OrderListFilter) ([]*models.ExtendedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExtendedOrders", ctx, f)
+	ret0, _ := ret[0].([]*models.ExtendedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExtendedOrders indicates an expected call of ListExtendedOrders.
+func (mr *MockExtendedOrderRepositoryMockRecorder) ListExtendedOrders(ctx, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExtendedOrders", reflect.TypeOf((*MockExtendedOrderRepository)(nil).ListExtendedOrders), ctx, f)
+}
+
 // Orders mocks base method.
 func (m *MockExtendedOrderRepository) Orders() repository.OrdersRepository {
 	m.ctrl.T.Helper()
@@ -141,3 +214,31 @@ func (mr *MockExtendedOrderRepositoryMockRecorder) Payment() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Payment", reflect.TypeOf((*MockExtendedOrderRepository)(nil).Payment))
 }
+
+// UpdateExtendedOrder mocks base method.
+func (m *MockExtendedOrderRepository) UpdateExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExtendedOrder", ctx, eo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateExtendedOrder indicates an expected call of UpdateExtendedOrder.
+func (mr *MockExtendedOrderRepositoryMockRecorder) UpdateExtendedOrder(ctx, eo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExtendedOrder", reflect.TypeOf((*MockExtendedOrderRepository)(nil).UpdateExtendedOrder), ctx, eo)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockExtendedOrderRepository) UpdateOrderStatus(ctx context.Context, id int64, status int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockExtendedOrderRepositoryMockRecorder) UpdateOrderStatus(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockExtendedOrderRepository)(nil).UpdateOrderStatus), ctx, id, status)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"test-task/internal/money"
+)
+
+// AnalyticsFilter ограничивает отчёт заказами, созданными в [From, To),
+// и задаёт валюту, в которую пересчитывается выручка.
+type AnalyticsFilter struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+	// Base — валюта, в которой указаны курсы fx_rates.
+	Base string
+}
+
+// RevenuePoint — число заказов и выручка за период.
+// Unconverted — заказы без курса на дату оплаты, их выручка не учтена.
+type RevenuePoint struct {
+	Period      time.Time    `json:"period"`
+	Orders      int64        `json:"orders"`
+	Revenue     money.Amount `json:"revenue"`
+	Unconverted int64        `json:"unconverted"`
+}
+
+// SalesRank — проданное количество и выручка по бренду или артикулу.
+type SalesRank struct {
+	Key      string       `json:"key"`
+	Quantity int64        `json:"quantity"`
+	Revenue  money.Amount `json:"revenue"`
+}
+
+type BasketStats struct {
+	Orders      int64        `json:"orders"`
+	Items       int64        `json:"items"`
+	AvgItems    float64      `json:"avg_items"`
+	AvgAmount   money.Amount `json:"avg_amount"`
+	Unconverted int64        `json:"unconverted"`
+}
+
+// RevenueShare — выручка по значению группировки: службе доставки,
+// платёжному провайдеру или банку.
+type RevenueShare struct {
+	Key         string       `json:"key"`
+	Orders      int64        `json:"orders"`
+	Revenue     money.Amount `json:"revenue"`
+	Unconverted int64        `json:"unconverted"`
+}
+
+// SaleBucket — число позиций со скидкой в [From, To] процентов.
+type SaleBucket struct {
+	From  int   `json:"from"`
+	To    int   `json:"to"`
+	Items int64 `json:"items"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate       = "create"
+	AuditActionUpdate       = "update"
+	AuditActionDelete       = "delete"
+	AuditActionStatusChange = "status_change"
+	AuditActionPurge        = "purge"
+	AuditActionArchive      = "archive"
+	AuditActionRestore      = "restore"
+	AuditActionRefund       = "refund"
+	AuditActionReturn       = "return"
+)
+
+const (
+	ActorTypeKafka  = "kafka"
+	ActorTypeAPI    = "api"
+	ActorTypeSystem = "system"
+)
+
+type Actor struct {
+	Type      string `json:"type"`
+	Topic     string `json:"topic,omitempty"`
+	Partition int    `json:"partition,omitempty"`
+	Offset    int64  `json:"offset,omitempty"`
+	Principal string `json:"principal,omitempty"`
+}
+
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	OrderID   int64           `json:"order_id"`
+	Action    string          `json:"action"`
+	Actor     Actor           `json:"actor"`
+	Diff      json.RawMessage `json:"diff"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"test-task/internal/money"
+)
+
+// CustomerOrders — страница заказов покупателя, новые первыми.
+// Total — число всех неудалённых заказов покупателя.
+type CustomerOrders struct {
+	Orders []*ExtendedOrder `json:"orders"`
+	Total  int64            `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+// CustomerSpend — сумма оплат покупателя в одной валюте.
+type CustomerSpend struct {
+	Currency string       `json:"currency"`
+	Amount   money.Amount `json:"amount"`
+}
+
+// CustomerSummary — сводка по неудалённым заказам покупателя.
+// PreferredDeliveryService — служба доставки самых частых заказов,
+// при равенстве — самых свежих.
+type CustomerSummary struct {
+	CustomerID               string           `json:"customer_id"`
+	Orders                   int64            `json:"orders"`
+	Spend                    []*CustomerSpend `json:"spend"`
+	FirstOrder               time.Time        `json:"first_order"`
+	LastOrder                time.Time        `json:"last_order"`
+	PreferredDeliveryService string           `json:"preferred_delivery_service"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// DeliveryEvent — событие перевозчика по трек-номеру: статус посылки,
+// место и время, когда он сменился.
+type DeliveryEvent struct {
+	ID          int64     `json:"id"`
+	TrackNumber string    `json:"track_number" validate:"required"`
+	Carrier     string    `json:"carrier" validate:"required,max=32"`
+	Status      string    `json:"status" validate:"required,max=64"`
+	Location    string    `json:"location" validate:"max=255"`
+	OccurredAt  time.Time `json:"occurred_at" validate:"required"`
+}
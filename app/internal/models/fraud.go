@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// FraudReason — сработавшее правило проверки заказа.
+type FraudReason struct {
+	Rule    string `json:"rule"`
+	Score   int    `json:"score"`
+	Message string `json:"message"`
+}
+
+// Risk — оценка риска заказа: сумма баллов сработавших правил, не больше 100.
+type Risk struct {
+	Score     int            `json:"score"`
+	Reasons   []*FraudReason `json:"reasons"`
+	FlaggedAt time.Time      `json:"flagged_at"`
+}
+
+// FlaggedOrder — подозрительный заказ в списке на проверку.
+type FlaggedOrder struct {
+	OrderID     int64     `json:"order_id"`
+	OrderUID    string    `json:"order_uid"`
+	CustomerID  string    `json:"customer_id"`
+	DateCreated time.Time `json:"date_created"`
+	Risk
+}
+
+// FlaggedOrders — страница подозрительных заказов.
+type FlaggedOrders struct {
+	Orders []*FlaggedOrder `json:"orders"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
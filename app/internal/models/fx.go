@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"test-task/internal/money"
+)
+
+// FXRate — стоимость единицы валюты Currency в базовой валюте на дату Date.
+type FXRate struct {
+	Currency string     `json:"currency" validate:"required,currency"`
+	Date     time.Time  `json:"date" validate:"required"`
+	Rate     money.Rate `json:"rate"`
+}
+
+// FXConversion описывает пересчёт сумм заказа в другую валюту.
+type FXConversion struct {
+	From     string     `json:"from"`
+	Rate     money.Rate `json:"rate"`
+	RateDate time.Time  `json:"rate_date"`
+}
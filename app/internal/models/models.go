@@ -1,12 +1,85 @@
 package models
 
 import (
+	"encoding/json"
+	"reflect"
+	"slices"
 	"time"
 
+	"test-task/internal/currency"
+	"test-task/internal/events"
+	"test-task/internal/money"
+
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// gt, required и прочие числовые теги сравнивают money.Amount как целые доли
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if a, ok := field.Interface().(money.Amount); ok {
+			return a.Units()
+		}
+		return nil
+	}, money.Amount{})
+
+	v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		_, ok := currency.Lookup(fl.Field().String())
+		return ok
+	})
+
+	v.RegisterValidation("event_type", func(fl validator.FieldLevel) bool {
+		return slices.Contains(events.Types, fl.Field().String())
+	})
+
+	v.RegisterStructValidation(validateTotals, ExtendedOrder{})
+
+	return v
+}
+
+// validateTotals сверяет итоги оплаты с позициями:
+// amount = goods_total + delivery_cost + custom_fee, goods_total = Σ total_price.
+// Суммы не могут быть точнее минимальной единицы валюты оплаты.
+func validateTotals(sl validator.StructLevel) {
+	eo := sl.Current().Interface().(ExtendedOrder)
+
+	p := eo.Payment
+	if cur, ok := currency.Lookup(p.Currency); ok {
+		precise := func(a money.Amount, field string) {
+			if a.Places() > cur.MinorUnits {
+				sl.ReportError(a, field, field, "precision", p.Currency)
+			}
+		}
+
+		precise(p.Amount, "Payment.Amount")
+		precise(p.DeliveryCost, "Payment.DeliveryCost")
+		precise(p.GoodsTotal, "Payment.GoodsTotal")
+		precise(p.CustomFee, "Payment.CustomFee")
+		for _, item := range eo.Items {
+			if item != nil {
+				precise(item.Price, "Items.Price")
+				precise(item.TotalPrice, "Items.TotalPrice")
+			}
+		}
+	}
+
+	if p.Amount.Cmp(money.Sum(p.GoodsTotal, p.DeliveryCost, p.CustomFee)) != 0 {
+		sl.ReportError(p.Amount, "Payment.Amount", "Amount", "amount_total", "")
+	}
+
+	var goods money.Amount
+	for _, item := range eo.Items {
+		if item != nil {
+			goods = goods.Add(item.TotalPrice)
+		}
+	}
+	if p.GoodsTotal.Cmp(goods) != 0 {
+		sl.ReportError(p.GoodsTotal, "Payment.GoodsTotal", "GoodsTotal", "goods_total", "")
+	}
+}
 
 func Validate(modelsStruct interface{}) error {
 	return validate.Struct(modelsStruct)
@@ -17,6 +90,8 @@ type ExtendedOrder struct {
 	Delivery Delivery `json:"delivery" validate:"required"`
 	Payment  Payment  `json:"payment" validate:"required"`
 	Items    []*Item  `json:"items" validate:"required,min=1,dive,required"`
+	// Risk выставляется проверкой при приёме заказа, значение из сообщения заменяется.
+	Risk *Risk `json:"risk,omitempty" validate:"-"`
 }
 
 type Delivery struct {
@@ -28,20 +103,48 @@ type Delivery struct {
 	Address string `json:"address" validate:"required"`
 	Region  string `json:"region" validate:"required"`
 	Email   string `json:"email" validate:"required,email"`
+	// Tracking — последнее событие перевозчика, заполняется при чтении заказа.
+	Tracking *DeliveryEvent `json:"tracking,omitempty"`
 }
 
 type Payment struct {
-	ID           int64   `json:"id"`
-	Transaction  string  `json:"transaction" validate:"required"`
-	RequestID    string  `json:"request_id" validate:"omitempty"`
-	Currency     string  `json:"currency" validate:"required,len=3,uppercase"`
-	Provider     string  `json:"provider" validate:"required"`
-	Amount       float64 `json:"amount" validate:"required,gt=0"`
-	PaymentDate  int64   `json:"payment_dt" validate:"required"`
-	Bank         string  `json:"bank" validate:"required"`
-	DeliveryCost float64 `json:"delivery_cost" validate:"required,gt=0"`
-	GoodsTotal   float64 `json:"goods_total" validate:"required,gt=0"`
-	CustomFee    float64 `json:"custom_fee"`
+	ID           int64        `json:"id"`
+	Transaction  string       `json:"transaction" validate:"required"`
+	RequestID    string       `json:"request_id" validate:"omitempty"`
+	Currency     string       `json:"currency" validate:"required,len=3,uppercase,currency"`
+	Provider     string       `json:"provider" validate:"required"`
+	Amount       money.Amount `json:"amount" validate:"required,gt=0"`
+	PaymentDate  int64        `json:"payment_dt" validate:"required"`
+	Bank         string       `json:"bank" validate:"required"`
+	DeliveryCost money.Amount `json:"delivery_cost" validate:"required,gt=0"`
+	GoodsTotal   money.Amount `json:"goods_total" validate:"required,gt=0"`
+	CustomFee    money.Amount `json:"custom_fee"`
+	// Refunded — сумма возвратов денег по заказу, заполняется при чтении.
+	Refunded money.Amount `json:"refunded"`
+	// Conversion заполняется, когда суммы пересчитаны в другую валюту при чтении.
+	Conversion *FXConversion `json:"conversion,omitempty"`
+}
+
+// NetAmount — сумма оплаты за вычетом возвратов.
+func (p Payment) NetAmount() money.Amount {
+	return p.Amount.Sub(p.Refunded)
+}
+
+// MarshalJSON добавляет к оплате сумму за вычетом возвратов и сведения
+// о валюте из справочника ISO 4217.
+func (p Payment) MarshalJSON() ([]byte, error) {
+	type payment Payment
+
+	var info *currency.Currency
+	if cur, ok := currency.Lookup(p.Currency); ok {
+		info = &cur
+	}
+
+	return json.Marshal(struct {
+		payment
+		NetAmount    money.Amount       `json:"net_amount"`
+		CurrencyInfo *currency.Currency `json:"currency_info,omitempty"`
+	}{payment(p), p.NetAmount(), info})
 }
 
 type Order struct {
@@ -62,17 +165,17 @@ type Order struct {
 }
 
 type Item struct {
-	ID          int64   `json:"id"`
-	OrderID     int64   `json:"order_id"`
-	ChrtID      int     `json:"chrt_id" validate:"required"`
-	TrackNumber string  `json:"track_number" validate:"required"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	RID         string  `json:"rid" validate:"required"`
-	Name        string  `json:"name" validate:"required"`
-	Sale        int     `json:"sale" validate:"required,gte=0,lt=100"`
-	Size        string  `json:"size" validate:"required"`
-	TotalPrice  float64 `json:"total_price" validate:"required,gt=0"`
-	NMID        int     `json:"nm_id" validate:"required"`
-	Brand       string  `json:"brand" validate:"required"`
-	Status      int     `json:"status" validate:"required"`
+	ID          int64        `json:"id"`
+	OrderID     int64        `json:"order_id"`
+	ChrtID      int          `json:"chrt_id" validate:"required"`
+	TrackNumber string       `json:"track_number" validate:"required"`
+	Price       money.Amount `json:"price" validate:"required,gt=0"`
+	RID         string       `json:"rid" validate:"required"`
+	Name        string       `json:"name" validate:"required"`
+	Sale        int          `json:"sale" validate:"required,gte=0,lt=100"`
+	Size        string       `json:"size" validate:"required"`
+	TotalPrice  money.Amount `json:"total_price" validate:"required,gt=0"`
+	NMID        int          `json:"nm_id" validate:"required"`
+	Brand       string       `json:"brand" validate:"required"`
+	Status      int          `json:"status" validate:"required"`
 }
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"test-task/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadModel(t *testing.T) *ExtendedOrder {
+	data, err := os.ReadFile("../../../model.json")
+	require.NoError(t, err)
+
+	eo := new(ExtendedOrder)
+	require.NoError(t, json.Unmarshal(data, eo))
+	return eo
+}
+
+func TestValidateModel(t *testing.T) {
+	assert.NoError(t, Validate(loadModel(t)))
+}
+
+func TestValidateTotals(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(eo *ExtendedOrder)
+		wantErr bool
+	}{
+		{
+			name:   "totals match",
+			modify: func(eo *ExtendedOrder) {},
+		},
+		{
+			name: "totals match with custom fee",
+			modify: func(eo *ExtendedOrder) {
+				eo.Payment.CustomFee = money.MustParse("10.5")
+				eo.Payment.Amount = eo.Payment.Amount.Add(money.MustParse("10.50"))
+			},
+		},
+		{
+			name: "totals match with several items",
+			modify: func(eo *ExtendedOrder) {
+				item := *eo.Items[0]
+				item.TotalPrice = money.MustParse("0.01")
+				eo.Items = append(eo.Items, &item)
+				eo.Payment.GoodsTotal = eo.Payment.GoodsTotal.Add(item.TotalPrice)
+				eo.Payment.Amount = eo.Payment.Amount.Add(item.TotalPrice)
+			},
+		},
+		{
+			name: "amount mismatch",
+			modify: func(eo *ExtendedOrder) {
+				eo.Payment.Amount = eo.Payment.Amount.Add(money.MustParse("0.01"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom fee not in amount",
+			modify: func(eo *ExtendedOrder) {
+				eo.Payment.CustomFee = money.MustParse("1")
+			},
+			wantErr: true,
+		},
+		{
+			name: "goods total mismatch",
+			modify: func(eo *ExtendedOrder) {
+				eo.Items[0].TotalPrice = eo.Items[0].TotalPrice.Sub(money.MustParse("0.01"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eo := loadModel(t)
+			tt.modify(eo)
+
+			err := Validate(eo)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateMoneyPlaces(t *testing.T) {
+	eo := loadModel(t)
+	eo.Items[0].Price = money.MustParse("453.001")
+	assert.Error(t, Validate(eo))
+
+	eo.Payment.Currency = "KWD"
+	assert.NoError(t, Validate(eo))
+
+	eo = loadModel(t)
+	eo.Payment.Currency = "JPY"
+	eo.Items[0].Price = money.MustParse("453.5")
+	assert.Error(t, Validate(eo))
+}
+
+func TestValidateCurrency(t *testing.T) {
+	eo := loadModel(t)
+	eo.Payment.Currency = "ABC"
+	assert.Error(t, Validate(eo))
+}
+
+func TestPaymentCurrencyInfo(t *testing.T) {
+	data, err := json.Marshal(Payment{Currency: "KWD", Amount: money.MustParse("1.005")})
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, 1.005, got["amount"])
+	assert.Equal(t, map[string]any{
+		"code":        "KWD",
+		"numeric":     "414",
+		"minor_units": float64(3),
+		"name":        "Kuwaiti Dinar",
+	}, got["currency_info"])
+}
+
+func TestValidateWebhook(t *testing.T) {
+	sub := WebhookSubscription{
+		URL:         "https://partner.example/hooks",
+		Events:      []string{"order.created", "order.refunded"},
+		MaxAttempts: 5,
+	}
+	assert.NoError(t, Validate(sub))
+
+	sub.Events = []string{"order.shipped"}
+	assert.Error(t, Validate(sub))
+
+	sub.Events = []string{"order.created"}
+	sub.URL = "ftp://partner.example"
+	assert.Error(t, Validate(sub))
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// OrderListFilter — выборка заказов по возрастанию id, начиная
+// со следующего за AfterID. Пустые поля не ограничивают выборку,
+// From и To задают интервал даты создания [From, To).
+type OrderListFilter struct {
+	CustomerID      string
+	DeliveryService string
+	From            time.Time
+	To              time.Time
+	AfterID         int64
+	Limit           int
+}
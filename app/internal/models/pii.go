@@ -0,0 +1,10 @@
+package models
+
+// PIIPaths — поля заказа с персональными данными получателя в виде путей
+// audit.Diff.
+var PIIPaths = []string{
+	"$.delivery.name",
+	"$.delivery.phone",
+	"$.delivery.email",
+	"$.delivery.address",
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"test-task/internal/money"
+)
+
+// Расхождения сверки с реестром провайдера.
+const (
+	// ReconMissingPayment — транзакция есть в реестре, но не в базе.
+	ReconMissingPayment = "missing_payment"
+	// ReconMissingSettlement — оплата за день реестра есть в базе, но не в реестре.
+	ReconMissingSettlement = "missing_settlement"
+	// ReconDuplicate — транзакция встречается в реестре или в базе больше одного раза.
+	ReconDuplicate = "duplicate"
+	// ReconAmountMismatch — суммы в реестре и в базе различаются.
+	ReconAmountMismatch = "amount_mismatch"
+	// ReconCurrencyMismatch — валюты в реестре и в базе различаются.
+	ReconCurrencyMismatch = "currency_mismatch"
+)
+
+// SettlementRow — строка реестра провайдера. Line — номер строки в файле.
+type SettlementRow struct {
+	Line        int64
+	Transaction string
+	Amount      money.Amount
+	Currency    string
+}
+
+// ReconciliationRun — сверка одного реестра. Discrepancies — число
+// расхождений по видам.
+type ReconciliationRun struct {
+	ID             int64            `json:"id"`
+	Provider       string           `json:"provider"`
+	SettlementDate time.Time        `json:"settlement_date"`
+	FileName       string           `json:"file_name"`
+	Rows           int64            `json:"rows"`
+	Matched        int64            `json:"matched"`
+	Discrepancies  map[string]int64 `json:"discrepancies"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// ReconciliationResult — расхождение по транзакции. Expected* — данные оплаты
+// в базе, Settled* и Line — из реестра; отсутствующая сторона пуста.
+type ReconciliationResult struct {
+	ID               int64         `json:"id"`
+	RunID            int64         `json:"run_id"`
+	Transaction      string        `json:"transaction"`
+	Status           string        `json:"status"`
+	ExpectedAmount   *money.Amount `json:"expected_amount"`
+	SettledAmount    *money.Amount `json:"settled_amount"`
+	ExpectedCurrency *string       `json:"expected_currency"`
+	SettledCurrency  *string       `json:"settled_currency"`
+	Line             *int64        `json:"line"`
+}
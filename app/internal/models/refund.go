@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"test-task/internal/money"
+)
+
+// Refund — возврат денег по заказу, полный или частичный.
+type Refund struct {
+	ID        int64        `json:"id"`
+	OrderID   int64        `json:"order_id"`
+	Amount    money.Amount `json:"amount" validate:"required,gt=0"`
+	Reason    string       `json:"reason" validate:"max=500"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Return — возврат позиции заказа. RefundID указывает на возврат денег
+// за позицию, если он оформлен вместе с ней.
+type Return struct {
+	ID        int64     `json:"id"`
+	OrderID   int64     `json:"order_id"`
+	ItemID    int64     `json:"item_id"`
+	RefundID  *int64    `json:"refund_id,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReturnRequest — позиции заказа, которые возвращает покупатель.
+// Refund оформляет возврат денег на сумму total_price этих позиций.
+type ReturnRequest struct {
+	ItemIDs []int64 `json:"item_ids" validate:"required,min=1,dive,gt=0"`
+	Reason  string  `json:"reason" validate:"max=500"`
+	Refund  bool    `json:"refund"`
+}
+
+// OrderReturn — результат оформления возврата позиций.
+type OrderReturn struct {
+	Returns []*Return `json:"returns"`
+	Refund  *Refund   `json:"refund,omitempty"`
+}
+
+// OrderRefunds — возвраты по заказу и сумма оплаты за их вычетом.
+type OrderRefunds struct {
+	Amount    money.Amount `json:"amount"`
+	Refunded  money.Amount `json:"refunded"`
+	NetAmount money.Amount `json:"net_amount"`
+	Refunds   []*Refund    `json:"refunds"`
+	Returns   []*Return    `json:"returns"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"test-task/internal/money"
+)
+
+// SearchQuery — строка поиска и страница результатов. Private разрешает
+// искать по персональным данным получателя: имени, адресу, email и телефону.
+type SearchQuery struct {
+	Text    string
+	Limit   int
+	Offset  int
+	Private bool
+}
+
+// OrderSummary — краткие сведения о заказе в результатах поиска
+// и в потоке новых заказов.
+type OrderSummary struct {
+	ID              int64        `json:"id"`
+	OrderUID        string       `json:"order_uid"`
+	TrackNumber     string       `json:"track_number"`
+	Entry           string       `json:"entry"`
+	CustomerID      string       `json:"customer_id"`
+	DeliveryService string       `json:"delivery_service"`
+	DateCreated     time.Time    `json:"date_created"`
+	Currency        string       `json:"currency"`
+	Amount          money.Amount `json:"amount"`
+	Items           int64        `json:"items"`
+}
+
+// Summarize возвращает краткие сведения о заказе с позициями.
+func Summarize(eo *ExtendedOrder) OrderSummary {
+	return OrderSummary{
+		ID:              eo.Order.ID,
+		OrderUID:        eo.Order.OrderUID,
+		TrackNumber:     eo.Order.TrackNumber,
+		Entry:           eo.Order.Entry,
+		CustomerID:      eo.Order.CustomerID,
+		DeliveryService: eo.Order.DeliveryService,
+		DateCreated:     eo.Order.DateCreated,
+		Currency:        eo.Payment.Currency,
+		Amount:          eo.Payment.Amount,
+		Items:           int64(len(eo.Items)),
+	}
+}
+
+// SearchMatch — поле заказа, совпавшее с запросом. В Highlight совпавшие
+// части значения обёрнуты в <mark></mark>.
+type SearchMatch struct {
+	Field     string `json:"field"`
+	Value     string `json:"value"`
+	Highlight string `json:"highlight"`
+}
+
+type SearchHit struct {
+	Order   OrderSummary   `json:"order"`
+	Rank    float64        `json:"rank"`
+	Matches []*SearchMatch `json:"matches"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// TrackedOrder — заказ, найденный по трек-номеру. Items — позиции с этим
+// трек-номером; если он указан у самого заказа, то все позиции заказа.
+type TrackedOrder struct {
+	Order *ExtendedOrder `json:"order"`
+	Items []*Item        `json:"items"`
+}
+
+// MatchTrack возвращает заказ eo с позициями, относящимися к трек-номеру track,
+// или false, если трек-номер не указан ни у заказа, ни у позиций.
+func MatchTrack(eo *ExtendedOrder, track string) (*TrackedOrder, bool) {
+	if eo.Order.TrackNumber == track {
+		return &TrackedOrder{Order: eo, Items: eo.Items}, true
+	}
+
+	items := make([]*Item, 0)
+	for _, item := range eo.Items {
+		if item.TrackNumber == track {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	return &TrackedOrder{Order: eo, Items: items}, true
+}
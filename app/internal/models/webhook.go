@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы доставки вебхука.
+const (
+	WebhookPending   = "pending"
+	WebhookSending   = "sending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// WebhookSubscription — подписка партнёра на события заказов. Secret
+// подписывает тело запроса и отдаётся только при создании подписки.
+type WebhookSubscription struct {
+	ID     int64    `json:"id"`
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,event_type"`
+	Secret string   `json:"secret,omitempty" validate:"omitempty,min=16,max=256"`
+	// MaxAttempts и BackoffSeconds задают повторы доставки: пауза перед
+	// повтором удваивается, начиная с BackoffSeconds.
+	MaxAttempts    int       `json:"max_attempts" validate:"gte=1,lte=20"`
+	BackoffSeconds int       `json:"backoff_seconds" validate:"gte=0,lte=3600"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery — отправка одного события одной подписке.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventType      string          `json:"event_type"`
+	OrderID        int64           `json:"order_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+
+	// Subscription заполняется только у доставок, взятых в отправку.
+	Subscription *WebhookSubscription `json:"-"`
+}
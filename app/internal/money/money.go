@@ -0,0 +1,211 @@
+// Package money — денежные суммы с фиксированной точностью без ошибок округления float64.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Scale — число знаков после запятой, которое хранит Amount.
+const Scale = 4
+
+var (
+	ErrInvalid   = errors.New("invalid money amount")
+	ErrPrecision = errors.New("money amount has too many decimal places")
+	ErrOverflow  = errors.New("money amount overflow")
+)
+
+var scaleFactor = big.NewInt(10_000)
+
+// Amount — сумма в десятитысячных долях единицы валюты.
+// Нулевое значение — ноль.
+type Amount struct {
+	units int64
+}
+
+// FromInt возвращает сумму из целого числа единиц.
+func FromInt(n int64) Amount {
+	return Amount{units: n * 10_000}
+}
+
+// FromUnits возвращает сумму из десятитысячных долей.
+func FromUnits(units int64) Amount {
+	return Amount{units: units}
+}
+
+// Parse разбирает десятичную запись вида "-12.34" или "1.5e3".
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "/") {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(scaleFactor))
+	if !r.IsInt() {
+		return Amount{}, fmt.Errorf("%w: %q", ErrPrecision, s)
+	}
+
+	return fromBig(r.Num())
+}
+
+// MustParse как Parse, но паникует при ошибке. Для констант и тестов.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func fromBig(n *big.Int) (Amount, error) {
+	if !n.IsInt64() {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{units: n.Int64()}, nil
+}
+
+func (a Amount) Units() int64 { return a.units }
+
+func (a Amount) IsZero() bool { return a.units == 0 }
+
+func (a Amount) Sign() int {
+	switch {
+	case a.units > 0:
+		return 1
+	case a.units < 0:
+		return -1
+	}
+	return 0
+}
+
+func (a Amount) Add(b Amount) Amount { return Amount{units: a.units + b.units} }
+
+func (a Amount) Sub(b Amount) Amount { return Amount{units: a.units - b.units} }
+
+func (a Amount) Mul(n int64) Amount { return Amount{units: a.units * n} }
+
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	}
+	return 0
+}
+
+// Places возвращает число значащих знаков после запятой.
+func (a Amount) Places() int {
+	places := Scale
+	for u := a.units; places > 0 && u%10 == 0; u /= 10 {
+		places--
+	}
+	return places
+}
+
+// Sum складывает суммы.
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, a := range amounts {
+		total = total.Add(a)
+	}
+	return total
+}
+
+// String возвращает десятичную запись без лишних нулей: "1817", "453.5".
+func (a Amount) String() string {
+	u := a.units
+	sign := ""
+	if u < 0 {
+		sign = "-"
+	}
+
+	var whole, frac uint64
+	if u == math.MinInt64 {
+		whole, frac = uint64(1<<63)/10_000, uint64(1<<63)%10_000
+	} else {
+		if u < 0 {
+			u = -u
+		}
+		whole, frac = uint64(u)/10_000, uint64(u)%10_000
+	}
+
+	s := sign + strconv.FormatUint(whole, 10)
+	if frac == 0 {
+		return s
+	}
+
+	return s + "." + strings.TrimRight(fmt.Sprintf("%04d", frac), "0")
+}
+
+// MarshalJSON пишет сумму числом, чтобы формат API не изменился.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON принимает и число, и строку: 12.5 и "12.5".
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
+
+// ScanNumeric реализует pgtype.NumericScanner.
+func (a *Amount) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		*a = Amount{}
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: not a finite number", ErrInvalid)
+	}
+
+	units := new(big.Int).Set(n.Int)
+	exp := int64(n.Exp) + Scale
+	if exp >= 0 {
+		units.Mul(units, new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	} else {
+		div := new(big.Int).Exp(big.NewInt(10), big.NewInt(-exp), nil)
+		var rem big.Int
+		units.QuoRem(units, div, &rem)
+		if rem.Sign() != 0 {
+			return ErrPrecision
+		}
+	}
+
+	parsed, err := fromBig(units)
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
+
+// NumericValue реализует pgtype.NumericValuer.
+func (a Amount) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(a.units), Exp: -Scale, Valid: true}, nil
+}
@@ -0,0 +1,91 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in    string
+		units int64
+		err   error
+	}{
+		{"1817", 18_170_000, nil},
+		{"0.1", 1_000, nil},
+		{"-12.34", -123_400, nil},
+		{"1.5e3", 15_000_000, nil},
+		{"0.00001", 0, ErrPrecision},
+		{"abc", 0, ErrInvalid},
+		{"1/3", 0, ErrInvalid},
+		{"1e30", 0, ErrOverflow},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			a, err := Parse(c.in)
+			if c.err != nil {
+				assert.ErrorIs(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.units, a.Units())
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "1817", FromInt(1817).String())
+	assert.Equal(t, "453.5", MustParse("453.50").String())
+	assert.Equal(t, "-0.0001", FromUnits(-1).String())
+	assert.Equal(t, "0", Amount{}.String())
+}
+
+func TestSumIsExact(t *testing.T) {
+	// 0.1 + 0.2 во float64 не равно 0.3
+	assert.Equal(t, MustParse("0.3"), Sum(MustParse("0.1"), MustParse("0.2")))
+}
+
+func TestPlaces(t *testing.T) {
+	assert.Equal(t, 0, FromInt(10).Places())
+	assert.Equal(t, 2, MustParse("10.25").Places())
+	assert.Equal(t, 4, MustParse("0.0001").Places())
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Number Amount `json:"number"`
+		String Amount `json:"string"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"number": 317.1, "string": "1500.25"}`), &v))
+	assert.Equal(t, MustParse("317.1"), v.Number)
+	assert.Equal(t, MustParse("1500.25"), v.String)
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"number": 317.1, "string": 1500.25}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"number": "ten"}`), &v))
+}
+
+func TestNumeric(t *testing.T) {
+	var a Amount
+	require.NoError(t, a.ScanNumeric(pgtype.Numeric{Int: big.NewInt(45350), Exp: -2, Valid: true}))
+	assert.Equal(t, MustParse("453.5"), a)
+
+	require.NoError(t, a.ScanNumeric(pgtype.Numeric{Int: big.NewInt(12), Exp: 2, Valid: true}))
+	assert.Equal(t, FromInt(1200), a)
+
+	assert.ErrorIs(t, a.ScanNumeric(pgtype.Numeric{Int: big.NewInt(1), Exp: -5, Valid: true}), ErrPrecision)
+
+	n, err := MustParse("453.5").NumericValue()
+	require.NoError(t, err)
+	require.NoError(t, a.ScanNumeric(n))
+	assert.Equal(t, MustParse("453.5"), a)
+}
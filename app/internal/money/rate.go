@@ -0,0 +1,144 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RateScale — число знаков после запятой, с которым курс хранится в базе.
+const RateScale = 10
+
+// Rate — курс валюты, точная положительная десятичная дробь.
+// Нулевое значение не является корректным курсом.
+type Rate struct {
+	r *big.Rat
+}
+
+// One — курс валюты к самой себе.
+func One() Rate {
+	return Rate{r: big.NewRat(1, 1)}
+}
+
+// ParseRate разбирает положительный курс в десятичной записи.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "/") {
+		return Rate{}, fmt.Errorf("%w: rate %q", ErrInvalid, s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("%w: rate %q", ErrInvalid, s)
+	}
+
+	return Rate{r: r}, nil
+}
+
+func (r Rate) IsZero() bool { return r.r == nil || r.r.Sign() == 0 }
+
+// Div возвращает кросс-курс r / o.
+func (r Rate) Div(o Rate) Rate {
+	return Rate{r: new(big.Rat).Quo(r.r, o.r)}
+}
+
+func (r Rate) String() string {
+	if r.r == nil {
+		return "0"
+	}
+
+	s := r.r.FloatString(RateScale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// Convert умножает сумму на курс и округляет до places знаков,
+// половину — от нуля.
+func (a Amount) Convert(r Rate, places int) (Amount, error) {
+	if r.IsZero() {
+		return Amount{}, fmt.Errorf("%w: zero rate", ErrInvalid)
+	}
+	if places > Scale {
+		places = Scale
+	}
+
+	v := new(big.Rat).Mul(big.NewRat(a.units, 10_000), r.r)
+
+	step := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	v.Mul(v, new(big.Rat).SetInt(step))
+
+	// округление половины от нуля: trunc(v ± 1/2)
+	half := big.NewRat(1, 2)
+	if v.Sign() < 0 {
+		v.Sub(v, half)
+	} else {
+		v.Add(v, half)
+	}
+	rounded := new(big.Int).Quo(v.Num(), v.Denom())
+
+	units := rounded.Mul(rounded, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(Scale-places)), nil))
+
+	return fromBig(units)
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// ScanNumeric реализует pgtype.NumericScanner.
+func (r *Rate) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		*r = Rate{}
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: not a finite rate", ErrInvalid)
+	}
+
+	v := new(big.Rat).SetInt(n.Int)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(n.Exp))), nil))
+	if n.Exp >= 0 {
+		v.Mul(v, pow)
+	} else {
+		v.Quo(v, pow)
+	}
+
+	*r = Rate{r: v}
+	return nil
+}
+
+// NumericValue реализует pgtype.NumericValuer.
+func (r Rate) NumericValue() (pgtype.Numeric, error) {
+	var n pgtype.Numeric
+	if err := n.Scan(r.String()); err != nil {
+		return pgtype.Numeric{}, err
+	}
+	return n, nil
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
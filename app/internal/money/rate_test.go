@@ -0,0 +1,57 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	r, err := ParseRate("92.5")
+	require.NoError(t, err)
+	assert.Equal(t, "92.5", r.String())
+
+	_, err = ParseRate("0")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = ParseRate("-1")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestConvert(t *testing.T) {
+	usd, _ := ParseRate("92.5")
+	eur, _ := ParseRate("100")
+
+	got, err := FromInt(1817).Convert(usd, 2)
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("168072.5"), got)
+
+	// 1817 USD в EUR через базовую валюту: 1817 * 92.5 / 100
+	got, err = FromInt(1817).Convert(usd.Div(eur), 2)
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("1680.73"), got)
+
+	// JPY без дробной части, половина округляется от нуля
+	got, err = MustParse("2.5").Convert(One(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, FromInt(3), got)
+
+	got, err = MustParse("-2.5").Convert(One(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, FromInt(-3), got)
+
+	_, err = FromInt(1).Convert(Rate{}, 2)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestRateNumeric(t *testing.T) {
+	r, _ := ParseRate("0.0061234567")
+
+	n, err := r.NumericValue()
+	require.NoError(t, err)
+
+	var got Rate
+	require.NoError(t, got.ScanNumeric(n))
+	assert.Equal(t, r.String(), got.String())
+}
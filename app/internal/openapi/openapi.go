@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// streamExtension помечает операции, ответ которых пишется потоком
+// (SSE, WebSocket): их ответы не проверяются.
+const streamExtension = "x-stream"
+
+// Load разбирает встроенную спецификацию и проверяет её.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	return doc, nil
+}
+
+// PathOf переводит путь маршрута echo в шаблон OpenAPI: /order/:id -> /order/{id}.
+func PathOf(echoPath string) string {
+	parts := strings.Split(echoPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Validator проверяет запросы, а при необходимости и ответы, по спецификации.
+// Маршруты, которых нет в спецификации, пропускаются без проверки.
+type Validator struct {
+	doc       *openapi3.T
+	options   *openapi3filter.Options
+	responses bool
+	log       *zap.Logger
+}
+
+func NewValidator(doc *openapi3.T, log *zap.Logger) *Validator {
+	options := &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		SkipSettingDefaults:   true,
+		IncludeResponseStatus: true,
+	}
+	// по умолчанию в текст ошибки попадают схема и значение целиком
+	options.WithCustomSchemaErrorFunc(func(err *openapi3.SchemaError) string {
+		return err.Reason
+	})
+
+	return &Validator{
+		doc:     doc,
+		options: options,
+		log:     log,
+	}
+}
+
+// WithResponses включает проверку ответов. Ответ клиенту не меняется,
+// несоответствие спецификации только пишется в лог.
+func (v *Validator) WithResponses(enabled bool) *Validator {
+	v.responses = enabled
+	return v
+}
+
+// Middleware отвечает 400, если запрос не соответствует спецификации.
+func (v *Validator) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		route, params := v.route(c)
+		if route == nil {
+			return next(c)
+		}
+
+		req := c.Request()
+		input := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: params,
+			Route:      route,
+			Options:    v.options,
+		}
+		if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": requestError(err)})
+		}
+
+		if !v.responses || route.Operation.Extensions[streamExtension] == true {
+			return next(c)
+		}
+
+		res := c.Response()
+		body := &teeWriter{ResponseWriter: res.Writer}
+		res.Writer = body
+		defer func() { res.Writer = body.ResponseWriter }()
+
+		if err := next(c); err != nil {
+			return err
+		}
+
+		if err := openapi3filter.ValidateResponse(req.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 res.Status,
+			Header:                 res.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(body.buf.Bytes())),
+			Options:                v.options,
+		}); err != nil {
+			v.log.Warn("response does not match openapi spec",
+				zap.String("method", req.Method),
+				zap.String("path", route.Path),
+				zap.Int("status", res.Status),
+				zap.Error(err),
+			)
+		}
+		return nil
+	}
+}
+
+// route находит операцию спецификации по маршруту, выбранному echo.
+func (v *Validator) route(c echo.Context) (*routers.Route, map[string]string) {
+	path := PathOf(c.Path())
+	item := v.doc.Paths.Find(path)
+	if item == nil {
+		return nil, nil
+	}
+	op := item.GetOperation(c.Request().Method)
+	if op == nil {
+		return nil, nil
+	}
+
+	params := make(map[string]string, len(c.ParamNames()))
+	for i, name := range c.ParamNames() {
+		params[name] = c.ParamValues()[i]
+	}
+
+	return &routers.Route{
+		Spec:      v.doc,
+		Path:      path,
+		PathItem:  item,
+		Method:    c.Request().Method,
+		Operation: op,
+	}, params
+}
+
+// requestError оставляет от ошибки проверки описание без служебных подробностей.
+func requestError(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return err.Error()
+	}
+
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case reqErr.Parameter != nil && errors.As(reqErr.Err, &schemaErr):
+		return fmt.Sprintf("%s %q: %s", reqErr.Parameter.In, reqErr.Parameter.Name, schemaErr.Reason)
+	case reqErr.RequestBody != nil && errors.As(reqErr.Err, &schemaErr):
+		if field := schemaErr.JSONPointer(); len(field) > 0 {
+			return fmt.Sprintf("body %q: %s", strings.Join(field, "."), schemaErr.Reason)
+		}
+		return "body: " + schemaErr.Reason
+	}
+	return reqErr.Error()
+}
+
+// teeWriter копирует тело ответа для проверки после обработчика.
+type teeWriter struct {
+	http.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,141 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"test-task/internal/handler"
+	"test-task/internal/openapi"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSpecCoversRoutes(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	e := echo.New()
+	handler.NewHandler(nil, nil, zap.NewNop()).RegisterRoutes(e)
+
+	routes := e.Routes()
+	require.NotEmpty(t, routes)
+
+	for _, r := range routes {
+		// e.Group добавляет свои маршруты на все методы, отвечающие 404
+		if strings.HasPrefix(r.Name, "github.com/labstack/echo.") {
+			continue
+		}
+
+		path := openapi.PathOf(r.Path)
+		item := doc.Paths.Value(path)
+		if !assert.NotNil(t, item, "route %s %s is missing from openapi spec", r.Method, r.Path) {
+			continue
+		}
+		assert.NotNil(t, item.GetOperation(r.Method), "route %s %s is missing from openapi spec", r.Method, r.Path)
+	}
+}
+
+func TestPathOf(t *testing.T) {
+	assert.Equal(t, "/order/{id}/status", openapi.PathOf("/order/:id/status"))
+	assert.Equal(t, "/track/{track_number}", openapi.PathOf("/track/:track_number"))
+	assert.Equal(t, "/webhooks", openapi.PathOf("/webhooks"))
+}
+
+func newServer(t *testing.T, log *zap.Logger, responses bool) *echo.Echo {
+	t.Helper()
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(openapi.NewValidator(doc, log).WithResponses(responses).Middleware)
+	return e
+}
+
+func serve(e *echo.Echo, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestValidatorRequests(t *testing.T) {
+	e := newServer(t, zap.NewNop(), false)
+
+	called := 0
+	ok := func(c echo.Context) error {
+		called++
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.PATCH("/order/:id/status", ok)
+	e.GET("/fraud/flags", ok)
+	e.GET("/internal", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		error  string
+	}{
+		{"valid", http.MethodPatch, "/order/1/status", `{"status": 2}`, http.StatusNoContent, ""},
+		{"invalid path param", http.MethodPatch, "/order/abc/status", `{"status": 2}`, http.StatusBadRequest, `"id" in path`},
+		{"missing body field", http.MethodPatch, "/order/1/status", `{}`, http.StatusBadRequest, "status"},
+		{"wrong body type", http.MethodPatch, "/order/1/status", `{"status": "done"}`, http.StatusBadRequest, `body "status"`},
+		{"query out of range", http.MethodGet, "/fraud/flags?limit=500", "", http.StatusBadRequest, `query "limit"`},
+		{"query default", http.MethodGet, "/fraud/flags", "", http.StatusNoContent, ""},
+		{"route not in spec", http.MethodGet, "/internal?limit=abc", "", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := called
+			rec := serve(e, tt.method, tt.target, tt.body)
+
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.error != "" {
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Contains(t, body["error"], tt.error)
+				assert.Equal(t, before, called)
+			} else {
+				assert.Equal(t, before+1, called)
+			}
+		})
+	}
+}
+
+func TestValidatorResponses(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	e := newServer(t, zap.New(core), true)
+
+	e.GET("/fraud/flags", func(c echo.Context) error {
+		if c.QueryParam("offset") == "1" {
+			return c.JSON(http.StatusOK, map[string]any{"orders": "none"})
+		}
+		return c.JSON(http.StatusOK, map[string]any{"orders": []any{}, "limit": 20, "offset": 0})
+	})
+
+	rec := serve(e, http.MethodGet, "/fraud/flags", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Zero(t, logs.Len())
+
+	// несоответствие только пишется в лог, ответ клиенту не меняется
+	rec = serve(e, http.MethodGet, "/fraud/flags?offset=1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"orders": "none"}`, rec.Body.String())
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "/fraud/flags", logs.All()[0].ContextMap()["path"])
+}
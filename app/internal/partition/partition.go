@@ -0,0 +1,89 @@
+package partition
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	Interval time.Duration
+	// Ahead — на сколько месяцев вперёд заранее создаются секции.
+	Ahead int
+	// Retention — секции, целиком старше Retention, отсоединяются.
+	// Ноль отключает отсоединение.
+	Retention time.Duration
+}
+
+// Maintainer создаёт будущие месячные секции orders и items
+// и отсоединяет устаревшие.
+type Maintainer struct {
+	repo repository.PartitionRepository
+	cfg  Config
+	log  *zap.Logger
+	now  func() time.Time
+}
+
+func New(repo repository.PartitionRepository, cfg Config, log *zap.Logger) *Maintainer {
+	return &Maintainer{
+		repo: repo,
+		cfg:  cfg,
+		log:  log,
+		now:  time.Now,
+	}
+}
+
+func (m *Maintainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.MaintainOnce(ctx); err != nil && ctx.Err() == nil {
+			m.log.Error("error on maintaining partitions", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			m.log.Info("partition maintenance stopped by context")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Maintainer) MaintainOnce(ctx context.Context) error {
+	now := m.now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= m.cfg.Ahead; i++ {
+		if err := m.repo.CreateMonthPartitions(ctx, current.AddDate(0, i, 0)); err != nil {
+			return err
+		}
+	}
+
+	if m.cfg.Retention <= 0 {
+		return nil
+	}
+
+	months, err := m.repo.ListMonthPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-m.cfg.Retention)
+	for _, month := range months {
+		if month.AddDate(0, 1, 0).After(cutoff) {
+			break
+		}
+
+		if err := m.repo.DetachMonthPartitions(ctx, month); err != nil {
+			return err
+		}
+		m.log.Info("partitions detached", zap.Time("month", month))
+	}
+
+	return nil
+}
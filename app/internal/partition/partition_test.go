@@ -0,0 +1,74 @@
+package partition
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeRepo struct {
+	partitions []time.Time
+	created    []time.Time
+	detached   []time.Time
+}
+
+func (r *fakeRepo) ListMonthPartitions(ctx context.Context) ([]time.Time, error) {
+	return r.partitions, nil
+}
+
+func (r *fakeRepo) CreateMonthPartitions(ctx context.Context, month time.Time) error {
+	r.created = append(r.created, month)
+	return nil
+}
+
+func (r *fakeRepo) DetachMonthPartitions(ctx context.Context, month time.Time) error {
+	r.detached = append(r.detached, month)
+	return nil
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMaintainOnce(t *testing.T) {
+	repo := &fakeRepo{
+		partitions: []time.Time{
+			month(2025, time.August),
+			month(2025, time.September),
+			month(2025, time.October),
+			month(2026, time.October),
+		},
+	}
+
+	m := New(repo, Config{Ahead: 2, Retention: 365 * 24 * time.Hour}, zap.NewNop())
+	m.now = func() time.Time { return time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, m.MaintainOnce(context.Background()))
+
+	assert.Equal(t, []time.Time{
+		month(2026, time.October),
+		month(2026, time.November),
+		month(2026, time.December),
+	}, repo.created)
+
+	// октябрь 2025 заканчивается позже границы хранения
+	assert.Equal(t, []time.Time{
+		month(2025, time.August),
+		month(2025, time.September),
+	}, repo.detached)
+}
+
+func TestMaintainOnceRetentionDisabled(t *testing.T) {
+	repo := &fakeRepo{partitions: []time.Time{month(2000, time.January)}}
+
+	m := New(repo, Config{Ahead: 0}, zap.NewNop())
+
+	require.NoError(t, m.MaintainOnce(context.Background()))
+
+	assert.Len(t, repo.created, 1)
+	assert.Empty(t, repo.detached)
+}
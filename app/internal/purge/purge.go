@@ -0,0 +1,130 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	Interval         time.Duration
+	Retention        time.Duration
+	DeletedRetention time.Duration
+	BatchSize        int
+}
+
+// OnPurgeFunc вызывается после каждой удалённой пачки заказов.
+type OnPurgeFunc func(ids []int64)
+
+// Archiver выгружает заказы старше before перед их удалением.
+type Archiver interface {
+	Archive(ctx context.Context, before time.Time, onDelete func(ids []int64)) (int, error)
+}
+
+type Purger struct {
+	repo     repository.RetentionRepository
+	archiver Archiver
+	cfg      Config
+	onPurge  OnPurgeFunc
+	log      *zap.Logger
+	now      func() time.Time
+}
+
+func New(repo repository.RetentionRepository, cfg Config, onPurge OnPurgeFunc, log *zap.Logger) *Purger {
+	return &Purger{
+		repo:    repo,
+		cfg:     cfg,
+		onPurge: onPurge,
+		log:     log,
+		now:     time.Now,
+	}
+}
+
+// WithArchiver включает архивирование заказов старше Retention
+// вместо их удаления.
+func (p *Purger) WithArchiver(archiver Archiver) *Purger {
+	p.archiver = archiver
+	return p
+}
+
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PurgeOnce(ctx); err != nil && ctx.Err() == nil {
+			p.log.Error("error on purging orders", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			p.log.Info("purge stopped by context")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce удаляет пачками все просроченные заказы и возвращает их количество.
+// Если блокировку держит другая реплика, проход пропускается.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	ctx = audit.WithActor(ctx, audit.SystemActor("purge"))
+
+	now := p.now().UTC()
+	var createdBefore, deletedBefore time.Time
+	if p.cfg.Retention > 0 {
+		createdBefore = now.Add(-p.cfg.Retention)
+	}
+	if p.cfg.DeletedRetention > 0 {
+		deletedBefore = now.Add(-p.cfg.DeletedRetention)
+	}
+
+	total := 0
+
+	if p.archiver != nil && !createdBefore.IsZero() {
+		archived, err := p.archiver.Archive(ctx, createdBefore, p.onPurge)
+		if err != nil {
+			return total, err
+		}
+		total += archived
+
+		// старые заказы уже выгружены и удалены архиватором,
+		// дальше чистятся только мягко удалённые
+		createdBefore = time.Time{}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		ids, err := p.repo.PurgeBatch(ctx, createdBefore, deletedBefore, p.cfg.BatchSize)
+		if errors.Is(err, repository.ErrLockNotAcquired) {
+			p.log.Info("purge is running on another replica, skipping")
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+
+		total += len(ids)
+		if len(ids) > 0 && p.onPurge != nil {
+			p.onPurge(ids)
+		}
+
+		if len(ids) < p.cfg.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		p.log.Info("orders purged", zap.Int("count", total))
+	}
+
+	return total, nil
+}
@@ -0,0 +1,141 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type purgeCall struct {
+	createdBefore time.Time
+	deletedBefore time.Time
+	limit         int
+	actor         models.Actor
+}
+
+type fakeRetentionRepository struct {
+	batches [][]int64
+	err     error
+	calls   []purgeCall
+}
+
+func (f *fakeRetentionRepository) PurgeBatch(
+	ctx context.Context,
+	createdBefore, deletedBefore time.Time,
+	limit int,
+) ([]int64, error) {
+	f.calls = append(f.calls, purgeCall{createdBefore, deletedBefore, limit, audit.ActorFromContext(ctx)})
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+type fakeArchiver struct {
+	before time.Time
+	ids    []int64
+}
+
+func (f *fakeArchiver) Archive(_ context.Context, before time.Time, onDelete func(ids []int64)) (int, error) {
+	f.before = before
+	onDelete(f.ids)
+	return len(f.ids), nil
+}
+
+func TestPurger_PurgeOnce(t *testing.T) {
+	now := time.Date(2025, time.September, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("purges in batches", func(t *testing.T) {
+		repo := &fakeRetentionRepository{batches: [][]int64{{1, 2}, {3, 4}, {5}}}
+
+		var purged []int64
+		p := New(repo, Config{
+			Retention:        24 * time.Hour,
+			DeletedRetention: time.Hour,
+			BatchSize:        2,
+		}, func(ids []int64) { purged = append(purged, ids...) }, zap.NewNop())
+		p.now = func() time.Time { return now }
+
+		total, err := p.PurgeOnce(t.Context())
+		require.NoError(t, err)
+
+		assert.Equal(t, 5, total)
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, purged)
+		require.Len(t, repo.calls, 3)
+		assert.Equal(t, now.Add(-24*time.Hour), repo.calls[0].createdBefore)
+		assert.Equal(t, now.Add(-time.Hour), repo.calls[0].deletedBefore)
+		assert.Equal(t, 2, repo.calls[0].limit)
+		assert.Equal(t, audit.SystemActor("purge"), repo.calls[0].actor)
+	})
+
+	t.Run("zero retention disables criterion", func(t *testing.T) {
+		repo := &fakeRetentionRepository{}
+
+		p := New(repo, Config{DeletedRetention: time.Hour, BatchSize: 10}, nil, zap.NewNop())
+		p.now = func() time.Time { return now }
+
+		_, err := p.PurgeOnce(t.Context())
+		require.NoError(t, err)
+
+		require.Len(t, repo.calls, 1)
+		assert.True(t, repo.calls[0].createdBefore.IsZero())
+	})
+
+	t.Run("lock held by another replica", func(t *testing.T) {
+		repo := &fakeRetentionRepository{err: repository.ErrLockNotAcquired}
+
+		p := New(repo, Config{Retention: time.Hour, BatchSize: 10}, nil, zap.NewNop())
+
+		total, err := p.PurgeOnce(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Len(t, repo.calls, 1)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		errDB := errors.New("db is down")
+		repo := &fakeRetentionRepository{err: errDB}
+
+		p := New(repo, Config{Retention: time.Hour, BatchSize: 10}, nil, zap.NewNop())
+
+		_, err := p.PurgeOnce(t.Context())
+		assert.ErrorIs(t, err, errDB)
+	})
+
+	t.Run("archives instead of deleting", func(t *testing.T) {
+		repo := &fakeRetentionRepository{batches: [][]int64{{7}}}
+		archiver := &fakeArchiver{ids: []int64{1, 2, 3}}
+
+		var purged []int64
+		p := New(repo, Config{
+			Retention:        24 * time.Hour,
+			DeletedRetention: time.Hour,
+			BatchSize:        10,
+		}, func(ids []int64) { purged = append(purged, ids...) }, zap.NewNop()).WithArchiver(archiver)
+		p.now = func() time.Time { return now }
+
+		total, err := p.PurgeOnce(t.Context())
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, total)
+		assert.Equal(t, now.Add(-24*time.Hour), archiver.before)
+		assert.Equal(t, []int64{1, 2, 3, 7}, purged)
+		require.Len(t, repo.calls, 1)
+		assert.True(t, repo.calls[0].createdBefore.IsZero())
+		assert.Equal(t, now.Add(-time.Hour), repo.calls[0].deletedBefore)
+	})
+}
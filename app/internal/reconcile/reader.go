@@ -0,0 +1,109 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+)
+
+// Обязательные колонки реестра, остальные колонки пропускаются.
+var requiredColumns = []string{"transaction", "amount", "currency"}
+
+// Reader построчно читает реестр провайдера из CSV с заголовком, в котором
+// есть колонки transaction, amount и currency в любом порядке:
+//
+//	transaction,amount,currency,settled_at
+//	b563feb7b2b84b6test,1817,USD,2021-11-26T06:22:19Z
+//
+// Файл не загружается в память целиком, поэтому подходит для реестров
+// в миллионы строк.
+type Reader struct {
+	csv     *csv.Reader
+	columns map[string]int
+	row     *models.SettlementRow
+	err     error
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q in header", name)
+		}
+	}
+
+	return &Reader{csv: reader, columns: columns}, nil
+}
+
+// Next читает следующую строку. Возвращает false в конце файла или при
+// ошибке, которую затем отдаёт Err.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	rec, err := r.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return false
+	}
+	if err != nil {
+		r.err = err
+		return false
+	}
+
+	line, _ := r.csv.FieldPos(0)
+	row, err := r.parseRecord(rec)
+	if err != nil {
+		r.err = fmt.Errorf("line %d: %w", line, err)
+		return false
+	}
+	row.Line = int64(line)
+	r.row = row
+
+	return true
+}
+
+// Row возвращает строку, прочитанную последним вызовом Next.
+func (r *Reader) Row() *models.SettlementRow { return r.row }
+
+func (r *Reader) Err() error { return r.err }
+
+func (r *Reader) parseRecord(rec []string) (*models.SettlementRow, error) {
+	transaction := strings.TrimSpace(rec[r.columns["transaction"]])
+	if transaction == "" {
+		return nil, errors.New("empty transaction")
+	}
+
+	amount, err := money.Parse(strings.TrimSpace(rec[r.columns["amount"]]))
+	if err != nil {
+		return nil, fmt.Errorf("amount: %w", err)
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(rec[r.columns["currency"]]))
+	if len(currency) != 3 {
+		return nil, fmt.Errorf("invalid currency %q", currency)
+	}
+
+	return &models.SettlementRow{
+		Transaction: transaction,
+		Amount:      amount,
+		Currency:    currency,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package reconcile
+
+import (
+	"strings"
+	"testing"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, data string) ([]*models.SettlementRow, error) {
+	t.Helper()
+
+	r, err := NewReader(strings.NewReader(data))
+	require.NoError(t, err)
+
+	var rows []*models.SettlementRow
+	for r.Next() {
+		rows = append(rows, r.Row())
+	}
+	return rows, r.Err()
+}
+
+func TestReader(t *testing.T) {
+	rows, err := readAll(t, `settled_at,Currency,transaction,amount
+2021-11-26,usd,b563feb7b2b84b6test,1817
+2021-11-26,JPY,second,1500.00
+`)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, &models.SettlementRow{
+		Line:        2,
+		Transaction: "b563feb7b2b84b6test",
+		Amount:      money.FromInt(1817),
+		Currency:    "USD",
+	}, rows[0])
+	assert.Equal(t, int64(3), rows[1].Line)
+	assert.Equal(t, "JPY", rows[1].Currency)
+	assert.Equal(t, 0, rows[1].Amount.Cmp(money.FromInt(1500)))
+}
+
+func TestReaderMissingColumn(t *testing.T) {
+	_, err := NewReader(strings.NewReader("transaction,amount\nx,1\n"))
+	assert.ErrorContains(t, err, `"currency"`)
+}
+
+func TestReaderInvalidRow(t *testing.T) {
+	tests := []struct {
+		name string
+		row  string
+		err  string
+	}{
+		{"empty transaction", ",1,USD", "line 3: empty transaction"},
+		{"amount", "x,abc,USD", "line 3: amount"},
+		{"currency", "x,1,US", "line 3: invalid currency"},
+		{"fields", "x,1", "line 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readAll(t, "transaction,amount,currency\nok,1,USD\n"+tt.row+"\n")
+			assert.ErrorContains(t, err, tt.err)
+			assert.Len(t, rows, 1)
+		})
+	}
+}
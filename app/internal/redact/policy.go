@@ -0,0 +1,376 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"test-task/internal/audit"
+	"test-task/internal/models"
+)
+
+var orderType = reflect.TypeFor[models.ExtendedOrder]()
+
+// Policy скрывает поля заказа по правилам. Нулевая *Policy ничего не скрывает.
+type Policy struct {
+	rules []rule
+	key   []byte
+}
+
+type rule struct {
+	path   string
+	tokens []string
+	steps  []step
+	action Action
+}
+
+// step — переход к полю структуры по index или, с each, к каждому
+// элементу среза.
+type step struct {
+	index []int
+	each  bool
+}
+
+// NewPolicy проверяет пути по полям models.ExtendedOrder: mask и hash
+// применимы только к строкам.
+func NewPolicy(rules Rules, key []byte) (*Policy, error) {
+	p := &Policy{key: key}
+
+	for path, action := range rules {
+		if _, err := ParseAction(string(action)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if action == Keep {
+			continue
+		}
+
+		tokens, err := parsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		steps, t, err := compile(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if action != Drop && t.Kind() != reflect.String {
+			return nil, fmt.Errorf("%s: %s applies only to strings", path, action)
+		}
+
+		p.rules = append(p.rules, rule{path: path, tokens: tokens, steps: steps, action: action})
+	}
+
+	// порядок правил не зависит от обхода map
+	sort.Slice(p.rules, func(i, j int) bool { return p.rules[i].path < p.rules[j].path })
+
+	return p, nil
+}
+
+// Order возвращает копию заказа со скрытыми полями. Исходный заказ
+// не меняется, общие с ним срезы и указатели на изменённых путях копируются.
+func (p *Policy) Order(eo *models.ExtendedOrder) *models.ExtendedOrder {
+	if p == nil || eo == nil || len(p.rules) == 0 {
+		return eo
+	}
+
+	c := *eo
+	v := reflect.ValueOf(&c).Elem()
+	for _, r := range p.rules {
+		apply(v, r.steps, func(f reflect.Value) {
+			if r.action == Drop {
+				f.Set(reflect.Zero(f.Type()))
+				return
+			}
+			f.SetString(p.redact(r.action, f.String()))
+		})
+	}
+	return &c
+}
+
+// Value скрывает значение поля path, например $.delivery.phone. ok false —
+// поле удаляется целиком.
+func (p *Policy) Value(path, s string) (_ string, ok bool) {
+	if p == nil {
+		return s, true
+	}
+
+	tokens, err := parsePath(path)
+	if err != nil {
+		return s, true
+	}
+	for _, r := range p.rules {
+		if n, ok := match(r.tokens, tokens); ok && n == len(tokens) {
+			if r.action == Drop {
+				return "", false
+			}
+			return p.redact(r.action, s), true
+		}
+	}
+	return s, true
+}
+
+// Changes скрывает поля в изменениях формата audit.Diff. Изменения,
+// которые не удалось разобрать, не показываются вовсе.
+func (p *Policy) Changes(raw json.RawMessage) json.RawMessage {
+	if p == nil || len(raw) == 0 || len(p.rules) == 0 {
+		return raw
+	}
+
+	var changes map[string]*audit.Change
+	if err := decode(raw, &changes); err != nil {
+		return nil
+	}
+
+	for path, change := range changes {
+		tokens, err := parsePath(path)
+		if err != nil {
+			continue
+		}
+		for _, r := range p.rules {
+			n, ok := match(r.tokens, tokens)
+			if !ok {
+				continue
+			}
+			if n < len(r.tokens) {
+				// изменилось поле целиком, скрываем внутри него
+				for _, v := range []any{change.Before, change.After} {
+					p.redactJSON(v, r.tokens[n:], r.action)
+				}
+				continue
+			}
+			if r.action == Drop {
+				delete(changes, path)
+				break
+			}
+			change.Before, _ = p.leaf(r.action, change.Before)
+			change.After, _ = p.leaf(r.action, change.After)
+		}
+	}
+
+	out, err := json.Marshal(changes)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// JSON скрывает поля в JSON заказа, например в сыром сообщении из Kafka.
+// У mask и hash нестроковые значения удаляются.
+func (p *Policy) JSON(data []byte) ([]byte, error) {
+	var v any
+	if err := decode(data, &v); err != nil {
+		return nil, err
+	}
+	if p != nil {
+		for _, r := range p.rules {
+			p.redactJSON(v, r.tokens, r.action)
+		}
+	}
+	return json.Marshal(v)
+}
+
+func (p *Policy) redact(a Action, s string) string {
+	if s == "" {
+		return s
+	}
+	switch a {
+	case Mask:
+		return MaskString(s)
+	case Hash:
+		return hash(p.key, s)
+	}
+	return s
+}
+
+// leaf скрывает значение JSON; ok false — значение надо удалить.
+func (p *Policy) leaf(a Action, v any) (_ any, ok bool) {
+	if v == nil {
+		return nil, true
+	}
+	s, isString := v.(string)
+	if a == Drop || !isString {
+		return nil, false
+	}
+	return p.redact(a, s), true
+}
+
+func (p *Policy) redactJSON(v any, tokens []string, a Action) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	switch c := v.(type) {
+	case map[string]any:
+		child, ok := c[tokens[0]]
+		if !ok {
+			return
+		}
+		if len(tokens) > 1 {
+			p.redactJSON(child, tokens[1:], a)
+		} else if redacted, ok := p.leaf(a, child); ok {
+			c[tokens[0]] = redacted
+		} else {
+			delete(c, tokens[0])
+		}
+	case []any:
+		if tokens[0] != "[*]" {
+			return
+		}
+		for i := range c {
+			if len(tokens) > 1 {
+				p.redactJSON(c[i], tokens[1:], a)
+			} else {
+				c[i], _ = p.leaf(a, c[i])
+			}
+		}
+	}
+}
+
+// apply вызывает set для поля по пути steps в v, копируя по пути
+// указатели и срезы, чтобы не менять данные исходного заказа.
+func apply(v reflect.Value, steps []step, set func(reflect.Value)) {
+	if len(steps) == 0 {
+		set(v)
+		return
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(v.Elem())
+		v.Set(c)
+		v = c.Elem()
+	}
+
+	s := steps[0]
+	if !s.each {
+		apply(v.FieldByIndex(s.index), steps[1:], set)
+		return
+	}
+
+	if v.IsNil() {
+		return
+	}
+	c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(c, v)
+	v.Set(c)
+	for i := 0; i < c.Len(); i++ {
+		apply(c.Index(i), steps[1:], set)
+	}
+}
+
+// compile переводит путь в переходы по полям ExtendedOrder и возвращает
+// тип конечного поля.
+func compile(tokens []string) ([]step, reflect.Type, error) {
+	t := orderType
+	steps := make([]step, 0, len(tokens))
+
+	for _, tok := range tokens {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		if strings.HasPrefix(tok, "[") {
+			if tok != "[*]" {
+				return nil, nil, errors.New("only [*] is allowed as an index")
+			}
+			if t.Kind() != reflect.Slice {
+				return nil, nil, fmt.Errorf("%s is not a list", t)
+			}
+			steps = append(steps, step{each: true})
+			t = t.Elem()
+			continue
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("%s has no field %q", t, tok)
+		}
+		f, ok := jsonField(t, tok)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s has no field %q", t, tok)
+		}
+		steps = append(steps, step{index: f.Index})
+		t = f.Type
+	}
+
+	return steps, t, nil
+}
+
+// jsonField ищет поле по имени в JSON, включая поля встроенных структур.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" || (tag == "" && f.Anonymous) {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// parsePath разбирает путь $.items[0].name на части items, [0], name.
+func parsePath(path string) ([]string, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+
+	var tokens []string
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			tokens = append(tokens, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 2 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			tokens = append(tokens, rest[:end+1])
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return tokens, nil
+}
+
+// match сравнивает начала пути правила и конкретного пути, [*] совпадает
+// с любым индексом. n — длина совпавшего начала.
+func match(rule, path []string) (n int, ok bool) {
+	n = min(len(rule), len(path))
+	for i := 0; i < n; i++ {
+		if rule[i] != path[i] && !(rule[i] == "[*]" && strings.HasPrefix(path[i], "[")) {
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+func decode(data []byte, v any) error {
+	// числа остаются json.Number, чтобы суммы не округлялись
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,188 @@
+package redact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"test-task/internal/auth"
+	"test-task/internal/models"
+)
+
+// Action — что делается с полем заказа.
+type Action string
+
+const (
+	// Keep оставляет поле как есть.
+	Keep Action = "keep"
+	// Mask заменяет большую часть строки звёздочками, см. MaskString.
+	Mask Action = "mask"
+	// Hash заменяет строку её HMAC-SHA256: значения нельзя прочитать,
+	// но одинаковые значения можно сопоставить.
+	Hash Action = "hash"
+	// Drop заменяет поле пустым значением.
+	Drop Action = "drop"
+)
+
+func ParseAction(s string) (Action, error) {
+	switch a := Action(s); a {
+	case Keep, Mask, Hash, Drop:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown redaction action %q", s)
+}
+
+// Rules — действия по путям полей заказа в формате audit.Diff:
+// $.delivery.phone, $.items[*].name.
+type Rules map[string]Action
+
+// DefaultRules — правила ролей, для которых в конфиге ничего не задано:
+// viewer не видит персональных данных получателя, остальные видят всё.
+func DefaultRules(role auth.Role) Rules {
+	if role.SeesPII() {
+		return Rules{}
+	}
+	return uniform(Drop)
+}
+
+// DefaultLogRules — персональные данные получателя в логах хешируются.
+func DefaultLogRules() Rules {
+	return uniform(Hash)
+}
+
+func uniform(a Action) Rules {
+	rules := make(Rules, len(models.PIIPaths))
+	for _, path := range models.PIIPaths {
+		rules[path] = a
+	}
+	return rules
+}
+
+type Config struct {
+	// Roles — правила для ответов клиентам каждой роли. Для роли без
+	// правил (nil) действуют DefaultRules, пустые правила ничего не скрывают.
+	Roles map[auth.Role]Rules
+	// Log — правила для логов, nil — DefaultLogRules.
+	Log Rules
+	// HashKey — ключ HMAC для Hash. Без него ключ случайный, и хеши
+	// совпадают только в пределах одного запуска.
+	HashKey []byte
+}
+
+// Redactor выбирает политику по роли клиента. Нулевой *Redactor
+// работает с правилами по умолчанию.
+type Redactor struct {
+	roles map[auth.Role]*Policy
+	log   *Policy
+}
+
+var std = func() *Redactor {
+	r, err := New(Config{})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}()
+
+func New(cfg Config) (*Redactor, error) {
+	key := cfg.HashKey
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	for role := range cfg.Roles {
+		if _, err := auth.ParseRole(string(role)); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Redactor{roles: make(map[auth.Role]*Policy)}
+	for _, role := range []auth.Role{auth.RoleViewer, auth.RoleSupport, auth.RoleAdmin} {
+		rules := cfg.Roles[role]
+		if rules == nil {
+			rules = DefaultRules(role)
+		}
+		p, err := NewPolicy(rules, key)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", role, err)
+		}
+		r.roles[role] = p
+	}
+
+	rules := cfg.Log
+	if rules == nil {
+		rules = DefaultLogRules()
+	}
+	p, err := NewPolicy(rules, key)
+	if err != nil {
+		return nil, fmt.Errorf("log: %w", err)
+	}
+	r.log = p
+
+	return r, nil
+}
+
+// For возвращает политику для клиента из ctx. Без клиента в контексте
+// действует политика viewer.
+func (r *Redactor) For(ctx context.Context) *Policy {
+	if r == nil {
+		r = std
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		return r.Role(p.Role)
+	}
+	return r.Role(auth.RoleViewer)
+}
+
+// Role возвращает политику роли role, для неизвестной роли — политику viewer.
+func (r *Redactor) Role(role auth.Role) *Policy {
+	if r == nil {
+		r = std
+	}
+	if policy, ok := r.roles[role]; ok {
+		return policy
+	}
+	return r.roles[auth.RoleViewer]
+}
+
+// Log возвращает политику для логов.
+func (r *Redactor) Log() *Policy {
+	if r == nil {
+		r = std
+	}
+	return r.log
+}
+
+// MaskString скрывает строку, оставляя достаточно, чтобы её узнать: у email —
+// первый символ имени ящика и домен, у остальных строк — последнюю
+// четверть, но не больше четырёх символов. Пробелы сохраняются.
+func MaskString(s string) string {
+	if local, domain, ok := strings.Cut(s, "@"); ok && local != "" {
+		r, _ := utf8.DecodeRuneInString(local)
+		return string(r) + "***@" + domain
+	}
+
+	runes := []rune(s)
+	visible := min(len(runes)/4, 4)
+	for i := range runes[:len(runes)-visible] {
+		if !unicode.IsSpace(runes[i]) {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
+
+func hash(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
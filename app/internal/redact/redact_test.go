@@ -0,0 +1,203 @@
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"test-task/internal/audit"
+	"test-task/internal/auth"
+	"test-task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte("test-key")
+
+func testOrder() *models.ExtendedOrder {
+	return &models.ExtendedOrder{
+		Order: models.Order{ID: 1, OrderUID: "b563feb7b2b84b6test", CustomerID: "test"},
+		Delivery: models.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Email: "test@gmail.com",
+		},
+		Items: []*models.Item{{Name: "Mascaras", Brand: "Vivienne Sabo"}},
+	}
+}
+
+func TestMaskString(t *testing.T) {
+	tests := map[string]string{
+		"+9720000000":     "*********00",
+		"test@gmail.com":  "t***@gmail.com",
+		"Ploshad Mira 15": "******* **** 15",
+		"Ann":             "***",
+		"":                "",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, MaskString(in), in)
+	}
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	tests := map[string]Rules{
+		"unknown action": {"$.delivery.phone": "blur"},
+		"unknown field":  {"$.delivery.fax": Drop},
+		"no root":        {"delivery.phone": Drop},
+		"index":          {"$.items[0].name": Drop},
+		"not a list":     {"$.delivery[*]": Drop},
+		"mask number":    {"$.payment.amount": Mask},
+		"hash struct":    {"$.delivery": Hash},
+	}
+	for name, rules := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPolicy(rules, testKey)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPolicy_Order(t *testing.T) {
+	p, err := NewPolicy(Rules{
+		"$.delivery.name":    Drop,
+		"$.delivery.phone":   Mask,
+		"$.delivery.email":   Hash,
+		"$.delivery.address": Keep,
+		"$.customer_id":      Hash,
+		"$.items[*].name":    Mask,
+	}, testKey)
+	require.NoError(t, err)
+
+	eo := testOrder()
+	got := p.Order(eo)
+
+	assert.Equal(t, "", got.Delivery.Name)
+	assert.Equal(t, "*********00", got.Delivery.Phone)
+	assert.Equal(t, hash(testKey, "test@gmail.com"), got.Delivery.Email)
+	assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, got.Delivery.Email)
+	assert.Equal(t, "Ploshad Mira 15", got.Delivery.Address)
+	assert.Equal(t, "Kiryat Mozkin", got.Delivery.City)
+	assert.Equal(t, hash(testKey, "test"), got.CustomerID)
+	assert.Equal(t, "b563feb7b2b84b6test", got.OrderUID)
+	assert.Equal(t, "******as", got.Items[0].Name)
+	assert.Equal(t, "Vivienne Sabo", got.Items[0].Brand)
+
+	// исходный заказ не меняется
+	assert.Equal(t, testOrder(), eo)
+
+	var empty *Policy
+	assert.Same(t, eo, empty.Order(eo))
+}
+
+func TestPolicy_Value(t *testing.T) {
+	p, err := NewPolicy(Rules{"$.delivery.name": Drop, "$.delivery.phone": Mask}, testKey)
+	require.NoError(t, err)
+
+	_, ok := p.Value("$.delivery.name", "Test Testov")
+	assert.False(t, ok)
+
+	v, ok := p.Value("$.delivery.phone", "+9720000000")
+	assert.True(t, ok)
+	assert.Equal(t, "*********00", v)
+
+	v, ok = p.Value("$.delivery.city", "Kiryat Mozkin")
+	assert.True(t, ok)
+	assert.Equal(t, "Kiryat Mozkin", v)
+}
+
+func TestPolicy_Changes(t *testing.T) {
+	p, err := NewPolicy(Rules{
+		"$.delivery.name":  Drop,
+		"$.delivery.phone": Mask,
+		"$.items[*].name":  Hash,
+	}, testKey)
+	require.NoError(t, err)
+
+	before := testOrder()
+	after := testOrder()
+	after.Delivery.Name = "Ivan Ivanov"
+	after.Delivery.Phone = "+9721111111"
+	after.Delivery.City = "Haifa"
+	after.Items[0].Name = "Lipstick"
+	after.Items = append(after.Items, &models.Item{Name: "Powder"})
+
+	raw, err := audit.Diff(before, after)
+	require.NoError(t, err)
+
+	var changes map[string]audit.Change
+	require.NoError(t, json.Unmarshal(p.Changes(raw), &changes))
+
+	assert.NotContains(t, changes, "$.delivery.name")
+	assert.Equal(t, audit.Change{Before: "*********00", After: "*********11"}, changes["$.delivery.phone"])
+	assert.Equal(t, audit.Change{Before: "Kiryat Mozkin", After: "Haifa"}, changes["$.delivery.city"])
+	assert.Equal(t, audit.Change{Before: hash(testKey, "Mascaras"), After: hash(testKey, "Lipstick")}, changes["$.items[0].name"])
+
+	added := changes["$.items[1]"].After.(map[string]any)
+	assert.Equal(t, hash(testKey, "Powder"), added["name"])
+
+	assert.Nil(t, p.Changes(json.RawMessage(`not json`)))
+}
+
+func TestPolicy_JSON(t *testing.T) {
+	p, err := NewPolicy(DefaultLogRules(), testKey)
+	require.NoError(t, err)
+
+	out, err := p.JSON([]byte(`{"order_uid": "b563feb7b2b84b6test",
+		"delivery": {"name": "Test Testov", "phone": 9720000000, "city": "Kiryat Mozkin", "email": "test@gmail.com"},
+		"payment": {"amount": 1817.10}}`))
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(out, &got))
+
+	delivery := got["delivery"].(map[string]any)
+	assert.Equal(t, hash(testKey, "Test Testov"), delivery["name"])
+	assert.Equal(t, hash(testKey, "test@gmail.com"), delivery["email"])
+	assert.NotContains(t, delivery, "phone")
+	assert.Equal(t, "Kiryat Mozkin", delivery["city"])
+	assert.JSONEq(t, `{"amount": 1817.10}`, mustMarshal(t, got["payment"]))
+	assert.NotContains(t, string(out), "Test Testov")
+
+	_, err = p.JSON([]byte(`{"delivery":`))
+	assert.Error(t, err)
+}
+
+func TestRedactor_For(t *testing.T) {
+	r, err := New(Config{
+		Roles:   map[auth.Role]Rules{auth.RoleSupport: {"$.delivery.phone": Mask}},
+		HashKey: testKey,
+	})
+	require.NoError(t, err)
+
+	ctx := func(role auth.Role) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Name: "test", Role: role})
+	}
+	eo := testOrder()
+
+	viewer := r.For(ctx(auth.RoleViewer)).Order(eo)
+	assert.Equal(t, models.Delivery{City: "Kiryat Mozkin"}, viewer.Delivery)
+
+	support := r.For(ctx(auth.RoleSupport)).Order(eo)
+	assert.Equal(t, "Test Testov", support.Delivery.Name)
+	assert.Equal(t, "*********00", support.Delivery.Phone)
+
+	assert.Same(t, eo, r.For(ctx(auth.RoleAdmin)).Order(eo))
+	assert.Equal(t, viewer, r.For(context.Background()).Order(eo))
+
+	logged := r.Log().Order(eo)
+	assert.Equal(t, hash(testKey, "+9720000000"), logged.Delivery.Phone)
+
+	// без настройки — правила по умолчанию
+	var zero *Redactor
+	assert.Equal(t, "", zero.For(context.Background()).Order(eo).Delivery.Name)
+
+	_, err = New(Config{Roles: map[auth.Role]Rules{"root": {}}})
+	assert.Error(t, err)
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
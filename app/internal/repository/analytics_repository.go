@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"test-task/internal/currency"
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrInvalidFilter = errors.New("invalid analytics filter")
+
+// Интервалы группировки выручки — аргументы date_trunc.
+var revenueIntervals = map[string]bool{"day": true, "week": true, "month": true}
+
+// Колонки, по которым можно разбить выручку.
+var revenueSplits = map[string]string{
+	"delivery_service": "f.delivery_service",
+	"provider":         "f.provider",
+	"bank":             "f.bank",
+}
+
+// rollupRateQuery — курс пересчёта строки агрегатов r из её валюты в валюту
+// отчёта на день оплаты r.payment_day. NULL, если для одной из валют нет курса.
+//
+// $1, $2 — период, $3 — базовая валюта fx_rates, $4 — валюта отчёта,
+// $5 — знаков после запятой в валюте отчёта.
+const rollupRateQuery = `
+	(
+		CASE WHEN r.currency = $3::TEXT THEN 1 ELSE (
+			SELECT fx.rate FROM fx_rates AS fx
+			WHERE fx.currency = r.currency AND fx.rate_date <= r.payment_day
+			ORDER BY fx.rate_date DESC
+			LIMIT 1
+		) END
+	) / (
+		CASE WHEN $4::TEXT = $3::TEXT THEN 1 ELSE (
+			SELECT fx.rate FROM fx_rates AS fx
+			WHERE fx.currency = $4::TEXT AND fx.rate_date <= r.payment_day
+			ORDER BY fx.rate_date DESC
+			LIMIT 1
+		) END
+	)
+`
+
+// Отчёты читают дневные агрегаты, а не сырые заказы.
+const (
+	analyticsOrdersQuery = `
+	WITH filtered AS (
+		SELECT r.*, ` + rollupRateQuery + ` AS rate
+		FROM orders_daily_rollup AS r
+		WHERE r.day >= $1::DATE AND r.day < $2::DATE
+	)
+	`
+
+	analyticsItemsQuery = `
+	WITH filtered AS (
+		SELECT r.*, ` + rollupRateQuery + ` AS rate
+		FROM items_daily_rollup AS r
+		WHERE r.day >= $1::DATE AND r.day < $2::DATE
+	)
+	`
+)
+
+type AnalyticsRepository interface {
+	// Revenue возвращает число заказов и выручку по периодам interval: day, week или month.
+	Revenue(ctx context.Context, f models.AnalyticsFilter, interval string) ([]*models.RevenuePoint, error)
+	// TopBrands возвращает бренды с наибольшим числом проданных позиций.
+	TopBrands(ctx context.Context, f models.AnalyticsFilter, limit int) ([]*models.SalesRank, error)
+	// TopProducts возвращает артикулы nm_id с наибольшим числом проданных позиций.
+	TopProducts(ctx context.Context, f models.AnalyticsFilter, limit int) ([]*models.SalesRank, error)
+	Basket(ctx context.Context, f models.AnalyticsFilter) (*models.BasketStats, error)
+	// RevenueSplit разбивает выручку по delivery_service, provider или bank.
+	RevenueSplit(ctx context.Context, f models.AnalyticsFilter, by string) ([]*models.RevenueShare, error)
+	// SaleDistribution считает позиции по корзинам скидки шириной 10%.
+	SaleDistribution(ctx context.Context, f models.AnalyticsFilter) ([]*models.SaleBucket, error)
+}
+
+type analyticsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAnalyticsRepository(db *pgxpool.Pool) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+func analyticsArgs(f models.AnalyticsFilter, extra ...any) ([]any, error) {
+	if f.From.IsZero() || f.To.IsZero() || !f.From.Before(f.To) {
+		return nil, ErrInvalidFilter
+	}
+
+	cur, ok := currency.Lookup(f.Currency)
+	if !ok {
+		return nil, ErrInvalidFilter
+	}
+
+	return append([]any{f.From, f.To, f.Base, f.Currency, cur.MinorUnits}, extra...), nil
+}
+
+func (r *analyticsRepository) Revenue(ctx context.Context, f models.AnalyticsFilter, interval string) ([]*models.RevenuePoint, error) {
+	if !revenueIntervals[interval] {
+		return nil, ErrInvalidFilter
+	}
+
+	args, err := analyticsArgs(f, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	query := analyticsOrdersQuery + `
+		SELECT
+			date_trunc($6, f.day) AS period,
+			sum(f.orders)::BIGINT,
+			COALESCE(ROUND(sum(f.revenue * f.rate), $5), 0),
+			COALESCE(sum(f.orders) FILTER (WHERE f.rate IS NULL), 0)::BIGINT
+		FROM filtered AS f
+		GROUP BY period
+		HAVING sum(f.orders) <> 0
+		ORDER BY period;
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.RevenuePoint, error) {
+		p := new(models.RevenuePoint)
+		err := row.Scan(&p.Period, &p.Orders, &p.Revenue, &p.Unconverted)
+		return p, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return points, nil
+}
+
+func (r *analyticsRepository) TopBrands(ctx context.Context, f models.AnalyticsFilter, limit int) ([]*models.SalesRank, error) {
+	return r.topItems(ctx, f, "f.brand", limit)
+}
+
+func (r *analyticsRepository) TopProducts(ctx context.Context, f models.AnalyticsFilter, limit int) ([]*models.SalesRank, error) {
+	return r.topItems(ctx, f, "f.nm_id::TEXT", limit)
+}
+
+func (r *analyticsRepository) topItems(ctx context.Context, f models.AnalyticsFilter, key string, limit int) ([]*models.SalesRank, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	args, err := analyticsArgs(f, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	query := analyticsItemsQuery + `
+		SELECT
+			` + key + ` AS key,
+			sum(f.quantity)::BIGINT AS quantity,
+			COALESCE(ROUND(sum(f.revenue * f.rate), $5), 0)
+		FROM filtered AS f
+		GROUP BY key
+		HAVING sum(f.quantity) > 0
+		ORDER BY quantity DESC, key
+		LIMIT $6;
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	ranks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SalesRank, error) {
+		rank := new(models.SalesRank)
+		err := row.Scan(&rank.Key, &rank.Quantity, &rank.Revenue)
+		return rank, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return ranks, nil
+}
+
+func (r *analyticsRepository) Basket(ctx context.Context, f models.AnalyticsFilter) (*models.BasketStats, error) {
+	args, err := analyticsArgs(f)
+	if err != nil {
+		return nil, err
+	}
+
+	query := analyticsOrdersQuery + `
+		SELECT
+			COALESCE(sum(f.orders), 0)::BIGINT,
+			COALESCE(sum(f.items), 0)::BIGINT,
+			COALESCE(sum(f.items)::FLOAT8 / NULLIF(sum(f.orders), 0), 0),
+			COALESCE(ROUND(
+				sum(f.revenue * f.rate) / NULLIF(sum(f.orders) FILTER (WHERE f.rate IS NOT NULL), 0), $5
+			), 0),
+			COALESCE(sum(f.orders) FILTER (WHERE f.rate IS NULL), 0)::BIGINT
+		FROM filtered AS f;
+	`
+
+	stats := new(models.BasketStats)
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&stats.Orders,
+		&stats.Items,
+		&stats.AvgItems,
+		&stats.AvgAmount,
+		&stats.Unconverted,
+	)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return stats, nil
+}
+
+func (r *analyticsRepository) RevenueSplit(ctx context.Context, f models.AnalyticsFilter, by string) ([]*models.RevenueShare, error) {
+	column, ok := revenueSplits[by]
+	if !ok {
+		return nil, ErrInvalidFilter
+	}
+
+	args, err := analyticsArgs(f)
+	if err != nil {
+		return nil, err
+	}
+
+	query := analyticsOrdersQuery + `
+		SELECT
+			` + column + ` AS key,
+			sum(f.orders)::BIGINT,
+			COALESCE(ROUND(sum(f.revenue * f.rate), $5), 0) AS revenue,
+			COALESCE(sum(f.orders) FILTER (WHERE f.rate IS NULL), 0)::BIGINT
+		FROM filtered AS f
+		GROUP BY key
+		HAVING sum(f.orders) <> 0
+		ORDER BY revenue DESC, key;
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	shares, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.RevenueShare, error) {
+		share := new(models.RevenueShare)
+		err := row.Scan(&share.Key, &share.Orders, &share.Revenue, &share.Unconverted)
+		return share, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return shares, nil
+}
+
+func (r *analyticsRepository) SaleDistribution(ctx context.Context, f models.AnalyticsFilter) ([]*models.SaleBucket, error) {
+	args, err := analyticsArgs(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// курс здесь не нужен, знаки валюты отчёта ($5) не передаются
+	args = args[:4]
+
+	query := analyticsItemsQuery + `
+		SELECT
+			f.sale_bucket,
+			sum(f.quantity)::BIGINT AS items
+		FROM filtered AS f
+		GROUP BY f.sale_bucket
+		HAVING sum(f.quantity) > 0
+		ORDER BY f.sale_bucket;
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	buckets, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SaleBucket, error) {
+		b := new(models.SaleBucket)
+		err := row.Scan(&b.From, &b.Items)
+		b.To = b.From + 9
+		return b, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,186 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func analyticsOrder(uid, currency, bank string, date time.Time, items ...*models.Item) *models.ExtendedOrder {
+	var goods money.Amount
+	for _, item := range items {
+		goods = goods.Add(item.TotalPrice)
+	}
+
+	return &models.ExtendedOrder{
+		Order: models.Order{
+			OrderUID:        uid,
+			TrackNumber:     "analytics",
+			Entry:           "WBIL",
+			Locale:          "ru",
+			CustomerID:      "analytics",
+			DeliveryService: "meest",
+			ShardKey:        "1",
+			SMID:            1,
+			DateCreated:     date,
+			OOFShard:        "1",
+		},
+		Payment: models.Payment{
+			Transaction:  uid,
+			Currency:     currency,
+			Provider:     "wbpay",
+			Amount:       goods.Add(money.FromInt(100)),
+			PaymentDate:  date.Unix(),
+			Bank:         bank,
+			DeliveryCost: money.FromInt(100),
+			GoodsTotal:   goods,
+		},
+		Delivery: models.Delivery{
+			Name:    "analytics",
+			Phone:   "+7926",
+			Zip:     "1542",
+			City:    "Moscow",
+			Address: "Lenina",
+			Region:  "Moscow",
+			Email:   "test@emal.com",
+		},
+		Items: items,
+	}
+}
+
+func analyticsItem(brand string, nmID, sale int, total int64) *models.Item {
+	return &models.Item{
+		ChrtID:      1,
+		TrackNumber: "analytics",
+		Price:       money.FromInt(total),
+		RID:         "analytics",
+		Name:        "analytics",
+		Sale:        sale,
+		Size:        "0",
+		TotalPrice:  money.FromInt(total),
+		NMID:        nmID,
+		Brand:       brand,
+		Status:      1,
+	}
+}
+
+func TestAnalyticsRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewAnalyticsRepository(db)
+
+	day := func(d int) time.Time { return time.Date(2003, time.May, d, 12, 0, 0, 0, time.UTC) }
+
+	orders := []*models.ExtendedOrder{
+		analyticsOrder("analytics 1", "RUB", "alpha", day(10),
+			analyticsItem("A", 1, 0, 300), analyticsItem("B", 2, 25, 600)),
+		analyticsOrder("analytics 2", "RUB", "tbank", day(20),
+			analyticsItem("A", 1, 5, 300)),
+		analyticsOrder("analytics 3", "USD", "alpha", day(20),
+			analyticsItem("A", 3, 0, 10)),
+	}
+	for _, eo := range orders {
+		require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+	}
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics %'`)
+		require.NoError(t, err)
+		require.NoError(t, repository.NewRollupRepository(db).Rebuild(t.Context(), day(1), day(31)))
+	})
+
+	require.NoError(t, repository.NewFXRepository(db).UpsertRates(t.Context(), []*models.FXRate{
+		{Currency: "USD", Date: day(1), Rate: mustRate(t, "30")},
+	}))
+
+	filter := models.AnalyticsFilter{
+		From:     day(1),
+		To:       day(31),
+		Currency: "RUB",
+		Base:     "RUB",
+	}
+
+	t.Run("Revenue", func(t *testing.T) {
+		points, err := repo.Revenue(t.Context(), filter, "month")
+		require.NoError(t, err)
+		require.Len(t, points, 1)
+
+		// 1000 + 400 + 110 USD * 30
+		assert.Equal(t, int64(3), points[0].Orders)
+		assert.Equal(t, money.FromInt(4700), points[0].Revenue)
+		assert.Zero(t, points[0].Unconverted)
+
+		_, err = repo.Revenue(t.Context(), filter, "year")
+		assert.ErrorIs(t, err, repository.ErrInvalidFilter)
+	})
+
+	t.Run("Revenue without rate", func(t *testing.T) {
+		f := filter
+		f.Currency = "EUR"
+
+		points, err := repo.Revenue(t.Context(), f, "month")
+		require.NoError(t, err)
+		require.Len(t, points, 1)
+		assert.Equal(t, int64(3), points[0].Unconverted)
+		assert.True(t, points[0].Revenue.IsZero())
+	})
+
+	t.Run("Top brands", func(t *testing.T) {
+		ranks, err := repo.TopBrands(t.Context(), filter, 10)
+		require.NoError(t, err)
+		require.Len(t, ranks, 2)
+		assert.Equal(t, "A", ranks[0].Key)
+		assert.Equal(t, int64(3), ranks[0].Quantity)
+		assert.Equal(t, money.FromInt(900), ranks[0].Revenue)
+	})
+
+	t.Run("Top products", func(t *testing.T) {
+		ranks, err := repo.TopProducts(t.Context(), filter, 1)
+		require.NoError(t, err)
+		require.Len(t, ranks, 1)
+		assert.Equal(t, "1", ranks[0].Key)
+		assert.Equal(t, int64(2), ranks[0].Quantity)
+	})
+
+	t.Run("Basket", func(t *testing.T) {
+		stats, err := repo.Basket(t.Context(), filter)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stats.Orders)
+		assert.Equal(t, int64(4), stats.Items)
+		assert.InDelta(t, 4.0/3.0, stats.AvgItems, 0.0001)
+		assert.Equal(t, money.MustParse("1566.67"), stats.AvgAmount)
+	})
+
+	t.Run("Revenue split", func(t *testing.T) {
+		shares, err := repo.RevenueSplit(t.Context(), filter, "bank")
+		require.NoError(t, err)
+		require.Len(t, shares, 2)
+		assert.Equal(t, "alpha", shares[0].Key)
+		assert.Equal(t, money.FromInt(4300), shares[0].Revenue)
+
+		_, err = repo.RevenueSplit(t.Context(), filter, "order_uid")
+		assert.ErrorIs(t, err, repository.ErrInvalidFilter)
+	})
+
+	t.Run("Sale distribution", func(t *testing.T) {
+		buckets, err := repo.SaleDistribution(t.Context(), filter)
+		require.NoError(t, err)
+		assert.Equal(t, []*models.SaleBucket{
+			{From: 0, To: 9, Items: 3},
+			{From: 20, To: 29, Items: 1},
+		}, buckets)
+	})
+}
+
+func mustRate(t *testing.T, s string) money.Rate {
+	rate, err := money.ParseRate(s)
+	require.NoError(t, err)
+	return rate
+}
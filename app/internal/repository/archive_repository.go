@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ArchiveCursor — позиция, с которой продолжается выборка заказов для архива.
+type ArchiveCursor struct {
+	DateCreated time.Time
+	ID          int64
+}
+
+type ArchiveRepository interface {
+	// GetExtendedOrdersBefore возвращает заказы, созданные раньше before,
+	// упорядоченные по (date_created, id) и идущие после курсора.
+	GetExtendedOrdersBefore(ctx context.Context, before time.Time, after ArchiveCursor, limit int) ([]*models.ExtendedOrder, error)
+	DeleteExtendedOrders(ctx context.Context, ids []int64) error
+	// RestoreExtendedOrder вставляет заказ с исходными id.
+	// Возвращает false, если заказ с таким id или order_uid уже есть.
+	RestoreExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) (bool, error)
+	// SyncSequences сдвигает последовательности id за максимальные
+	// восстановленные значения.
+	SyncSequences(ctx context.Context) error
+	// TryLock берёт сессионную advisory-блокировку, общую с purge.
+	TryLock(ctx context.Context) (release func(), err error)
+}
+
+type archiveRepository struct {
+	db   *pgxpool.Pool
+	keys *keyring.Keyring
+}
+
+func NewArchiveRepository(db *pgxpool.Pool, keys *keyring.Keyring) ArchiveRepository {
+	return &archiveRepository{db: db, keys: keys}
+}
+
+func (r *archiveRepository) GetExtendedOrdersBefore(
+	ctx context.Context,
+	before time.Time,
+	after ArchiveCursor,
+	limit int,
+) ([]*models.ExtendedOrder, error) {
+	if limit <= 0 {
+		return nil, ErrNilValue
+	}
+
+	query := selectExtendedOrderWithoutItemsQuery + `
+		AND o.date_created < $1
+		AND (o.date_created, o.id) > ($2, $3)
+		ORDER BY o.date_created, o.id
+		LIMIT $4;
+	`
+
+	rows, err := r.db.Query(ctx, query, before, after.DateCreated, after.ID, limit)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	eos := make([]*models.ExtendedOrder, 0, limit)
+	for rows.Next() {
+		eo, err := scanExtendedOrder(rows, r.keys)
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+		eos = append(eos, eo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+	rows.Close()
+
+	if err := loadItems(ctx, r.db, eos); err != nil {
+		return nil, err
+	}
+
+	return eos, nil
+}
+
+func (r *archiveRepository) DeleteExtendedOrders(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = hardDeleteOrders(ctx, tx, ids, models.AuditActionArchive)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+func (r *archiveRepository) RestoreExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) (bool, error) {
+	if eo == nil {
+		return false, ErrNilValue
+	}
+	if eo.Order.ID <= 0 {
+		return false, ErrInvalidID
+	}
+
+	// доставка из архива уже расшифрована, в базу она снова пишется зашифрованной
+	delivery, err := sealDelivery(r.keys, &eo.Delivery)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = lockOrderUID(ctx, tx, eo.Order.OrderUID)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM order_keys WHERE id = $1 OR order_uid = $2);`,
+		eo.Order.ID, eo.Order.OrderUID,
+	).Scan(&exists)
+	if err != nil {
+		return false, wrapDBError(err)
+	}
+	if exists {
+		return false, tx.Rollback(ctx)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(restoreDeliveryQuery, delivery.updateArgs()...)
+	batch.Queue(restorePaymentQuery,
+		eo.Payment.ID,
+		eo.Payment.Transaction,
+		eo.Payment.RequestID,
+		eo.Payment.Currency,
+		eo.Payment.Provider,
+		eo.Payment.Amount,
+		eo.Payment.PaymentDate,
+		eo.Payment.Bank,
+		eo.Payment.DeliveryCost,
+		eo.Payment.GoodsTotal,
+		eo.Payment.CustomFee,
+	)
+	batch.Queue(restoreOrderQuery,
+		eo.Order.ID,
+		eo.Order.OrderUID,
+		eo.Order.TrackNumber,
+		eo.Order.Entry,
+		eo.Delivery.ID,
+		eo.Payment.ID,
+		eo.Order.Locale,
+		eo.Order.InternalSignature,
+		eo.Order.CustomerID,
+		eo.Order.DeliveryService,
+		eo.Order.ShardKey,
+		eo.Order.SMID,
+		eo.Order.DateCreated,
+		eo.Order.OOFShard,
+	)
+	for _, item := range eo.Items {
+		batch.Queue(restoreItemQuery,
+			item.ID,
+			eo.Order.ID,
+			item.ChrtID,
+			item.TrackNumber,
+			item.Price,
+			item.RID,
+			item.Name,
+			item.Sale,
+			item.Size,
+			item.TotalPrice,
+			item.NMID,
+			item.Brand,
+			item.Status,
+		)
+	}
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return false, wrapDBError(err)
+	}
+
+	err = applyRollups(ctx, tx, []int64{eo.Order.ID}, 1)
+	if err != nil {
+		return false, err
+	}
+
+	var diff []byte
+	diff, err = audit.Diff(nil, auditOrder(r.keys, eo))
+	if err != nil {
+		return false, err
+	}
+
+	err = NewAuditRepository(r.db).Create(ctx, tx, &models.AuditEntry{
+		OrderID: eo.Order.ID,
+		Action:  models.AuditActionRestore,
+		Actor:   audit.ActorFromContext(ctx),
+		Diff:    diff,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, wrapDBError(err)
+	}
+
+	return true, nil
+}
+
+func (r *archiveRepository) SyncSequences(ctx context.Context) error {
+	batch := &pgx.Batch{}
+	for _, table := range []string{"delivery", "payment", "orders", "items"} {
+		batch.Queue(`
+			SELECT setval(
+				pg_get_serial_sequence($1, 'id'),
+				GREATEST(
+					nextval(pg_get_serial_sequence($1, 'id')),
+					(SELECT COALESCE(max(id), 0) FROM `+table+`)
+				)
+			);
+		`, table)
+	}
+
+	return wrapDBError(r.db.SendBatch(ctx, batch).Close())
+}
+
+func (r *archiveRepository) TryLock(ctx context.Context) (func(), error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	var locked bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1));`, purgeLockName).Scan(&locked)
+	if err != nil {
+		conn.Release()
+		return nil, wrapDBError(err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, ErrLockNotAcquired
+	}
+
+	release := func() {
+		conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1));`, purgeLockName)
+		conn.Release()
+	}
+
+	return release, nil
+}
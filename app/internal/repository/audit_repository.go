@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, entry *models.AuditEntry) error
+	GetByOrderID(ctx context.Context, tx pgx.Tx, orderID int64) ([]*models.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, tx pgx.Tx, entry *models.AuditEntry) error {
+	if entry == nil {
+		return ErrNilValue
+	}
+	if entry.OrderID <= 0 {
+		return ErrInvalidID
+	}
+
+	var exec pgx.Row
+	if tx != nil {
+		exec = tx.QueryRow(ctx, insertAuditQuery,
+			entry.OrderID,
+			entry.Action,
+			entry.Actor,
+			entry.Diff,
+		)
+	} else {
+		exec = r.db.QueryRow(ctx, insertAuditQuery,
+			entry.OrderID,
+			entry.Action,
+			entry.Actor,
+			entry.Diff,
+		)
+	}
+
+	err := exec.Scan(&entry.ID, &entry.CreatedAt)
+
+	return wrapDBError(err)
+}
+
+func (r *auditRepository) GetByOrderID(ctx context.Context, tx pgx.Tx, orderID int64) ([]*models.AuditEntry, error) {
+	if orderID <= 0 {
+		return nil, ErrInvalidID
+	}
+
+	query := `
+		SELECT id, order_id, action, actor, diff, created_at
+		FROM order_audit
+		WHERE order_id = $1
+		ORDER BY created_at, id;
+	`
+
+	var rows pgx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, orderID)
+	} else {
+		rows, err = r.db.Query(ctx, query, orderID)
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	entries := make([]*models.AuditEntry, 0)
+	for rows.Next() {
+		e := new(models.AuditEntry)
+		if err := rows.Scan(
+			&e.ID, &e.OrderID, &e.Action,
+			&e.Actor, &e.Diff, &e.CreatedAt,
+		); err != nil {
+			return nil, wrapDBError(err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,267 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendedOrderRepository_Audit(t *testing.T) {
+	repo := repository.NewExtendedOrderRepository(db, nil)
+
+	eo := &models.ExtendedOrder{
+		Order: models.Order{
+			OrderUID:        "audit test",
+			TrackNumber:     "2634",
+			Entry:           "142",
+			Locale:          "ru",
+			CustomerID:      "test",
+			DeliveryService: "test",
+			ShardKey:        "test",
+			SMID:            2,
+			DateCreated:     time.Date(2025, time.September, 5, 3, 0, 0, 0, time.Local).UTC(),
+			OOFShard:        "test",
+		},
+		Payment: models.Payment{
+			Transaction:  "test",
+			Currency:     "RUB",
+			Provider:     "alfa",
+			Amount:       money.FromInt(1000),
+			PaymentDate:  90872534,
+			Bank:         "tbank",
+			DeliveryCost: money.FromInt(325),
+			GoodsTotal:   money.FromInt(32),
+		},
+		Delivery: models.Delivery{
+			Name:    "test",
+			Phone:   "+7926",
+			Zip:     "1542",
+			City:    "Moscow",
+			Address: "Lenina",
+			Region:  "Moscow",
+			Email:   "test@emal.com",
+		},
+		Items: []*models.Item{
+			{
+				ChrtID:      324,
+				TrackNumber: "test",
+				Price:       money.FromInt(200),
+				RID:         "test",
+				Name:        "test",
+				Sale:        20,
+				Size:        "test",
+				TotalPrice:  money.FromInt(200),
+				NMID:        12,
+				Brand:       "test",
+				Status:      1,
+			},
+		},
+	}
+
+	ctx := audit.WithActor(t.Context(), audit.KafkaActor("orders", 1, 42))
+
+	t.Run("Create", func(t *testing.T) {
+		err := repo.CreateExtendedOrder(ctx, eo)
+		require.NoError(t, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		eo.Order.DeliveryService = "new test"
+		err := repo.UpdateExtendedOrder(ctx, eo)
+		assert.NoError(t, err)
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		err := repo.UpdateOrderStatus(ctx, eo.Order.ID, 202)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		err := repo.DeleteExtendedOrder(ctx, eo.Order.ID)
+		assert.NoError(t, err)
+		_, err = repo.GetExtendedOrder(t.Context(), eo.Order.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		entries, err := repo.Audit().GetByOrderID(t.Context(), nil, eo.Order.ID)
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+
+		actions := make([]string, 0, len(entries))
+		for _, e := range entries {
+			actions = append(actions, e.Action)
+			assert.Equal(t, audit.KafkaActor("orders", 1, 42), e.Actor)
+		}
+		assert.Equal(t, []string{
+			models.AuditActionCreate,
+			models.AuditActionUpdate,
+			models.AuditActionStatusChange,
+			models.AuditActionDelete,
+		}, actions)
+
+		assert.JSONEq(t,
+			`{"$.delivery_service": {"before": "test", "after": "new test"}}`,
+			string(entries[1].Diff),
+		)
+	})
+}
+
+func TestExtendedOrderRepository_AuditEncrypted(t *testing.T) {
+	repo := repository.NewExtendedOrderRepository(db, testKeyring(t, "k1"))
+
+	eo := &models.ExtendedOrder{
+		Order: models.Order{
+			OrderUID:        "audit encrypted test",
+			TrackNumber:     "2635",
+			Entry:           "142",
+			Locale:          "ru",
+			CustomerID:      "test",
+			DeliveryService: "test",
+			ShardKey:        "test",
+			SMID:            2,
+			DateCreated:     time.Date(2025, time.September, 5, 3, 0, 0, 0, time.Local).UTC(),
+			OOFShard:        "test",
+		},
+		Payment: models.Payment{
+			Transaction: "test",
+			Currency:    "RUB",
+			Provider:    "alfa",
+			Amount:      money.FromInt(1000),
+			PaymentDate: 90872534,
+			Bank:        "tbank",
+		},
+		Delivery: models.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+	}
+
+	require.NoError(t, repo.CreateExtendedOrder(t.Context(), eo))
+	defer repo.DeleteExtendedOrder(t.Context(), eo.Order.ID)
+
+	eo.Delivery.Name = "Ivan Ivanov"
+	require.NoError(t, repo.UpdateExtendedOrder(t.Context(), eo))
+
+	entries, err := repo.Audit().GetByOrderID(t.Context(), nil, eo.Order.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	for _, e := range entries {
+		for _, pii := range []string{"Test Testov", "Ivan Ivanov", "+9720000000", "Ploshad Mira 15", "test@gmail.com"} {
+			assert.NotContains(t, string(e.Diff), pii)
+		}
+	}
+
+	var changes map[string]audit.Change
+	require.NoError(t, json.Unmarshal(entries[1].Diff, &changes))
+	name := changes["$.delivery.name"]
+	assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, name.Before)
+	assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, name.After)
+	assert.NotEqual(t, name.Before, name.After)
+}
+
+func TestExtendedOrderRepository_AuditReingest(t *testing.T) {
+	repo := repository.NewExtendedOrderRepository(db, nil)
+
+	newOrder := func(city string, chrtID int) *models.ExtendedOrder {
+		return &models.ExtendedOrder{
+			Order: models.Order{
+				OrderUID:        "audit reingest test",
+				TrackNumber:     "2636",
+				Entry:           "142",
+				Locale:          "ru",
+				CustomerID:      "test",
+				DeliveryService: "test",
+				ShardKey:        "test",
+				SMID:            2,
+				DateCreated:     time.Date(2025, time.September, 5, 3, 0, 0, 0, time.Local).UTC(),
+				OOFShard:        "test",
+			},
+			Payment: models.Payment{
+				Transaction:  "test",
+				Currency:     "RUB",
+				Provider:     "alfa",
+				Amount:       money.FromInt(525),
+				PaymentDate:  90872534,
+				Bank:         "tbank",
+				DeliveryCost: money.FromInt(325),
+				GoodsTotal:   money.FromInt(200),
+			},
+			Delivery: models.Delivery{
+				Name:    "test",
+				Phone:   "+7926",
+				Zip:     "1542",
+				City:    city,
+				Address: "Lenina",
+				Region:  "Moscow",
+				Email:   "test@emal.com",
+			},
+			Items: []*models.Item{
+				{
+					ChrtID:      chrtID,
+					TrackNumber: "test",
+					Price:       money.FromInt(200),
+					RID:         "test",
+					Name:        "test",
+					Sale:        20,
+					Size:        "test",
+					TotalPrice:  money.FromInt(200),
+					NMID:        12,
+					Brand:       "test",
+					Status:      1,
+				},
+			},
+		}
+	}
+
+	ctx := audit.WithActor(t.Context(), audit.KafkaActor("orders", 1, 43))
+
+	first := newOrder("Moscow", 1)
+	require.NoError(t, repo.CreateExtendedOrder(ctx, first))
+	t.Cleanup(func() {
+		_ = repo.DeleteExtendedOrder(t.Context(), first.Order.ID)
+	})
+
+	second := newOrder("Tver", 2)
+	require.NoError(t, repo.CreateExtendedOrder(ctx, second))
+
+	// доставка и оплата заказа переиспользуются, позиции добавляются
+	assert.Equal(t, first.Order.ID, second.Order.ID)
+	assert.Equal(t, first.Delivery.ID, second.Delivery.ID)
+	assert.Equal(t, first.Payment.ID, second.Payment.ID)
+	assert.Len(t, second.Items, 2)
+
+	got, err := repo.GetExtendedOrder(t.Context(), first.Order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Tver", got.Delivery.City)
+	assert.Equal(t, first.Delivery.ID, got.Delivery.ID)
+	assert.Len(t, got.Items, 2)
+
+	entries, err := repo.Audit().GetByOrderID(t.Context(), nil, first.Order.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditActionCreate, entries[0].Action)
+	assert.Equal(t, models.AuditActionUpdate, entries[1].Action)
+
+	var diff map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(entries[1].Diff, &diff))
+	assert.JSONEq(t, `{"before": "Moscow", "after": "Tver"}`, string(diff["$.delivery.city"]))
+	assert.NotContains(t, diff, "$")
+}
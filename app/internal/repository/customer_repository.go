@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CustomerRepository interface {
+	// ListOrders возвращает страницу неудалённых заказов покупателя с позициями,
+	// новые первыми, и общее число его заказов.
+	ListOrders(ctx context.Context, customerID string, limit, offset int) (*models.CustomerOrders, error)
+	// Summary возвращает сводку по заказам покупателя
+	// или ErrNotFound, если заказов нет.
+	Summary(ctx context.Context, customerID string) (*models.CustomerSummary, error)
+}
+
+type customerRepository struct {
+	db   *pgxpool.Pool
+	keys *keyring.Keyring
+}
+
+func NewCustomerRepository(db *pgxpool.Pool, keys *keyring.Keyring) CustomerRepository {
+	return &customerRepository{db: db, keys: keys}
+}
+
+func (r *customerRepository) ListOrders(ctx context.Context, customerID string, limit, offset int) (*models.CustomerOrders, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	page := &models.CustomerOrders{
+		Orders: make([]*models.ExtendedOrder, 0, limit),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT count(*) FROM orders
+		WHERE customer_id = $1 AND deleted_at IS NULL;
+	`, customerID)
+	batch.Queue(selectExtendedOrderWithoutItemsQuery+`
+		AND o.customer_id = $1
+		ORDER BY o.date_created DESC, o.id DESC
+		LIMIT $2 OFFSET $3;
+	`, customerID, limit, offset)
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	if err := br.QueryRow().Scan(&page.Total); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	for rows.Next() {
+		eo, err := scanExtendedOrder(rows, r.keys)
+		if err != nil {
+			rows.Close()
+			return nil, wrapDBError(err)
+		}
+		page.Orders = append(page.Orders, eo)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	if err := br.Close(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	if err := loadItems(ctx, r.db, page.Orders); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (r *customerRepository) Summary(ctx context.Context, customerID string) (*models.CustomerSummary, error) {
+	summary := &models.CustomerSummary{
+		CustomerID: customerID,
+		Spend:      make([]*models.CustomerSpend, 0),
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT count(*), min(date_created), max(date_created)
+		FROM orders
+		WHERE customer_id = $1 AND deleted_at IS NULL
+		HAVING count(*) > 0;
+	`, customerID)
+	batch.Queue(`
+		SELECT delivery_service
+		FROM orders
+		WHERE customer_id = $1 AND deleted_at IS NULL
+		GROUP BY delivery_service
+		ORDER BY count(*) DESC, max(date_created) DESC
+		LIMIT 1;
+	`, customerID)
+	batch.Queue(`
+		SELECT p.currency, sum(p.amount)
+		FROM orders AS o
+		INNER JOIN payment AS p ON p.id = o.payment_id
+		WHERE o.customer_id = $1 AND o.deleted_at IS NULL
+		GROUP BY p.currency
+		ORDER BY p.currency;
+	`, customerID)
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	err := br.QueryRow().Scan(&summary.Orders, &summary.FirstOrder, &summary.LastOrder)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	if err := br.QueryRow().Scan(&summary.PreferredDeliveryService); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		spend := new(models.CustomerSpend)
+		if err := rows.Scan(&spend.Currency, &spend.Amount); err != nil {
+			return nil, wrapDBError(err)
+		}
+		summary.Spend = append(summary.Spend, spend)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return summary, nil
+}
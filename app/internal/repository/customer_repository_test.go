@@ -0,0 +1,75 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewCustomerRepository(db, nil)
+
+	day := func(d int) time.Time { return time.Date(2003, time.July, d, 12, 0, 0, 0, time.UTC) }
+
+	orders := []*models.ExtendedOrder{
+		analyticsOrder("analytics customer 1", "RUB", "alpha", day(1), analyticsItem("A", 1, 0, 300)),
+		analyticsOrder("analytics customer 2", "RUB", "alpha", day(2), analyticsItem("A", 1, 0, 500)),
+		analyticsOrder("analytics customer 3", "USD", "alpha", day(3), analyticsItem("A", 1, 0, 10)),
+	}
+	orders[2].Order.DeliveryService = "dhl"
+	for _, eo := range orders {
+		eo.Order.CustomerID = "customer test"
+		require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+	}
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics customer%'`)
+		require.NoError(t, err)
+		require.NoError(t, repository.NewRollupRepository(db).Rebuild(t.Context(), day(1), day(31)))
+	})
+
+	t.Run("List orders", func(t *testing.T) {
+		page, err := repo.ListOrders(t.Context(), "customer test", 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), page.Total)
+		require.Len(t, page.Orders, 2)
+		assert.Equal(t, orders[2].Order.ID, page.Orders[0].Order.ID)
+		assert.Equal(t, orders[1].Order.ID, page.Orders[1].Order.ID)
+		assert.Len(t, page.Orders[0].Items, 1)
+
+		page, err = repo.ListOrders(t.Context(), "customer test", 2, 2)
+		require.NoError(t, err)
+		require.Len(t, page.Orders, 1)
+		assert.Equal(t, orders[0].Order.ID, page.Orders[0].Order.ID)
+
+		page, err = repo.ListOrders(t.Context(), "nobody", 2, 0)
+		require.NoError(t, err)
+		assert.Zero(t, page.Total)
+		assert.Empty(t, page.Orders)
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		summary, err := repo.Summary(t.Context(), "customer test")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), summary.Orders)
+		assert.True(t, day(1).Equal(summary.FirstOrder))
+		assert.True(t, day(3).Equal(summary.LastOrder))
+		assert.Equal(t, "meest", summary.PreferredDeliveryService)
+		assert.Equal(t, []*models.CustomerSpend{
+			{Currency: "RUB", Amount: money.FromInt(1000)},
+			{Currency: "USD", Amount: money.FromInt(110)},
+		}, summary.Spend)
+
+		_, err = repo.Summary(t.Context(), "nobody")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+)
+
+// Поля доставки с персональными данными хранятся зашифрованными:
+// enc_key_id — ключ keyring, которым обёрнут ключ строки enc_dek.
+// Строки с пустым enc_key_id записаны до включения шифрования и хранятся
+// открыто, пока их не перешифрует Reencrypt.
+
+// sealedDelivery — доставка в том виде, в каком она пишется в базу.
+type sealedDelivery struct {
+	models.Delivery
+	keyID *string
+	dek   []byte
+	// слепые индексы для поиска точных совпадений
+	nameIdx, phoneIdx, emailIdx []byte
+}
+
+// sealDelivery шифрует персональные данные копии d. Без keyring
+// доставка пишется открыто.
+func sealDelivery(keys *keyring.Keyring, d *models.Delivery) (*sealedDelivery, error) {
+	s := &sealedDelivery{Delivery: *d}
+	if keys == nil {
+		return s, nil
+	}
+
+	s.nameIdx = blindIndex(keys, "name", d.Name)
+	s.phoneIdx = blindIndex(keys, "phone", d.Phone)
+	s.emailIdx = blindIndex(keys, "email", d.Email)
+
+	env, err := keys.Seal(deliveryFields(&s.Delivery)...)
+	if err != nil {
+		return nil, err
+	}
+	s.keyID, s.dek = &env.KeyID, env.DEK
+	return s, nil
+}
+
+// openDelivery расшифровывает на месте доставку, прочитанную из базы
+// вместе с enc_key_id и enc_dek.
+func openDelivery(keys *keyring.Keyring, d *models.Delivery, keyID *string, dek []byte) error {
+	if keyID == nil {
+		return nil
+	}
+	if keys == nil {
+		return ErrNoKeyring
+	}
+	return keys.Open(keyring.Envelope{KeyID: *keyID, DEK: dek}, deliveryFields(d)...)
+}
+
+func deliveryFields(d *models.Delivery) []keyring.Field {
+	return []keyring.Field{
+		{Name: "name", Value: &d.Name},
+		{Name: "phone", Value: &d.Phone},
+		{Name: "address", Value: &d.Address},
+		{Name: "email", Value: &d.Email},
+	}
+}
+
+// blindIndex возвращает слепой индекс нормализованного значения поля
+// или nil без keyring и для пустого значения.
+func blindIndex(keys *keyring.Keyring, field, value string) []byte {
+	if keys == nil {
+		return nil
+	}
+
+	value = normalizeContact(field, value)
+	if value == "" {
+		return nil
+	}
+	return keys.BlindIndex(field, value)
+}
+
+// normalizeContact приводит значение поля доставки к виду, по которому
+// строится слепой индекс, чтобы одно имя, почта или телефон, записанные
+// по-разному, совпадали.
+func normalizeContact(field, value string) string {
+	switch field {
+	case "name":
+		return strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	case "email":
+		return strings.ToLower(strings.TrimSpace(value))
+	case "phone":
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) || r == '+' {
+				return r
+			}
+			return -1
+		}, value)
+	}
+	return value
+}
+
+// auditOrder возвращает заказ в том виде, в каком он попадает в order_audit.
+// С keyring персональные данные доставки заменяются началом их слепых
+// индексов: в истории видно, что поле изменилось, но не его значение.
+func auditOrder(keys *keyring.Keyring, eo *models.ExtendedOrder) *models.ExtendedOrder {
+	if keys == nil || eo == nil {
+		return eo
+	}
+
+	c := *eo
+	for _, f := range deliveryFields(&c.Delivery) {
+		if idx := blindIndex(keys, f.Name, *f.Value); idx != nil {
+			*f.Value = auditMarker(idx)
+		}
+	}
+	return &c
+}
+
+// auditMarker — запись слепого индекса idx в истории изменений.
+func auditMarker(idx []byte) string {
+	return "hmac:" + hex.EncodeToString(idx[:8])
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DeliveryEventRepository interface {
+	// AddEvents сохраняет события, пропуская уже сохранённые,
+	// и возвращает число новых.
+	AddEvents(ctx context.Context, events []*models.DeliveryEvent) (int, error)
+	// Timeline возвращает события по трек-номерам tracks в порядке времени.
+	Timeline(ctx context.Context, tracks []string) ([]*models.DeliveryEvent, error)
+	// LatestByTrack возвращает последнее событие по каждому из трек-номеров
+	// tracks. Трек-номеров без событий в ответе нет.
+	LatestByTrack(ctx context.Context, tracks []string) (map[string]*models.DeliveryEvent, error)
+}
+
+type deliveryEventRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDeliveryEventRepository(db *pgxpool.Pool) DeliveryEventRepository {
+	return &deliveryEventRepository{db: db}
+}
+
+const selectDeliveryEventsQuery = `
+	SELECT id, track_number, carrier, status, location, occurred_at
+	FROM delivery_events
+	WHERE track_number = ANY($1)
+`
+
+func (r *deliveryEventRepository) AddEvents(ctx context.Context, events []*models.DeliveryEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		if e == nil {
+			return 0, ErrNilValue
+		}
+		batch.Queue(`
+			INSERT INTO delivery_events (track_number, carrier, status, location, occurred_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (track_number, carrier, status, occurred_at) DO NOTHING
+			RETURNING id;
+		`, e.TrackNumber, e.Carrier, e.Status, e.Location, e.OccurredAt)
+	}
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	added := 0
+	for _, e := range events {
+		err := br.QueryRow().Scan(&e.ID)
+		switch {
+		case err == nil:
+			added++
+		case errors.Is(err, pgx.ErrNoRows):
+			// событие уже сохранено
+		default:
+			return 0, wrapDBError(err)
+		}
+	}
+
+	return added, wrapDBError(br.Close())
+}
+
+func (r *deliveryEventRepository) Timeline(ctx context.Context, tracks []string) ([]*models.DeliveryEvent, error) {
+	rows, err := r.db.Query(ctx, selectDeliveryEventsQuery+`
+		ORDER BY occurred_at, id;
+	`, tracks)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	events, err := pgx.CollectRows(rows, scanDeliveryEvent)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return events, nil
+}
+
+func (r *deliveryEventRepository) LatestByTrack(ctx context.Context, tracks []string) (map[string]*models.DeliveryEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT ON (track_number) id, track_number, carrier, status, location, occurred_at
+		FROM delivery_events
+		WHERE track_number = ANY($1)
+		ORDER BY track_number, occurred_at DESC, id DESC;
+	`, tracks)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	events, err := pgx.CollectRows(rows, scanDeliveryEvent)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	latest := make(map[string]*models.DeliveryEvent, len(events))
+	for _, e := range events {
+		latest[e.TrackNumber] = e
+	}
+
+	return latest, nil
+}
+
+func scanDeliveryEvent(row pgx.CollectableRow) (*models.DeliveryEvent, error) {
+	e := new(models.DeliveryEvent)
+	err := row.Scan(&e.ID, &e.TrackNumber, &e.Carrier, &e.Status, &e.Location, &e.OccurredAt)
+	return e, err
+}
@@ -0,0 +1,58 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryEventRepository(t *testing.T) {
+	repo := repository.NewDeliveryEventRepository(db)
+
+	at := func(h int) time.Time { return time.Date(2025, time.January, 2, h, 0, 0, 0, time.UTC) }
+
+	events := []*models.DeliveryEvent{
+		{TrackNumber: "event test 1", Carrier: "cdek", Status: "accepted", Location: "Moscow", OccurredAt: at(8)},
+		{TrackNumber: "event test 2", Carrier: "cdek", Status: "in_transit", Location: "Tver", OccurredAt: at(12)},
+		{TrackNumber: "event test 1", Carrier: "cdek", Status: "in_transit", Location: "Tver", OccurredAt: at(10)},
+	}
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM delivery_events WHERE track_number LIKE 'event test%'`)
+		require.NoError(t, err)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		added, err := repo.AddEvents(t.Context(), events)
+		require.NoError(t, err)
+		assert.Equal(t, 3, added)
+
+		duplicate := *events[0]
+		added, err = repo.AddEvents(t.Context(), []*models.DeliveryEvent{&duplicate})
+		require.NoError(t, err)
+		assert.Zero(t, added)
+	})
+
+	t.Run("Timeline", func(t *testing.T) {
+		timeline, err := repo.Timeline(t.Context(), []string{"event test 1"})
+		require.NoError(t, err)
+		require.Len(t, timeline, 2)
+		assert.Equal(t, "accepted", timeline[0].Status)
+		assert.Equal(t, "in_transit", timeline[1].Status)
+	})
+
+	t.Run("LatestByTrack", func(t *testing.T) {
+		latest, err := repo.LatestByTrack(t.Context(), []string{"event test 1", "event test 2", "event test none"})
+		require.NoError(t, err)
+		require.Len(t, latest, 2)
+		assert.Equal(t, events[2].ID, latest["event test 1"].ID)
+		assert.Equal(t, events[1].ID, latest["event test 2"].ID)
+	})
+}
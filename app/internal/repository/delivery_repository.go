@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"test-task/internal/keyring"
 	"test-task/internal/models"
 
 	"github.com/jackc/pgx/v5"
@@ -15,14 +17,22 @@ type DeliveryRepository interface {
 	GetByOrderIDs(ctx context.Context, tx pgx.Tx, ids []int64) ([]*models.Delivery, error)
 	Update(ctx context.Context, tx pgx.Tx, delivery *models.Delivery) error
 	Delete(ctx context.Context, tx pgx.Tx, id int64) error
+	// Reencrypt перешифровывает основным ключом keyring до limit доставок
+	// с id больше afterID, зашифрованных другим ключом или открытых.
+	// Возвращает id последней перешифрованной доставки и их число;
+	// n == 0 — перешифровывать больше нечего.
+	Reencrypt(ctx context.Context, afterID int64, limit int) (lastID int64, n int, err error)
 }
 
 type deliveryRepository struct {
-	db *pgxpool.Pool
+	db   *pgxpool.Pool
+	keys *keyring.Keyring
 }
 
-func NewDeliveryRepository(db *pgxpool.Pool) DeliveryRepository {
-	return &deliveryRepository{db: db}
+// NewDeliveryRepository создаёт репозиторий доставок. С keyring имя,
+// телефон, адрес и email шифруются, без него пишутся открыто.
+func NewDeliveryRepository(db *pgxpool.Pool, keys *keyring.Keyring) DeliveryRepository {
+	return &deliveryRepository{db: db, keys: keys}
 }
 
 func (r *deliveryRepository) Create(ctx context.Context, tx pgx.Tx, delivery *models.Delivery) error {
@@ -30,30 +40,19 @@ func (r *deliveryRepository) Create(ctx context.Context, tx pgx.Tx, delivery *mo
 		return ErrNilValue
 	}
 
+	sealed, err := sealDelivery(r.keys, delivery)
+	if err != nil {
+		return err
+	}
+
 	var exec pgx.Row
 	if tx != nil {
-		exec = tx.QueryRow(ctx, insertDeliveryQuery,
-			delivery.Name,
-			delivery.Phone,
-			delivery.Zip,
-			delivery.City,
-			delivery.Address,
-			delivery.Region,
-			delivery.Email,
-		)
+		exec = tx.QueryRow(ctx, insertDeliveryQuery, sealed.args()...)
 	} else {
-		exec = r.db.QueryRow(ctx, insertDeliveryQuery,
-			delivery.Name,
-			delivery.Phone,
-			delivery.Zip,
-			delivery.City,
-			delivery.Address,
-			delivery.Region,
-			delivery.Email,
-		)
+		exec = r.db.QueryRow(ctx, insertDeliveryQuery, sealed.args()...)
 	}
 
-	err := exec.Scan(&delivery.ID)
+	err = exec.Scan(&delivery.ID)
 
 	return wrapDBError(err)
 }
@@ -65,12 +64,14 @@ func (r *deliveryRepository) Get(ctx context.Context, tx pgx.Tx, id int64) (*mod
 
 	query := `
 		SELECT
-			id, name, phone, zip, city, address, region, email
+			id, name, phone, zip, city, address, region, email, enc_key_id, enc_dek
 		FROM delivery
 		WHERE id = $1;
 	`
 
 	delivery := new(models.Delivery)
+	var keyID *string
+	var dek []byte
 	var exec pgx.Row
 	if tx != nil {
 		exec = tx.QueryRow(ctx, query, id)
@@ -87,13 +88,22 @@ func (r *deliveryRepository) Get(ctx context.Context, tx pgx.Tx, id int64) (*mod
 		&delivery.Address,
 		&delivery.Region,
 		&delivery.Email,
+		&keyID,
+		&dek,
 	)
 
 	if err == pgx.ErrNoRows {
 		return nil, ErrNotFound
 	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
 
-	return delivery, wrapDBError(err)
+	if err := openDelivery(r.keys, delivery, keyID, dek); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
 }
 
 func (r *deliveryRepository) GetByOrderIDs(ctx context.Context, tx pgx.Tx, ids []int64) ([]*models.Delivery, error) {
@@ -103,7 +113,7 @@ func (r *deliveryRepository) GetByOrderIDs(ctx context.Context, tx pgx.Tx, ids [
 
 	query := `
         SELECT
-			id, name, phone, zip, city, address, region, email
+			id, name, phone, zip, city, address, region, email, enc_key_id, enc_dek
         FROM delivery
         WHERE id = ANY($1);
     `
@@ -123,12 +133,17 @@ func (r *deliveryRepository) GetByOrderIDs(ctx context.Context, tx pgx.Tx, ids [
 	deliveries := make([]*models.Delivery, 0, len(ids))
 	for rows.Next() {
 		d := new(models.Delivery)
+		var keyID *string
+		var dek []byte
 		if err := rows.Scan(
 			&d.ID, &d.Name, &d.Phone, &d.Zip, &d.City,
-			&d.Address, &d.Region, &d.Email,
+			&d.Address, &d.Region, &d.Email, &keyID, &dek,
 		); err != nil {
 			return nil, wrapDBError(err)
 		}
+		if err := openDelivery(r.keys, d, keyID, dek); err != nil {
+			return nil, err
+		}
 		deliveries = append(deliveries, d)
 	}
 
@@ -144,36 +159,16 @@ func (r *deliveryRepository) Update(ctx context.Context, tx pgx.Tx, delivery *mo
 		return ErrNilValue
 	}
 
-	query := `
-		UPDATE delivery SET
-			name = $1, phone = $2, zip = $3, city = $4, address = $5, region = $6, email = $7
-		WHERE id = $8;
-	`
+	sealed, err := sealDelivery(r.keys, delivery)
+	if err != nil {
+		return err
+	}
 
 	var cmd pgconn.CommandTag
-	var err error
 	if tx != nil {
-		cmd, err = tx.Exec(ctx, query,
-			delivery.Name,
-			delivery.Phone,
-			delivery.Zip,
-			delivery.City,
-			delivery.Address,
-			delivery.Region,
-			delivery.Email,
-			delivery.ID,
-		)
+		cmd, err = tx.Exec(ctx, updateDeliveryQuery, sealed.updateArgs()...)
 	} else {
-		cmd, err = r.db.Exec(ctx, query,
-			delivery.Name,
-			delivery.Phone,
-			delivery.Zip,
-			delivery.City,
-			delivery.Address,
-			delivery.Region,
-			delivery.Email,
-			delivery.ID,
-		)
+		cmd, err = r.db.Exec(ctx, updateDeliveryQuery, sealed.updateArgs()...)
 	}
 
 	if cmd.RowsAffected() == 0 {
@@ -204,3 +199,85 @@ func (r *deliveryRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) er
 
 	return wrapDBError(err)
 }
+
+func (r *deliveryRepository) Reencrypt(ctx context.Context, afterID int64, limit int) (lastID int64, n int, err error) {
+	if r.keys == nil {
+		return 0, 0, ErrNoKeyring
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT
+			id, name, phone, zip, city, address, region, email, enc_key_id, enc_dek
+		FROM delivery
+		WHERE id > $1 AND enc_key_id IS DISTINCT FROM $2
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE;
+	`, afterID, r.keys.Primary(), limit)
+	if err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+
+	var deliveries []*models.Delivery
+	for rows.Next() {
+		d := new(models.Delivery)
+		var keyID *string
+		var dek []byte
+		if err := rows.Scan(
+			&d.ID, &d.Name, &d.Phone, &d.Zip, &d.City,
+			&d.Address, &d.Region, &d.Email, &keyID, &dek,
+		); err != nil {
+			rows.Close()
+			return 0, 0, wrapDBError(err)
+		}
+		if err := openDelivery(r.keys, d, keyID, dek); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("delivery %d: %w", d.ID, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+	if len(deliveries) == 0 {
+		return afterID, 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		sealed, err := sealDelivery(r.keys, d)
+		if err != nil {
+			return 0, 0, err
+		}
+		batch.Queue(updateDeliveryQuery, sealed.updateArgs()...)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+
+	return deliveries[len(deliveries)-1].ID, len(deliveries), nil
+}
+
+// args — параметры insertDeliveryQuery.
+func (s *sealedDelivery) args() []any {
+	return []any{
+		s.Name, s.Phone, s.Zip, s.City, s.Address, s.Region, s.Email,
+		s.keyID, s.dek, s.nameIdx, s.phoneIdx, s.emailIdx,
+	}
+}
+
+// updateArgs — параметры updateDeliveryQuery.
+func (s *sealedDelivery) updateArgs() []any {
+	return append(s.args(), s.ID)
+}
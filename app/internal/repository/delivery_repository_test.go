@@ -4,16 +4,19 @@
 package repository_test
 
 import (
+	"bytes"
 	"testing"
 
+	"test-task/internal/keyring"
 	"test-task/internal/models"
 	"test-task/internal/repository"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDeliveryRepository_CRUD(t *testing.T) {
-	repo := repository.NewDeliveryRepository(db)
+	repo := repository.NewDeliveryRepository(db, nil)
 
 	delivery := &models.Delivery{
 		Name:    "test",
@@ -60,3 +63,64 @@ func TestDeliveryRepository_CRUD(t *testing.T) {
 		assert.ErrorIs(t, repository.ErrNotFound, err)
 	})
 }
+
+// testKeyring возвращает keyring с ключами k1 и k2 и основным primary.
+func testKeyring(t *testing.T, primary string) *keyring.Keyring {
+	t.Helper()
+	key := func(b byte) []byte { return bytes.Repeat([]byte{b}, keyring.KeySize) }
+	keys, err := keyring.New(primary, map[string][]byte{"k1": key(1), "k2": key(2)}, key(9))
+	require.NoError(t, err)
+	return keys
+}
+
+func TestDeliveryRepository_Encrypted(t *testing.T) {
+	old, rotated := testKeyring(t, "k1"), testKeyring(t, "k2")
+
+	repo := repository.NewDeliveryRepository(db, old)
+
+	delivery := &models.Delivery{
+		Name:    "Test Testov",
+		Phone:   "+9720000000",
+		Zip:     "2639809",
+		City:    "Kiryat Mozkin",
+		Address: "Ploshad Mira 15",
+		Region:  "Kraiot",
+		Email:   "test@gmail.com",
+	}
+	want := *delivery
+
+	// перешифровка работает в своей транзакции, поэтому строка пишется сразу
+	require.NoError(t, repo.Create(t.Context(), nil, delivery))
+	defer repository.NewDeliveryRepository(db, nil).Delete(t.Context(), nil, delivery.ID)
+	want.ID = delivery.ID
+	assert.Equal(t, &want, delivery)
+
+	var name, phone, keyID string
+	require.NoError(t, db.QueryRow(t.Context(),
+		`SELECT name, phone, enc_key_id FROM delivery WHERE id = $1;`, delivery.ID,
+	).Scan(&name, &phone, &keyID))
+	assert.NotEqual(t, want.Name, name)
+	assert.NotEqual(t, want.Phone, phone)
+	assert.Equal(t, "k1", keyID)
+
+	d, err := repo.Get(t.Context(), nil, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &want, d)
+
+	_, err = repository.NewDeliveryRepository(db, nil).Get(t.Context(), nil, delivery.ID)
+	assert.ErrorIs(t, err, repository.ErrNoKeyring)
+
+	lastID, n, err := repository.NewDeliveryRepository(db, rotated).Reencrypt(t.Context(), delivery.ID-1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, delivery.ID, lastID)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, db.QueryRow(t.Context(),
+		`SELECT enc_key_id FROM delivery WHERE id = $1;`, delivery.ID,
+	).Scan(&keyID))
+	assert.Equal(t, "k2", keyID)
+
+	d, err = repository.NewDeliveryRepository(db, rotated).Get(t.Context(), nil, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &want, d)
+}
@@ -15,6 +15,8 @@ var (
 	ErrDuplicate           = errors.New("duplicate")
 	ErrForeignKeyViolation = errors.New("foreign key violation")
 	ErrNoRowsAffected      = errors.New("no rows affected")
+	ErrLockNotAcquired     = errors.New("advisory lock not acquired")
+	ErrNoKeyring           = errors.New("record is encrypted but no keyring is configured")
 )
 
 // Оборачивает pgx/pgconn ошибки
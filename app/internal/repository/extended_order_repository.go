@@ -3,7 +3,11 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"test-task/internal/audit"
+	"test-task/internal/keyring"
 	"test-task/internal/models"
 
 	"github.com/jackc/pgx/v5"
@@ -13,28 +17,43 @@ import (
 type ExtendedOrderRepository interface {
 	CreateExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) error
 	GetExtendedOrder(ctx context.Context, id int64) (*models.ExtendedOrder, error)
+	GetExtendedOrderByUID(ctx context.Context, uid string) (*models.ExtendedOrder, error)
+	// ListExtendedOrders возвращает страницу заказов по фильтру f
+	// в порядке возрастания id.
+	ListExtendedOrders(ctx context.Context, f models.OrderListFilter) ([]*models.ExtendedOrder, error)
 	GetLastExtendedOrders(ctx context.Context, limit int) ([]*models.ExtendedOrder, error)
+	// GetExtendedOrdersByTrack возвращает не больше limit заказов, у которых
+	// трек-номер track указан у самого заказа или у одной из позиций.
+	GetExtendedOrdersByTrack(ctx context.Context, track string, limit int) ([]*models.ExtendedOrder, error)
+	UpdateExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) error
+	UpdateOrderStatus(ctx context.Context, id int64, status int) error
+	DeleteExtendedOrder(ctx context.Context, id int64) error
 	Orders() OrdersRepository
 	Items() ItemsRepository
 	Delivery() DeliveryRepository
 	Payment() PaymentRepository
+	Audit() AuditRepository
 }
 
 type extendedOrderRepository struct {
 	db       *pgxpool.Pool
+	keys     *keyring.Keyring
 	orders   OrdersRepository
 	items    ItemsRepository
 	delivery DeliveryRepository
 	payment  PaymentRepository
+	audit    AuditRepository
 }
 
-func NewExtendedOrderRepository(db *pgxpool.Pool) ExtendedOrderRepository {
+func NewExtendedOrderRepository(db *pgxpool.Pool, keys *keyring.Keyring) ExtendedOrderRepository {
 	return &extendedOrderRepository{
 		db:       db,
+		keys:     keys,
 		orders:   NewOrdersRepository(db),
 		items:    NewItemsRepository(db),
-		delivery: NewDeliveryRepository(db),
+		delivery: NewDeliveryRepository(db, keys),
 		payment:  NewPaymentRepository(db),
+		audit:    NewAuditRepository(db),
 	}
 }
 
@@ -53,7 +72,39 @@ func (r *extendedOrderRepository) CreateExtendedOrder(ctx context.Context, eo *m
 		}
 	}()
 
-	err = r.delivery.Create(ctx, tx, &eo.Delivery)
+	err = lockOrderUID(ctx, tx, eo.Order.OrderUID)
+	if err != nil {
+		return err
+	}
+
+	var existingID int64
+	err = tx.QueryRow(ctx, `SELECT id FROM order_keys WHERE order_uid = $1;`, eo.Order.OrderUID).Scan(&existingID)
+	switch {
+	case err == nil:
+		err = r.reingestExtendedOrder(ctx, tx, existingID, eo)
+		if err != nil {
+			return err
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		err = r.createExtendedOrder(ctx, tx, eo)
+		if err != nil {
+			return err
+		}
+	default:
+		return wrapDBError(err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+// createExtendedOrder создаёт новый заказ в транзакции tx.
+func (r *extendedOrderRepository) createExtendedOrder(ctx context.Context, tx pgx.Tx, eo *models.ExtendedOrder) error {
+	err := r.delivery.Create(ctx, tx, &eo.Delivery)
 	if err != nil {
 		return wrapDBError(err)
 	}
@@ -71,18 +122,94 @@ func (r *extendedOrderRepository) CreateExtendedOrder(ctx context.Context, eo *m
 		return wrapDBError(err)
 	}
 
+	err = r.addItems(ctx, tx, eo)
+	if err != nil {
+		return err
+	}
+
+	return r.writeAudit(ctx, tx, eo.Order.ID, models.AuditActionCreate, nil, eo)
+}
+
+// reingestExtendedOrder применяет повторно принятый заказ к заказу id с тем
+// же order_uid: доставка и оплата обновляются на месте, позиции добавляются.
+// В аудит пишется изменение между прежним и новым состоянием заказа,
+// а eo заполняется новым состоянием целиком.
+func (r *extendedOrderRepository) reingestExtendedOrder(ctx context.Context, tx pgx.Tx, id int64, eo *models.ExtendedOrder) error {
+	err := lockOrder(ctx, tx, id)
+	if errors.Is(err, ErrNotFound) {
+		// удалённый заказ повторным приёмом не восстанавливается
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+
+	before, err := r.getExtendedOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if eo.Payment.Amount.Cmp(before.Payment.Refunded) < 0 {
+		return ErrRefundExceedsPayment
+	}
+	eo.Payment.Refunded = before.Payment.Refunded
+
+	// прежнее состояние заказа вычитается из агрегатов
+	err = applyRollups(ctx, tx, []int64{id}, -1)
+	if err != nil {
+		return err
+	}
+
+	eo.Order = before.Order
+	eo.Delivery.ID = before.Delivery.ID
+	eo.Payment.ID = before.Payment.ID
+
+	err = r.delivery.Update(ctx, tx, &eo.Delivery)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = r.payment.Update(ctx, tx, &eo.Payment)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = r.addItems(ctx, tx, eo)
+	if err != nil {
+		return err
+	}
+
+	after, err := r.getExtendedOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	*eo = *after
+
+	return r.writeAudit(ctx, tx, id, models.AuditActionUpdate, before, after)
+}
+
+// addItems добавляет позиции eo к заказу eo.Order.ID, пересчитывает агрегаты
+// и сохраняет оценку риска.
+func (r *extendedOrderRepository) addItems(ctx context.Context, tx pgx.Tx, eo *models.ExtendedOrder) error {
 	for _, item := range eo.Items {
 		item.OrderID = eo.Order.ID
 	}
 
-	err = r.items.CreateItems(ctx, tx, eo.Items)
+	err := r.items.CreateItems(ctx, tx, eo.Items)
 	if err != nil {
 		return wrapDBError(err)
 	}
 
-	err = tx.Commit(ctx)
+	err = applyRollups(ctx, tx, []int64{eo.Order.ID}, 1)
 	if err != nil {
-		return wrapDBError(err)
+		return err
+	}
+
+	if eo.Risk != nil {
+		err = saveRisk(ctx, tx, eo.Order.ID, eo.Risk)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -93,15 +220,27 @@ func (r *extendedOrderRepository) GetExtendedOrder(ctx context.Context, id int64
 		return nil, ErrInvalidID
 	}
 
-	eo := new(models.ExtendedOrder)
+	return r.getExtendedOrder(ctx, r.db, id)
+}
 
+func (r *extendedOrderRepository) GetExtendedOrderByUID(ctx context.Context, uid string) (*models.ExtendedOrder, error) {
+	var id int64
+	err := r.db.QueryRow(ctx, `SELECT id FROM order_keys WHERE order_uid = $1;`, uid).Scan(&id)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return r.getExtendedOrder(ctx, r.db, id)
+}
+
+func (r *extendedOrderRepository) getExtendedOrder(ctx context.Context, q querier, id int64) (*models.ExtendedOrder, error) {
 	batch := &pgx.Batch{}
 
 	query := selectExtendedOrderWithoutItemsQuery + `
-		WHERE o.id = $1;
+		AND o.id = $1 AND o.date_created = ` + orderDateQuery + `;
 	`
-	itemsQuery := selectItemsWitoutWhereQuery + `
-		wHERE order_id = $1;
+	itemsQuery := selectItemsQuery + `
+		AND order_id = $1 AND order_date_created = ` + orderDateQuery + `;
 	`
 
 	batch.Queue(
@@ -114,24 +253,10 @@ func (r *extendedOrderRepository) GetExtendedOrder(ctx context.Context, id int64
 		id,
 	)
 
-	br := r.db.SendBatch(ctx, batch)
+	br := q.SendBatch(ctx, batch)
 	defer br.Close()
 
-	err := br.QueryRow().Scan(
-		&eo.Order.ID, &eo.Order.OrderUID, &eo.Order.TrackNumber,
-		&eo.Order.Entry, &eo.Order.DeliveryID, &eo.Order.PaymentID,
-		&eo.Order.Locale, &eo.Order.InternalSignature,
-		&eo.Order.CustomerID, &eo.Order.DeliveryService,
-		&eo.Order.ShardKey, &eo.Order.SMID, &eo.Order.DateCreated, &eo.Order.OOFShard,
-
-		&eo.Delivery.ID, &eo.Delivery.Name, &eo.Delivery.Phone, &eo.Delivery.Zip, &eo.Delivery.City,
-		&eo.Delivery.Address, &eo.Delivery.Region, &eo.Delivery.Email,
-
-		&eo.Payment.ID, &eo.Payment.Transaction, &eo.Payment.RequestID,
-		&eo.Payment.Currency, &eo.Payment.Provider, &eo.Payment.Amount,
-		&eo.Payment.PaymentDate, &eo.Payment.Bank, &eo.Payment.DeliveryCost,
-		&eo.Payment.GoodsTotal, &eo.Payment.CustomFee,
-	)
+	eo, err := scanExtendedOrder(br.QueryRow(), r.keys)
 	if err != nil {
 		return nil, wrapDBError(err)
 	}
@@ -144,27 +269,15 @@ func (r *extendedOrderRepository) GetExtendedOrder(ctx context.Context, id int64
 
 	eo.Items = make([]*models.Item, 0)
 	for rows.Next() {
-		item := new(models.Item)
-		err = rows.Scan(
-			&item.ID,
-			&item.OrderID,
-			&item.ChrtID,
-			&item.TrackNumber,
-			&item.Price,
-			&item.RID,
-			&item.Name,
-			&item.Sale,
-			&item.Size,
-			&item.TotalPrice,
-			&item.NMID,
-			&item.Brand,
-			&item.Status,
-		)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, wrapDBError(err)
 		}
 		eo.Items = append(eo.Items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
 
 	return eo, nil
 }
@@ -192,64 +305,469 @@ func (r *extendedOrderRepository) GetLastExtendedOrders(ctx context.Context, lim
 	eos := make([]*models.ExtendedOrder, 0, limit)
 
 	for rows.Next() {
-		eo := new(models.ExtendedOrder)
-		if err := rows.Scan(
-			&eo.Order.ID, &eo.Order.OrderUID, &eo.Order.TrackNumber,
-			&eo.Order.Entry, &eo.Order.DeliveryID, &eo.Order.PaymentID,
-			&eo.Order.Locale, &eo.Order.InternalSignature,
-			&eo.Order.CustomerID, &eo.Order.DeliveryService,
-			&eo.Order.ShardKey, &eo.Order.SMID, &eo.Order.DateCreated, &eo.Order.OOFShard,
-
-			&eo.Delivery.ID, &eo.Delivery.Name, &eo.Delivery.Phone, &eo.Delivery.Zip, &eo.Delivery.City,
-			&eo.Delivery.Address, &eo.Delivery.Region, &eo.Delivery.Email,
-
-			&eo.Payment.ID, &eo.Payment.Transaction, &eo.Payment.RequestID,
-			&eo.Payment.Currency, &eo.Payment.Provider, &eo.Payment.Amount,
-			&eo.Payment.PaymentDate, &eo.Payment.Bank, &eo.Payment.DeliveryCost,
-			&eo.Payment.GoodsTotal, &eo.Payment.CustomFee,
-		); err != nil {
+		eo, err := scanExtendedOrder(rows, r.keys)
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+		eos = append(eos, eo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+	rows.Close()
+
+	if err := loadItems(ctx, r.db, eos); err != nil {
+		return nil, err
+	}
+
+	return eos, nil
+}
+
+func (r *extendedOrderRepository) ListExtendedOrders(ctx context.Context, f models.OrderListFilter) ([]*models.ExtendedOrder, error) {
+	if f.Limit <= 0 {
+		return []*models.ExtendedOrder{}, nil
+	}
+
+	query := selectExtendedOrderWithoutItemsQuery + `
+		AND o.id > $1
+		AND ($2 = '' OR o.customer_id = $2)
+		AND ($3 = '' OR o.delivery_service = $3)
+		AND ($4::timestamp IS NULL OR o.date_created >= $4)
+		AND ($5::timestamp IS NULL OR o.date_created < $5)
+		ORDER BY o.id
+		LIMIT $6;
+	`
+
+	rows, err := r.db.Query(ctx, query,
+		f.AfterID, f.CustomerID, f.DeliveryService,
+		nullTime(f.From), nullTime(f.To), f.Limit,
+	)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	eos := make([]*models.ExtendedOrder, 0, f.Limit)
+	for rows.Next() {
+		eo, err := scanExtendedOrder(rows, r.keys)
+		if err != nil {
 			return nil, wrapDBError(err)
 		}
 		eos = append(eos, eo)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+	rows.Close()
+
+	if err := loadItems(ctx, r.db, eos); err != nil {
+		return nil, err
+	}
+
+	return eos, nil
+}
+
+func (r *extendedOrderRepository) GetExtendedOrdersByTrack(ctx context.Context, track string, limit int) ([]*models.ExtendedOrder, error) {
+	if limit <= 0 {
+		return []*models.ExtendedOrder{}, nil
+	}
+
+	query := selectExtendedOrderWithoutItemsQuery + `
+		AND (o.id, o.date_created) IN (
+			SELECT id, date_created FROM orders
+			WHERE track_number = $1 AND deleted_at IS NULL
+			UNION
+			SELECT order_id, order_date_created FROM items
+			WHERE track_number = $1 AND deleted_at IS NULL
+		)
+		ORDER BY o.date_created DESC, o.id DESC
+		LIMIT $2;
+	`
+
+	rows, err := r.db.Query(ctx, query, track, limit)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	eos := make([]*models.ExtendedOrder, 0)
+	for rows.Next() {
+		eo, err := scanExtendedOrder(rows, r.keys)
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+		eos = append(eos, eo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+	rows.Close()
+
+	if err := loadItems(ctx, r.db, eos); err != nil {
+		return nil, err
+	}
+
+	return eos, nil
+}
+
+func (r *extendedOrderRepository) UpdateExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) error {
+	if eo == nil {
+		return ErrNilValue
+	}
+	if eo.Order.ID <= 0 {
+		return ErrInvalidID
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = lockOrder(ctx, tx, eo.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	var before *models.ExtendedOrder
+	before, err = r.getExtendedOrder(ctx, tx, eo.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	// сумма оплаты не может стать меньше уже оформленных возвратов
+	if eo.Payment.Amount.Cmp(before.Payment.Refunded) < 0 {
+		err = ErrRefundExceedsPayment
+		return err
+	}
+	eo.Payment.Refunded = before.Payment.Refunded
+	// оценка риска выставляется только при приёме заказа
+	eo.Risk = before.Risk
+
+	err = applyRollups(ctx, tx, []int64{eo.Order.ID}, -1)
+	if err != nil {
+		return err
+	}
+
+	eo.Delivery.ID = before.Delivery.ID
+	eo.Payment.ID = before.Payment.ID
+	eo.Order.DeliveryID = before.Delivery.ID
+	eo.Order.PaymentID = before.Payment.ID
+	eo.Order.DateCreated = before.Order.DateCreated
+
+	err = r.delivery.Update(ctx, tx, &eo.Delivery)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = r.payment.Update(ctx, tx, &eo.Payment)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = r.orders.Update(ctx, tx, &eo.Order)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	existing := make(map[int64]bool, len(before.Items))
+	for _, item := range before.Items {
+		existing[item.ID] = true
+	}
+
+	kept := make(map[int64]bool, len(eo.Items))
+	newItems := make([]*models.Item, 0)
+	for _, item := range eo.Items {
+		item.OrderID = eo.Order.ID
+		if item.ID == 0 {
+			newItems = append(newItems, item)
+			continue
+		}
+		if !existing[item.ID] {
+			err = ErrInvalidID
+			return err
+		}
+		kept[item.ID] = true
+		err = r.items.Update(ctx, tx, item)
+		if err != nil {
+			return wrapDBError(err)
+		}
+	}
+
+	for _, item := range before.Items {
+		if kept[item.ID] {
+			continue
+		}
+		err = r.items.Delete(ctx, tx, item.ID)
+		if err != nil {
+			return wrapDBError(err)
+		}
+	}
+
+	err = r.items.CreateItems(ctx, tx, newItems)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = applyRollups(ctx, tx, []int64{eo.Order.ID}, 1)
+	if err != nil {
+		return err
+	}
+
+	err = r.writeAudit(ctx, tx, eo.Order.ID, models.AuditActionUpdate, before, eo)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+func (r *extendedOrderRepository) UpdateOrderStatus(ctx context.Context, id int64, status int) error {
+	if id <= 0 {
+		return ErrInvalidID
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = lockOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	var before *models.ExtendedOrder
+	before, err = r.getExtendedOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.items.UpdateStatus(ctx, tx, id, status)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	after := *before
+	after.Items = make([]*models.Item, 0, len(before.Items))
+	for _, item := range before.Items {
+		updated := *item
+		updated.Status = status
+		after.Items = append(after.Items, &updated)
+	}
+
+	err = r.writeAudit(ctx, tx, id, models.AuditActionStatusChange, before, &after)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+func (r *extendedOrderRepository) DeleteExtendedOrder(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrInvalidID
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = lockOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	var before *models.ExtendedOrder
+	before, err = r.getExtendedOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = applyRollups(ctx, tx, []int64{id}, -1)
+	if err != nil {
+		return err
+	}
 
+	// delivery и payment остаются до окончательного удаления заказа в purge.
+	err = r.orders.Delete(ctx, tx, id)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = r.writeAudit(ctx, tx, id, models.AuditActionDelete, before, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+// lockOrder блокирует заказ и его оплату до конца транзакции tx, чтобы
+// состояние до изменения для аудита не разошлось с параллельным изменением,
+// а сумма оплаты сверялась с возвратами, которые блокируют ту же оплату.
+func lockOrder(ctx context.Context, tx pgx.Tx, id int64) error {
+	var locked int64
+	err := tx.QueryRow(ctx, `
+		SELECT o.id
+		FROM orders AS o
+		INNER JOIN payment AS p ON p.id = o.payment_id
+		WHERE o.id = $1 AND o.date_created = `+orderDateQuery+` AND o.deleted_at IS NULL
+		FOR UPDATE OF o, p;
+	`, id).Scan(&locked)
+	return wrapDBError(err)
+}
+
+// lockOrderUID до конца транзакции tx не пускает другие транзакции создавать
+// заказ с тем же order_uid. Без неё параллельные вставки обе не находят заказ
+// в order_keys, и вторая падает на уникальности вместо добавления позиций.
+func lockOrderUID(ctx context.Context, tx pgx.Tx, uid string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('order_uid:' || $1));`, uid)
+	return wrapDBError(err)
+}
+
+// writeAudit записывает изменение заказа в order_audit в рамках транзакции tx.
+func (r *extendedOrderRepository) writeAudit(
+	ctx context.Context,
+	tx pgx.Tx,
+	orderID int64,
+	action string,
+	before, after *models.ExtendedOrder,
+) error {
+	diff, err := audit.Diff(auditOrder(r.keys, before), auditOrder(r.keys, after))
+	if err != nil {
+		return err
+	}
+
+	return r.audit.Create(ctx, tx, &models.AuditEntry{
+		OrderID: orderID,
+		Action:  action,
+		Actor:   audit.ActorFromContext(ctx),
+		Diff:    diff,
+	})
+}
+
+// scanExtendedOrder читает строку selectExtendedOrderWithoutItemsQuery.
+func scanExtendedOrder(row pgx.Row, keys *keyring.Keyring) (*models.ExtendedOrder, error) {
+	eo := new(models.ExtendedOrder)
+	var keyID *string
+	var dek []byte
+	err := row.Scan(
+		&eo.Order.ID, &eo.Order.OrderUID, &eo.Order.TrackNumber,
+		&eo.Order.Entry, &eo.Order.DeliveryID, &eo.Order.PaymentID,
+		&eo.Order.Locale, &eo.Order.InternalSignature,
+		&eo.Order.CustomerID, &eo.Order.DeliveryService,
+		&eo.Order.ShardKey, &eo.Order.SMID, &eo.Order.DateCreated, &eo.Order.OOFShard,
+
+		&eo.Delivery.ID, &eo.Delivery.Name, &eo.Delivery.Phone, &eo.Delivery.Zip, &eo.Delivery.City,
+		&eo.Delivery.Address, &eo.Delivery.Region, &eo.Delivery.Email,
+		&keyID, &dek,
+
+		&eo.Payment.ID, &eo.Payment.Transaction, &eo.Payment.RequestID,
+		&eo.Payment.Currency, &eo.Payment.Provider, &eo.Payment.Amount,
+		&eo.Payment.PaymentDate, &eo.Payment.Bank, &eo.Payment.DeliveryCost,
+		&eo.Payment.GoodsTotal, &eo.Payment.CustomFee, &eo.Payment.Refunded,
+
+		&eo.Risk,
+	)
+	if err != nil {
+		return eo, err
+	}
+	return eo, openDelivery(keys, &eo.Delivery, keyID, dek)
+}
+
+// scanItem читает строку selectItemsQuery.
+func scanItem(row pgx.Row) (*models.Item, error) {
+	item := new(models.Item)
+	err := row.Scan(
+		&item.ID,
+		&item.OrderID,
+		&item.ChrtID,
+		&item.TrackNumber,
+		&item.Price,
+		&item.RID,
+		&item.Name,
+		&item.Sale,
+		&item.Size,
+		&item.TotalPrice,
+		&item.NMID,
+		&item.Brand,
+		&item.Status,
+	)
+	return item, err
+}
+
+// loadItems одним запросом загружает позиции для всех заказов eos.
+func loadItems(ctx context.Context, q querier, eos []*models.ExtendedOrder) error {
+	if len(eos) == 0 {
+		return nil
+	}
+
+	// границы дат заказов ограничивают поиск нужными секциями items
 	orderIDs := make([]int64, 0, len(eos))
+	from, to := eos[0].Order.DateCreated, eos[0].Order.DateCreated
 	for _, eo := range eos {
 		orderIDs = append(orderIDs, eo.Order.ID)
+		if eo.Order.DateCreated.Before(from) {
+			from = eo.Order.DateCreated
+		}
+		if eo.Order.DateCreated.After(to) {
+			to = eo.Order.DateCreated
+		}
 	}
 
-	itemsQuery := selectItemsWitoutWhereQuery + `
-		WHERE order_id = ANY($1);
+	itemsQuery := selectItemsQuery + `
+		AND order_id = ANY($1)
+		AND order_date_created BETWEEN $2 AND $3;
 	`
 
-	rows, err = r.db.Query(ctx, itemsQuery, orderIDs)
+	rows, err := q.Query(ctx, itemsQuery, orderIDs, from, to)
 	if err != nil {
-		return nil, wrapDBError(err)
+		return wrapDBError(err)
 	}
 	defer rows.Close()
 
 	items := make(map[int64][]*models.Item)
 	for rows.Next() {
-		item := new(models.Item)
-		if err := rows.Scan(
-			&item.ID,
-			&item.OrderID,
-			&item.ChrtID,
-			&item.TrackNumber,
-			&item.Price,
-			&item.RID,
-			&item.Name,
-			&item.Sale,
-			&item.Size,
-			&item.TotalPrice,
-			&item.NMID,
-			&item.Brand,
-			&item.Status,
-		); err != nil {
-			return nil, wrapDBError(err)
+		item, err := scanItem(rows)
+		if err != nil {
+			return wrapDBError(err)
 		}
 		items[item.OrderID] = append(items[item.OrderID], item)
 	}
+	if err := rows.Err(); err != nil {
+		return wrapDBError(err)
+	}
 
 	for _, eo := range eos {
 		if its, ok := items[eo.Order.ID]; ok {
@@ -257,7 +775,15 @@ func (r *extendedOrderRepository) GetLastExtendedOrders(ctx context.Context, lim
 		}
 	}
 
-	return eos, nil
+	return nil
+}
+
+// nullTime передаёт нулевое время как NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
 func (r *extendedOrderRepository) Orders() OrdersRepository { return r.orders }
@@ -267,3 +793,5 @@ func (r *extendedOrderRepository) Items() ItemsRepository { return r.items }
 func (r *extendedOrderRepository) Delivery() DeliveryRepository { return r.delivery }
 
 func (r *extendedOrderRepository) Payment() PaymentRepository { return r.payment }
+
+func (r *extendedOrderRepository) Audit() AuditRepository { return r.audit }
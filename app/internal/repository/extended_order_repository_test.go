@@ -5,6 +5,7 @@ package repository_test
 
 import (
 	"test-task/internal/models"
+	"test-task/internal/money"
 	"test-task/internal/repository"
 	"testing"
 	"time"
@@ -13,7 +14,7 @@ import (
 )
 
 func TestExtendedOrderRepository(t *testing.T) {
-	repo := repository.NewExtendedOrderRepository(db)
+	repo := repository.NewExtendedOrderRepository(db, nil)
 
 	extendedOrder := &models.ExtendedOrder{
 		Order: models.Order{
@@ -33,12 +34,12 @@ func TestExtendedOrderRepository(t *testing.T) {
 			RequestID:    "",
 			Currency:     "RUB",
 			Provider:     "alfa",
-			Amount:       1000,
+			Amount:       money.FromInt(1000),
 			PaymentDate:  90872534,
 			Bank:         "tbank",
-			DeliveryCost: 325,
-			GoodsTotal:   32,
-			CustomFee:    0,
+			DeliveryCost: money.FromInt(325),
+			GoodsTotal:   money.FromInt(32),
+			CustomFee:    money.FromInt(0),
 		},
 		Delivery: models.Delivery{
 			Name:    "test",
@@ -53,12 +54,12 @@ func TestExtendedOrderRepository(t *testing.T) {
 			{
 				ChrtID:      324,
 				TrackNumber: "test",
-				Price:       200,
+				Price:       money.FromInt(200),
 				RID:         "test",
 				Name:        "test",
 				Sale:        20,
 				Size:        "test",
-				TotalPrice:  200,
+				TotalPrice:  money.FromInt(200),
 				NMID:        12,
 				Brand:       "test",
 				Status:      1,
@@ -66,12 +67,12 @@ func TestExtendedOrderRepository(t *testing.T) {
 			{
 				ChrtID:      324,
 				TrackNumber: "test",
-				Price:       200,
+				Price:       money.FromInt(200),
 				RID:         "test",
 				Name:        "test",
 				Sale:        20,
 				Size:        "test",
-				TotalPrice:  200,
+				TotalPrice:  money.FromInt(200),
 				NMID:        12,
 				Brand:       "test",
 				Status:      1,
@@ -79,12 +80,12 @@ func TestExtendedOrderRepository(t *testing.T) {
 			{
 				ChrtID:      324,
 				TrackNumber: "test",
-				Price:       200,
+				Price:       money.FromInt(200),
 				RID:         "test",
 				Name:        "test",
 				Sale:        20,
 				Size:        "test",
-				TotalPrice:  200,
+				TotalPrice:  money.FromInt(200),
 				NMID:        12,
 				Brand:       "test",
 				Status:      1,
@@ -109,4 +110,40 @@ func TestExtendedOrderRepository(t *testing.T) {
 		assert.Equal(t, 1, len(eos))
 		assert.Equal(t, extendedOrder, eos[0])
 	})
+
+	t.Run("Get By Track", func(t *testing.T) {
+		eos, err := repo.GetExtendedOrdersByTrack(t.Context(), extendedOrder.Order.TrackNumber, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.ExtendedOrder{extendedOrder}, eos)
+
+		eos, err = repo.GetExtendedOrdersByTrack(t.Context(), "no such track", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, eos)
+	})
+	t.Run("Get By UID", func(t *testing.T) {
+		eo, err := repo.GetExtendedOrderByUID(t.Context(), extendedOrder.Order.OrderUID)
+		assert.NoError(t, err)
+		assert.Equal(t, extendedOrder, eo)
+
+		_, err = repo.GetExtendedOrderByUID(t.Context(), "no such order")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		eos, err := repo.ListExtendedOrders(t.Context(), models.OrderListFilter{
+			CustomerID: extendedOrder.Order.CustomerID,
+			From:       extendedOrder.Order.DateCreated,
+			To:         extendedOrder.Order.DateCreated.Add(time.Hour),
+			Limit:      10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.ExtendedOrder{extendedOrder}, eos)
+
+		eos, err = repo.ListExtendedOrders(t.Context(), models.OrderListFilter{
+			AfterID: extendedOrder.Order.ID,
+			Limit:   10,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, eos)
+	})
 }
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FraudRepository отдаёт правилам проверки выборки по сохранённым заказам
+// и список подозрительных заказов. Оценка риска сохраняется вместе с заказом
+// в CreateExtendedOrder.
+type FraudRepository interface {
+	CustomerOrders(ctx context.Context, customerID string, since, until time.Time) (int64, error)
+	ContactCustomers(ctx context.Context, email, phone, customerID string, since, until time.Time) (int64, error)
+	// ListFlagged возвращает неудалённые заказы с оценкой риска не ниже minScore,
+	// помеченные последними первыми.
+	ListFlagged(ctx context.Context, minScore, limit, offset int) (*models.FlaggedOrders, error)
+}
+
+type fraudRepository struct {
+	db   *pgxpool.Pool
+	keys *keyring.Keyring
+}
+
+func NewFraudRepository(db *pgxpool.Pool, keys *keyring.Keyring) FraudRepository {
+	return &fraudRepository{db: db, keys: keys}
+}
+
+func (r *fraudRepository) CustomerOrders(ctx context.Context, customerID string, since, until time.Time) (int64, error) {
+	var n int64
+	err := r.db.QueryRow(ctx, `
+		SELECT count(*) FROM orders
+		WHERE customer_id = $1 AND customer_id <> '' AND deleted_at IS NULL
+			AND date_created >= $2 AND date_created <= $3;
+	`, customerID, since, until).Scan(&n)
+	if err != nil {
+		return 0, wrapDBError(err)
+	}
+	return n, nil
+}
+
+func (r *fraudRepository) ContactCustomers(
+	ctx context.Context,
+	email, phone, customerID string,
+	since, until time.Time,
+) (int64, error) {
+	// зашифрованные доставки сравниваются по слепым индексам, открытые —
+	// после той же нормализации, что и для индексов
+	email, phone = normalizeContact("email", email), normalizeContact("phone", phone)
+
+	var n int64
+	err := r.db.QueryRow(ctx, `
+		SELECT count(DISTINCT NULLIF(o.customer_id, ''))
+		FROM delivery AS d
+		INNER JOIN orders AS o ON o.delivery_id = d.id
+		WHERE (
+				d.email_bidx = $6 OR d.phone_bidx = $7
+				OR (d.enc_key_id IS NULL AND (
+					($1 <> '' AND lower(btrim(d.email)) = $1)
+					OR ($2 <> '' AND regexp_replace(d.phone, '[^0-9+]', '', 'g') = $2)
+				))
+			)
+			AND o.customer_id <> $3 AND o.deleted_at IS NULL
+			AND o.date_created >= $4 AND o.date_created <= $5;
+	`, email, phone, customerID, since, until,
+		blindIndex(r.keys, "email", email), blindIndex(r.keys, "phone", phone),
+	).Scan(&n)
+	if err != nil {
+		return 0, wrapDBError(err)
+	}
+	return n, nil
+}
+
+func (r *fraudRepository) ListFlagged(ctx context.Context, minScore, limit, offset int) (*models.FlaggedOrders, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT f.order_id, o.order_uid, o.customer_id, o.date_created, f.score, f.reasons, f.created_at
+		FROM fraud_flags AS f
+		INNER JOIN orders AS o ON o.id = f.order_id AND o.deleted_at IS NULL
+		WHERE f.score >= $1
+		ORDER BY f.created_at DESC, f.order_id DESC
+		LIMIT $2 OFFSET $3;
+	`, minScore, limit, offset)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	orders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.FlaggedOrder, error) {
+		fo := new(models.FlaggedOrder)
+		err := row.Scan(
+			&fo.OrderID, &fo.OrderUID, &fo.CustomerID, &fo.DateCreated,
+			&fo.Score, &fo.Reasons, &fo.FlaggedAt,
+		)
+		return fo, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return &models.FlaggedOrders{Orders: orders, Limit: limit, Offset: offset}, nil
+}
+
+// saveRisk записывает оценку риска заказа, заменяя прежнюю.
+func saveRisk(ctx context.Context, q querier, orderID int64, risk *models.Risk) error {
+	err := q.QueryRow(ctx, `
+		INSERT INTO fraud_flags (order_id, score, reasons)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_id) DO UPDATE
+		SET score = EXCLUDED.score, reasons = EXCLUDED.reasons, created_at = now()
+		RETURNING created_at;
+	`, orderID, risk.Score, risk.Reasons).Scan(&risk.FlaggedAt)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	return nil
+}
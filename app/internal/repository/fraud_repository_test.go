@@ -0,0 +1,108 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraudRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewFraudRepository(db, nil)
+
+	date := time.Date(2003, time.November, 7, 12, 0, 0, 0, time.UTC)
+
+	var ids []int64
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics fraud%'`)
+		require.NoError(t, err)
+		_, err = db.Exec(t.Context(), `DELETE FROM fraud_flags WHERE order_id = ANY($1)`, ids)
+		require.NoError(t, err)
+		require.NoError(t, repository.NewRollupRepository(db).Rebuild(t.Context(), date, date.AddDate(0, 0, 1)))
+	})
+
+	// два заказа покупателя A за час, заказ покупателя B и заказ без покупателя
+	// с тем же email
+	for i, customer := range []string{"fraud A", "fraud A", "fraud B", ""} {
+		eo := analyticsOrder("analytics fraud "+strconv.Itoa(i), "RUB", "alpha",
+			date.Add(time.Duration(i)*10*time.Minute), analyticsItem("A", 1, 0, 300))
+		eo.Order.CustomerID = customer
+		eo.Delivery.Email = "fraud@test.com"
+		eo.Delivery.Phone = "+7900000000" + strconv.Itoa(i)
+		if i == 2 {
+			eo.Risk = &models.Risk{
+				Score:   50,
+				Reasons: []*models.FraudReason{{Rule: "shared_contact", Score: 50, Message: "shared"}},
+			}
+		}
+		require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+		ids = append(ids, eo.Order.ID)
+	}
+
+	t.Run("Customer Orders", func(t *testing.T) {
+		n, err := repo.CustomerOrders(t.Context(), "fraud A", date, date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		n, err = repo.CustomerOrders(t.Context(), "fraud A", date.Add(5*time.Minute), date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = repo.CustomerOrders(t.Context(), "", date, date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("Contact Customers", func(t *testing.T) {
+		n, err := repo.ContactCustomers(t.Context(), "fraud@test.com", "", "fraud C", date, date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		n, err = repo.ContactCustomers(t.Context(), "other@test.com", "+79000000001", "fraud B", date, date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		// открытые значения сравниваются после нормализации, как слепые индексы
+		n, err = repo.ContactCustomers(t.Context(), " Fraud@Test.COM ", "", "fraud C", date, date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		n, err = repo.ContactCustomers(t.Context(), "other@test.com", "+7 (900) 000-00-01", "fraud B", date, date.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+
+	t.Run("Risk", func(t *testing.T) {
+		got, err := eoRepo.GetExtendedOrder(t.Context(), ids[2])
+		require.NoError(t, err)
+		require.NotNil(t, got.Risk)
+		assert.Equal(t, 50, got.Risk.Score)
+		assert.Equal(t, "shared_contact", got.Risk.Reasons[0].Rule)
+		assert.False(t, got.Risk.FlaggedAt.IsZero())
+
+		got, err = eoRepo.GetExtendedOrder(t.Context(), ids[0])
+		require.NoError(t, err)
+		assert.Nil(t, got.Risk)
+	})
+
+	t.Run("List Flagged", func(t *testing.T) {
+		page, err := repo.ListFlagged(t.Context(), 50, 100, 0)
+		require.NoError(t, err)
+		require.Len(t, page.Orders, 1)
+		assert.Equal(t, ids[2], page.Orders[0].OrderID)
+		assert.Equal(t, "fraud B", page.Orders[0].CustomerID)
+
+		page, err = repo.ListFlagged(t.Context(), 51, 100, 0)
+		require.NoError(t, err)
+		assert.Empty(t, page.Orders)
+	})
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FXRepository interface {
+	// UpsertRates сохраняет курсы, перезаписывая уже загруженные на те же даты.
+	UpsertRates(ctx context.Context, rates []*models.FXRate) error
+	// GetRate возвращает последний курс валюты на дату date или раньше.
+	GetRate(ctx context.Context, currency string, date time.Time) (*models.FXRate, error)
+}
+
+type fxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFXRepository(db *pgxpool.Pool) FXRepository {
+	return &fxRepository{db: db}
+}
+
+func (r *fxRepository) UpsertRates(ctx context.Context, rates []*models.FXRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		if rate == nil {
+			return ErrNilValue
+		}
+		batch.Queue(`
+			INSERT INTO fx_rates (currency, rate_date, rate)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (currency, rate_date)
+			DO UPDATE SET rate = EXCLUDED.rate;
+		`, rate.Currency, rate.Date, rate.Rate)
+	}
+
+	return wrapDBError(r.db.SendBatch(ctx, batch).Close())
+}
+
+func (r *fxRepository) GetRate(ctx context.Context, currency string, date time.Time) (*models.FXRate, error) {
+	rate := &models.FXRate{Currency: currency}
+
+	err := r.db.QueryRow(ctx, `
+		SELECT rate_date, rate
+		FROM fx_rates
+		WHERE currency = $1 AND rate_date <= $2
+		ORDER BY rate_date DESC
+		LIMIT 1;
+	`, currency, date).Scan(&rate.Date, &rate.Rate)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return rate, nil
+}
@@ -15,7 +15,10 @@ type ItemsRepository interface {
 	CreateItems(ctx context.Context, tx pgx.Tx, items []*models.Item) error
 	Get(ctx context.Context, tx pgx.Tx, id int64) (*models.Item, error)
 	GetItems(ctx context.Context, tx pgx.Tx, orderID int64) ([]*models.Item, error)
+	// GetByOrderIDs возвращает позиции заказов ids, сгруппированные по заказу.
+	GetByOrderIDs(ctx context.Context, tx pgx.Tx, ids []int64) (map[int64][]*models.Item, error)
 	Update(ctx context.Context, tx pgx.Tx, item *models.Item) error
+	UpdateStatus(ctx context.Context, tx pgx.Tx, orderID int64, status int) error
 	Delete(ctx context.Context, tx pgx.Tx, id int64) error
 }
 
@@ -133,8 +136,8 @@ func (r *itemsRepository) Get(ctx context.Context, tx pgx.Tx, id int64) (*models
 
 	item := new(models.Item)
 	item.ID = id
-	query := selectItemsWitoutWhereQuery + `
-		WHERE id = $1;
+	query := selectItemsQuery + `
+		AND id = $1;
 	`
 
 	var err error
@@ -188,8 +191,8 @@ func (r *itemsRepository) GetItems(ctx context.Context, tx pgx.Tx, orderID int64
 		return nil, ErrInvalidID
 	}
 
-	query := selectItemsWitoutWhereQuery + `
-		WHERE order_id = $1;
+	query := selectItemsQuery + `
+		AND order_id = $1 AND order_date_created = ` + orderDateQuery + `;
 	`
 
 	var rows pgx.Rows
@@ -235,6 +238,46 @@ func (r *itemsRepository) GetItems(ctx context.Context, tx pgx.Tx, orderID int64
 	return items, nil
 }
 
+func (r *itemsRepository) GetByOrderIDs(ctx context.Context, tx pgx.Tx, ids []int64) (map[int64][]*models.Item, error) {
+	if len(ids) == 0 {
+		return map[int64][]*models.Item{}, nil
+	}
+
+	// даты заказов из order_keys ограничивают поиск нужными секциями items
+	query := selectItemsQuery + `
+		AND (order_id, order_date_created) IN (
+			SELECT id, date_created FROM order_keys WHERE id = ANY($1)
+		)
+		ORDER BY order_id, id;
+	`
+
+	var rows pgx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, ids)
+	} else {
+		rows, err = r.db.Query(ctx, query, ids)
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	items := make(map[int64][]*models.Item, len(ids))
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+		items[item.OrderID] = append(items[item.OrderID], item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return items, nil
+}
+
 func (r *itemsRepository) Update(ctx context.Context, tx pgx.Tx, item *models.Item) error {
 	if item == nil {
 		return ErrNilValue
@@ -247,6 +290,7 @@ func (r *itemsRepository) Update(ctx context.Context, tx pgx.Tx, item *models.It
 		UPDATE items
 		SET
 			order_id = $2,
+			order_date_created = (SELECT date_created FROM order_keys WHERE id = $2),
 			chrt_id = $3,
 			track_number = $4,
 			price = $5,
@@ -258,7 +302,7 @@ func (r *itemsRepository) Update(ctx context.Context, tx pgx.Tx, item *models.It
 			nm_id = $11,
 			brand = $12,
 			status = $13
-		WHERE id = $1;
+		WHERE id = $1 AND deleted_at IS NULL;
 	`
 
 	var cmd pgconn.CommandTag
@@ -308,14 +352,44 @@ func (r *itemsRepository) Update(ctx context.Context, tx pgx.Tx, item *models.It
 	return wrapDBError(err)
 }
 
+func (r *itemsRepository) UpdateStatus(ctx context.Context, tx pgx.Tx, orderID int64, status int) error {
+	if orderID <= 0 {
+		return ErrInvalidID
+	}
+
+	query := `
+		UPDATE items
+		SET status = $2
+		WHERE order_id = $1 AND order_date_created = ` + orderDateQuery + ` AND deleted_at IS NULL;
+	`
+
+	var cmd pgconn.CommandTag
+	var err error
+	if tx == nil {
+		cmd, err = r.db.Exec(ctx, query, orderID, status)
+	} else {
+		cmd, err = tx.Exec(ctx, query, orderID, status)
+	}
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
 func (r *itemsRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) error {
 	if id <= 0 {
 		return ErrInvalidID
 	}
 
 	query := `
-		DELETE FROM items
-		WHERE id = $1;
+		UPDATE items
+		SET deleted_at = now()
+		WHERE id = $1 AND deleted_at IS NULL;
 	`
 
 	var cmd pgconn.CommandTag
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"test-task/internal/models"
+	"test-task/internal/money"
 	"test-task/internal/repository"
 
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,7 @@ import (
 // test build and change int64 to int, delete last migration
 
 func TestItemsRepository_CRUD(t *testing.T) {
-	dRepo := repository.NewDeliveryRepository(db)
+	dRepo := repository.NewDeliveryRepository(db, nil)
 	pRepo := repository.NewPaymentRepository(db)
 	oRepo := repository.NewOrdersRepository(db)
 	repo := repository.NewItemsRepository(db)
@@ -26,12 +27,12 @@ func TestItemsRepository_CRUD(t *testing.T) {
 		RequestID:    "",
 		Currency:     "RUB",
 		Provider:     "alfa",
-		Amount:       1000,
+		Amount:       money.FromInt(1000),
 		PaymentDate:  90872534,
 		Bank:         "tbank",
-		DeliveryCost: 325,
-		GoodsTotal:   32,
-		CustomFee:    0,
+		DeliveryCost: money.FromInt(325),
+		GoodsTotal:   money.FromInt(32),
+		CustomFee:    money.FromInt(0),
 	}
 
 	delivery := &models.Delivery{
@@ -60,12 +61,12 @@ func TestItemsRepository_CRUD(t *testing.T) {
 	item := &models.Item{
 		ChrtID:      324,
 		TrackNumber: "test",
-		Price:       200,
+		Price:       money.FromInt(200),
 		RID:         "test",
 		Name:        "test",
 		Sale:        20,
 		Size:        "test",
-		TotalPrice:  200,
+		TotalPrice:  money.FromInt(200),
 		NMID:        12,
 		Brand:       "test",
 		Status:      1,
@@ -75,12 +76,12 @@ func TestItemsRepository_CRUD(t *testing.T) {
 		{
 			ChrtID:      324,
 			TrackNumber: "test",
-			Price:       200,
+			Price:       money.FromInt(200),
 			RID:         "test",
 			Name:        "test",
 			Sale:        20,
 			Size:        "test",
-			TotalPrice:  200,
+			TotalPrice:  money.FromInt(200),
 			NMID:        12,
 			Brand:       "test",
 			Status:      1,
@@ -88,12 +89,12 @@ func TestItemsRepository_CRUD(t *testing.T) {
 		{
 			ChrtID:      324,
 			TrackNumber: "test",
-			Price:       200,
+			Price:       money.FromInt(200),
 			RID:         "test",
 			Name:        "test",
 			Sale:        20,
 			Size:        "test",
-			TotalPrice:  200,
+			TotalPrice:  money.FromInt(200),
 			NMID:        12,
 			Brand:       "test",
 			Status:      1,
@@ -101,12 +102,12 @@ func TestItemsRepository_CRUD(t *testing.T) {
 		{
 			ChrtID:      324,
 			TrackNumber: "test",
-			Price:       200,
+			Price:       money.FromInt(200),
 			RID:         "test",
 			Name:        "test",
 			Sale:        20,
 			Size:        "test",
-			TotalPrice:  200,
+			TotalPrice:  money.FromInt(200),
 			NMID:        12,
 			Brand:       "test",
 			Status:      1,
@@ -174,4 +175,9 @@ func TestItemsRepository_CRUD(t *testing.T) {
 		assert.Equal(t, items, i)
 		assert.NoError(t, err)
 	})
+	t.Run("Get by order ids", func(t *testing.T) {
+		byOrder, err := repo.GetByOrderIDs(t.Context(), tx, []int64{order.ID, -1})
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64][]*models.Item{order.ID: items}, byOrder)
+	})
 }
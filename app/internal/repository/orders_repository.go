@@ -12,6 +12,10 @@ import (
 type OrdersRepository interface {
 	Create(ctx context.Context, tx pgx.Tx, order *models.Order) error
 	Get(ctx context.Context, tx pgx.Tx, id int64) (*models.Order, error)
+	GetByUID(ctx context.Context, tx pgx.Tx, uid string) (*models.Order, error)
+	// List возвращает заказы без позиций, доставки и оплаты по фильтру f
+	// в порядке возрастания id.
+	List(ctx context.Context, tx pgx.Tx, f models.OrderListFilter) ([]*models.Order, error)
 	Update(ctx context.Context, tx pgx.Tx, order *models.Order) error
 	Delete(ctx context.Context, tx pgx.Tx, id int64) error
 }
@@ -91,7 +95,7 @@ func (r *ordersRepository) Get(ctx context.Context, tx pgx.Tx, id int64) (*model
 			date_created,
 			oof_shard
 		FROM orders
-		WHERE id = $1;
+		WHERE id = $1 AND date_created = ` + orderDateQuery + ` AND deleted_at IS NULL;
 	`
 
 	order := new(models.Order)
@@ -122,6 +126,93 @@ func (r *ordersRepository) Get(ctx context.Context, tx pgx.Tx, id int64) (*model
 	return order, wrapDBError(err)
 }
 
+func (r *ordersRepository) GetByUID(ctx context.Context, tx pgx.Tx, uid string) (*models.Order, error) {
+	query := selectOrdersQuery + `
+		AND (id, date_created) = (SELECT id, date_created FROM order_keys WHERE order_uid = $1);
+	`
+
+	var exec pgx.Row
+	if tx != nil {
+		exec = tx.QueryRow(ctx, query, uid)
+	} else {
+		exec = r.db.QueryRow(ctx, query, uid)
+	}
+
+	order, err := scanOrder(exec)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return order, nil
+}
+
+func (r *ordersRepository) List(ctx context.Context, tx pgx.Tx, f models.OrderListFilter) ([]*models.Order, error) {
+	if f.Limit <= 0 {
+		return []*models.Order{}, nil
+	}
+
+	query := selectOrdersQuery + `
+		AND id > $1
+		AND ($2 = '' OR customer_id = $2)
+		AND ($3 = '' OR delivery_service = $3)
+		AND ($4::timestamp IS NULL OR date_created >= $4)
+		AND ($5::timestamp IS NULL OR date_created < $5)
+		ORDER BY id
+		LIMIT $6;
+	`
+	args := []any{
+		f.AfterID, f.CustomerID, f.DeliveryService,
+		nullTime(f.From), nullTime(f.To), f.Limit,
+	}
+
+	var rows pgx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, args...)
+	} else {
+		rows, err = r.db.Query(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer rows.Close()
+
+	orders := make([]*models.Order, 0, f.Limit)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return orders, nil
+}
+
+func scanOrder(row pgx.Row) (*models.Order, error) {
+	order := new(models.Order)
+	err := row.Scan(
+		&order.ID,
+		&order.OrderUID,
+		&order.TrackNumber,
+		&order.Entry,
+		&order.DeliveryID,
+		&order.PaymentID,
+		&order.Locale,
+		&order.InternalSignature,
+		&order.CustomerID,
+		&order.DeliveryService,
+		&order.ShardKey,
+		&order.SMID,
+		&order.DateCreated,
+		&order.OOFShard,
+	)
+	return order, err
+}
+
 func (r *ordersRepository) Update(ctx context.Context, tx pgx.Tx, order *models.Order) error {
 	if order == nil {
 		return ErrNilValue
@@ -141,7 +232,7 @@ func (r *ordersRepository) Update(ctx context.Context, tx pgx.Tx, order *models.
 			shardkey = $11,
 			sm_id = $12,
 			oof_shard = $13
-		WHERE id = $1;
+		WHERE id = $1 AND date_created = ` + orderDateQuery + ` AND deleted_at IS NULL;
 	`
 
 	var cmd pgconn.CommandTag
@@ -192,18 +283,35 @@ func (r *ordersRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) erro
 		return ErrInvalidID
 	}
 
-	query := `DELETE FROM orders WHERE id = $1;`
-	var cmd pgconn.CommandTag
-	var err error
+	// Заказ и его позиции удаляются мягко, окончательно их удаляет purge.
+	query := `
+		WITH deleted AS (
+			UPDATE orders SET deleted_at = now()
+			WHERE id = $1 AND date_created = ` + orderDateQuery + ` AND deleted_at IS NULL
+			RETURNING id, date_created
+		), deleted_items AS (
+			UPDATE items SET deleted_at = now()
+			WHERE (order_id, order_date_created) IN (SELECT id, date_created FROM deleted)
+				AND deleted_at IS NULL
+		)
+		SELECT count(*) FROM deleted;
+	`
+
+	var exec pgx.Row
 	if tx != nil {
-		cmd, err = tx.Exec(ctx, query, id)
+		exec = tx.QueryRow(ctx, query, id)
 	} else {
-		cmd, err = r.db.Exec(ctx, query, id)
+		exec = r.db.QueryRow(ctx, query, id)
 	}
 
-	if cmd.RowsAffected() == 0 {
+	var deleted int64
+	if err := exec.Scan(&deleted); err != nil {
+		return wrapDBError(err)
+	}
+
+	if deleted == 0 {
 		return ErrNoRowsAffected
 	}
 
-	return wrapDBError(err)
+	return nil
 }
@@ -8,13 +8,14 @@ import (
 	"time"
 
 	"test-task/internal/models"
+	"test-task/internal/money"
 	"test-task/internal/repository"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestOrdersRepository_CRUD(t *testing.T) {
-	dRepo := repository.NewDeliveryRepository(db)
+	dRepo := repository.NewDeliveryRepository(db, nil)
 	pRepo := repository.NewPaymentRepository(db)
 	repo := repository.NewOrdersRepository(db)
 
@@ -23,12 +24,12 @@ func TestOrdersRepository_CRUD(t *testing.T) {
 		RequestID:    "",
 		Currency:     "RUB",
 		Provider:     "alfa",
-		Amount:       1000,
+		Amount:       money.FromInt(1000),
 		PaymentDate:  90872534,
 		Bank:         "tbank",
-		DeliveryCost: 325,
-		GoodsTotal:   32,
-		CustomFee:    0,
+		DeliveryCost: money.FromInt(325),
+		GoodsTotal:   money.FromInt(32),
+		CustomFee:    money.FromInt(0),
 	}
 
 	delivery := &models.Delivery{
@@ -83,6 +84,27 @@ func TestOrdersRepository_CRUD(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("Get By UID", func(t *testing.T) {
+		o, err := repo.GetByUID(t.Context(), tx, order.OrderUID)
+		assert.NoError(t, err)
+		assert.Equal(t, order, o)
+
+		_, err = repo.GetByUID(t.Context(), tx, "no such order")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		orders, err := repo.List(t.Context(), tx, models.OrderListFilter{
+			CustomerID: order.CustomerID,
+			AfterID:    order.ID - 1,
+			From:       order.DateCreated,
+			To:         order.DateCreated.Add(time.Hour),
+			Limit:      1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.Order{order}, orders)
+	})
+
 	order.DeliveryService = "new test"
 
 	t.Run("Update", func(t *testing.T) {
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// partitionLockName — имя advisory-блокировки на обслуживание секций,
+// чтобы несколько реплик не меняли их одновременно.
+const partitionLockName = "orders_partitions"
+
+// Секционированные таблицы: orders по date_created и items по order_date_created.
+// Месячные секции называются <table>_pYYYY_MM.
+var partitionedTables = []string{"orders", "items"}
+
+type PartitionRepository interface {
+	// ListMonthPartitions возвращает начала месяцев, для которых
+	// у orders есть присоединённая секция, по возрастанию.
+	ListMonthPartitions(ctx context.Context) ([]time.Time, error)
+	// CreateMonthPartitions создаёт секции orders и items на месяц month,
+	// если их ещё нет, и переносит в них заказы этого месяца из секций
+	// по умолчанию.
+	CreateMonthPartitions(ctx context.Context, month time.Time) error
+	// DetachMonthPartitions отсоединяет секции orders и items за месяц month.
+	// Отсоединённые таблицы остаются в базе как обычные, доставка и оплата
+	// их заказов переносятся рядом в delivery_pYYYY_MM и payment_pYYYY_MM.
+	DetachMonthPartitions(ctx context.Context, month time.Time) error
+}
+
+type partitionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPartitionRepository(db *pgxpool.Pool) PartitionRepository {
+	return &partitionRepository{db: db}
+}
+
+func monthPartitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_p%04d_%02d", table, month.Year(), int(month.Month()))
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (r *partitionRepository) ListMonthPartitions(ctx context.Context) ([]time.Time, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits AS i
+		INNER JOIN pg_class AS c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'orders'::regclass;
+	`)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	months := make([]time.Time, 0, len(names))
+	for _, name := range names {
+		var year, month int
+		// orders_default и посторонние секции пропускаются
+		if _, err := fmt.Sscanf(name, "orders_p%04d_%02d", &year, &month); err != nil {
+			continue
+		}
+		months = append(months, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC))
+	}
+
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+
+	return months, nil
+}
+
+func (r *partitionRepository) CreateMonthPartitions(ctx context.Context, month time.Time) error {
+	from := monthStart(month)
+	to := from.AddDate(0, 1, 0)
+
+	return r.withLock(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL;`,
+			monthPartitionName("orders", from)).Scan(&exists)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		if exists {
+			return nil
+		}
+
+		// Заказы за месяц без секции лежат в orders_default и items_default,
+		// и секцию с ними не создать. Они переносятся во временные таблицы
+		// и после создания секций вставляются обратно.
+		_, err = tx.Exec(ctx, `LOCK TABLE orders_default, items_default IN EXCLUSIVE MODE;`)
+		if err != nil {
+			return wrapDBError(err)
+		}
+
+		columns := make(map[string]string, len(partitionedTables))
+		for _, table := range partitionedTables {
+			if columns[table], err = insertableColumns(ctx, tx, table); err != nil {
+				return err
+			}
+		}
+
+		for _, move := range []struct{ table, key string }{
+			{"items", "order_date_created"},
+			{"orders", "date_created"},
+		} {
+			// позиции удаляются первыми, удаление заказов убирает их ключи из order_keys
+			_, err = tx.Exec(ctx, fmt.Sprintf(`
+				CREATE TEMP TABLE moved_%[1]s (LIKE %[1]s) ON COMMIT DROP;
+				INSERT INTO moved_%[1]s (%[2]s) SELECT %[2]s FROM %[1]s_default
+				WHERE %[3]s >= '%[4]s' AND %[3]s < '%[5]s';
+				DELETE FROM %[1]s_default WHERE %[3]s >= '%[4]s' AND %[3]s < '%[5]s';
+			`, move.table, columns[move.table], move.key, from.Format(time.DateOnly), to.Format(time.DateOnly)))
+			if err != nil {
+				return wrapDBError(err)
+			}
+		}
+
+		for _, table := range partitionedTables {
+			query := fmt.Sprintf(
+				`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s');`,
+				pgx.Identifier{monthPartitionName(table, from)}.Sanitize(),
+				pgx.Identifier{table}.Sanitize(),
+				from.Format(time.DateOnly),
+				to.Format(time.DateOnly),
+			)
+			if _, err := tx.Exec(ctx, query); err != nil {
+				return wrapDBError(err)
+			}
+		}
+
+		// триггер order_keys снова добавляет ключи перенесённых заказов
+		for _, table := range partitionedTables {
+			_, err = tx.Exec(ctx, fmt.Sprintf(
+				`INSERT INTO %[1]s (%[2]s) SELECT %[2]s FROM moved_%[1]s;`,
+				table, columns[table],
+			))
+			if err != nil {
+				return wrapDBError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *partitionRepository) DetachMonthPartitions(ctx context.Context, month time.Time) error {
+	from := monthStart(month)
+	to := from.AddDate(0, 1, 0)
+
+	orders := monthPartitionName("orders", from)
+	items := monthPartitionName("items", from)
+	delivery := monthPartitionName("delivery", from)
+	payment := monthPartitionName("payment", from)
+
+	return r.withLock(ctx, func(tx pgx.Tx) error {
+		var attached bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM pg_inherits
+				WHERE inhrelid = to_regclass($1) AND inhparent = 'orders'::regclass
+			);
+		`, orders).Scan(&attached)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		if !attached {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf(
+			`ALTER TABLE items DETACH PARTITION %s;`,
+			pgx.Identifier{items}.Sanitize(),
+		))
+		if err != nil {
+			return wrapDBError(err)
+		}
+
+		// Внешний ключ отсоединённой секции items на orders
+		// не даст отсоединить секцию orders.
+		err = dropForeignKeys(ctx, tx, items)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf(
+			`ALTER TABLE orders DETACH PARTITION %s;`,
+			pgx.Identifier{orders}.Sanitize(),
+		))
+		if err != nil {
+			return wrapDBError(err)
+		}
+
+		err = dropForeignKeys(ctx, tx, orders)
+		if err != nil {
+			return err
+		}
+
+		// Доставка и оплата отсоединённых заказов переносятся в таблицы
+		// рядом с секциями, чтобы не остаться в рабочих таблицах без заказов.
+		for _, move := range []struct{ table, archive, column string }{
+			{"delivery", delivery, "delivery_id"},
+			{"payment", payment, "payment_id"},
+		} {
+			_, err = tx.Exec(ctx, fmt.Sprintf(
+				`CREATE TABLE %[1]s AS SELECT * FROM %[2]s WHERE id IN (SELECT %[3]s FROM %[4]s);
+				DELETE FROM %[2]s WHERE id IN (SELECT id FROM %[1]s);`,
+				pgx.Identifier{move.archive}.Sanitize(),
+				pgx.Identifier{move.table}.Sanitize(),
+				pgx.Identifier{move.column}.Sanitize(),
+				pgx.Identifier{orders}.Sanitize(),
+			))
+			if err != nil {
+				return wrapDBError(err)
+			}
+		}
+
+		// Триггер order_keys на отсоединение не срабатывает.
+		_, err = tx.Exec(ctx, `
+			DELETE FROM order_keys
+			WHERE date_created >= $1 AND date_created < $2;
+		`, from, to)
+		if err != nil {
+			return wrapDBError(err)
+		}
+
+		return nil
+	})
+}
+
+// insertableColumns возвращает через запятую столбцы table, которые можно
+// вставлять: без вычисляемых и удалённых.
+func insertableColumns(ctx context.Context, tx pgx.Tx, table string) (string, error) {
+	var columns string
+	err := tx.QueryRow(ctx, `
+		SELECT string_agg(quote_ident(attname), ', ' ORDER BY attnum)
+		FROM pg_attribute
+		WHERE attrelid = to_regclass($1) AND attnum > 0
+			AND NOT attisdropped AND attgenerated = '';
+	`, table).Scan(&columns)
+	if err != nil {
+		return "", wrapDBError(err)
+	}
+	return columns, nil
+}
+
+// dropForeignKeys удаляет внешние ключи отсоединённой таблицы table.
+func dropForeignKeys(ctx context.Context, tx pgx.Tx, table string) error {
+	rows, err := tx.Query(ctx, `
+		SELECT conname FROM pg_constraint
+		WHERE conrelid = to_regclass($1) AND contype = 'f';
+	`, table)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	constraints, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	for _, name := range constraints {
+		_, err = tx.Exec(ctx, fmt.Sprintf(
+			`ALTER TABLE %s DROP CONSTRAINT %s;`,
+			pgx.Identifier{table}.Sanitize(),
+			pgx.Identifier{name}.Sanitize(),
+		))
+		if err != nil {
+			return wrapDBError(err)
+		}
+	}
+
+	return nil
+}
+
+func (r *partitionRepository) withLock(ctx context.Context, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1));`, partitionLockName)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/partition"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPartitionRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewPartitionRepository(db)
+
+	month := time.Date(2001, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.CreateMonthPartitions(t.Context(), month))
+	// повторное создание ничего не ломает
+	require.NoError(t, repo.CreateMonthPartitions(t.Context(), month))
+
+	months, err := repo.ListMonthPartitions(t.Context())
+	require.NoError(t, err)
+	assert.Contains(t, months, month)
+
+	eo := &models.ExtendedOrder{
+		Order: models.Order{
+			OrderUID:        "partition test",
+			TrackNumber:     "2634",
+			Entry:           "142",
+			Locale:          "ru",
+			CustomerID:      "test",
+			DeliveryService: "test",
+			ShardKey:        "test",
+			SMID:            2,
+			DateCreated:     time.Date(2001, time.March, 15, 0, 0, 0, 0, time.UTC),
+			OOFShard:        "test",
+		},
+		Payment: models.Payment{
+			Transaction:  "test",
+			Currency:     "RUB",
+			Provider:     "alfa",
+			Amount:       money.FromInt(1000),
+			PaymentDate:  90872534,
+			Bank:         "tbank",
+			DeliveryCost: money.FromInt(325),
+			GoodsTotal:   money.FromInt(32),
+		},
+		Delivery: models.Delivery{
+			Name:    "test",
+			Phone:   "+7926",
+			Zip:     "1542",
+			City:    "Moscow",
+			Address: "Lenina",
+			Region:  "Moscow",
+			Email:   "test@emal.com",
+		},
+		Items: []*models.Item{
+			{
+				ChrtID:      324,
+				TrackNumber: "test",
+				Price:       money.FromInt(200),
+				RID:         "test",
+				Name:        "test",
+				Sale:        20,
+				Size:        "test",
+				TotalPrice:  money.FromInt(200),
+				NMID:        12,
+				Brand:       "test",
+				Status:      1,
+			},
+		},
+	}
+
+	require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+
+	var partition string
+	err = db.QueryRow(t.Context(),
+		`SELECT tableoid::regclass::text FROM orders WHERE id = $1`, eo.Order.ID,
+	).Scan(&partition)
+	require.NoError(t, err)
+	assert.Equal(t, "orders_p2001_03", partition)
+
+	got, err := eoRepo.GetExtendedOrder(t.Context(), eo.Order.ID)
+	require.NoError(t, err)
+	assert.Len(t, got.Items, 1)
+
+	require.NoError(t, repo.DetachMonthPartitions(t.Context(), month))
+
+	_, err = eoRepo.GetExtendedOrder(t.Context(), eo.Order.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	months, err = repo.ListMonthPartitions(t.Context())
+	require.NoError(t, err)
+	assert.NotContains(t, months, month)
+
+	// order_uid освободился вместе с отсоединённой секцией
+	var keys int
+	err = db.QueryRow(t.Context(), `SELECT count(*) FROM order_keys WHERE order_uid = $1`, eo.Order.OrderUID).Scan(&keys)
+	require.NoError(t, err)
+	assert.Zero(t, keys)
+
+	// доставка и оплата перенесены рядом с отсоединённой секцией
+	for _, tc := range []struct {
+		table, archive string
+		id             int64
+	}{
+		{"delivery", "delivery_p2001_03", eo.Delivery.ID},
+		{"payment", "payment_p2001_03", eo.Payment.ID},
+	} {
+		var live, archived int
+		err = db.QueryRow(t.Context(), `SELECT count(*) FROM `+tc.table+` WHERE id = $1`, tc.id).Scan(&live)
+		require.NoError(t, err)
+		assert.Zero(t, live, tc.table)
+		err = db.QueryRow(t.Context(), `SELECT count(*) FROM `+tc.archive+` WHERE id = $1`, tc.id).Scan(&archived)
+		require.NoError(t, err)
+		assert.Equal(t, 1, archived, tc.archive)
+	}
+
+	_, err = db.Exec(t.Context(), `DROP TABLE items_p2001_03, orders_p2001_03, delivery_p2001_03, payment_p2001_03`)
+	require.NoError(t, err)
+}
+
+// Заказ за месяц без секции попадает в orders_default. Когда обслуживание
+// доходит до этого месяца, заказ переносится в новую секцию.
+func TestPartitionRepository_DefaultRows(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewPartitionRepository(db)
+
+	const ahead = 36
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, ahead, 0)
+
+	before, err := repo.ListMonthPartitions(t.Context())
+	require.NoError(t, err)
+	require.NotContains(t, before, month)
+
+	eo := analyticsOrder("partition default", "RUB", "alpha", month.AddDate(0, 0, 14), analyticsItem("A", 1, 0, 300))
+	require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid = 'partition default'`)
+		require.NoError(t, err)
+
+		after, err := repo.ListMonthPartitions(t.Context())
+		require.NoError(t, err)
+		for _, m := range after {
+			if !slices.Contains(before, m) {
+				_, err = db.Exec(t.Context(), fmt.Sprintf(`DROP TABLE items_p%04d_%02d, orders_p%04d_%02d`,
+					m.Year(), m.Month(), m.Year(), m.Month()))
+				require.NoError(t, err)
+			}
+		}
+	})
+
+	partitionOf := func() string {
+		var name string
+		err := db.QueryRow(t.Context(),
+			`SELECT tableoid::regclass::text FROM orders WHERE id = $1`, eo.Order.ID,
+		).Scan(&name)
+		require.NoError(t, err)
+		return name
+	}
+	assert.Equal(t, "orders_default", partitionOf())
+
+	maintainer := partition.New(repo, partition.Config{Interval: time.Hour, Ahead: ahead}, zap.NewNop())
+	require.NoError(t, maintainer.MaintainOnce(t.Context()))
+	// повторный запуск не падает на уже созданных секциях
+	require.NoError(t, maintainer.MaintainOnce(t.Context()))
+
+	assert.Equal(t, monthPartitionName(month), partitionOf())
+
+	got, err := eoRepo.GetExtendedOrderByUID(t.Context(), "partition default")
+	require.NoError(t, err)
+	assert.Equal(t, eo.Order.ID, got.Order.ID)
+	assert.Len(t, got.Items, 1)
+}
+
+func monthPartitionName(month time.Time) string {
+	return fmt.Sprintf("orders_p%04d_%02d", month.Year(), month.Month())
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"test-task/internal/models"
+	"test-task/internal/money"
 	"test-task/internal/repository"
 
 	"github.com/stretchr/testify/assert"
@@ -20,12 +21,12 @@ func TestPaymentRepository_CRUD(t *testing.T) {
 		RequestID:    "",
 		Currency:     "RUB",
 		Provider:     "alfa",
-		Amount:       1000,
+		Amount:       money.FromInt(1000),
 		PaymentDate:  90872534,
 		Bank:         "tbank",
-		DeliveryCost: 325,
-		GoodsTotal:   32,
-		CustomFee:    0,
+		DeliveryCost: money.FromInt(325),
+		GoodsTotal:   money.FromInt(32),
+		CustomFee:    money.FromInt(0),
 	}
 
 	tx, err := db.Begin(t.Context())
@@ -10,37 +10,74 @@ const (
 		o.shardkey, o.sm_id, o.date_created, o.oof_shard,
 
 		d.id, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+		d.enc_key_id, d.enc_dek,
 
 		p.id, p.transaction, p.request_id,
 		p.currency, p.provider, p.amount,
 		p.payment_dt, p.bank, p.delivery_cost,
-		p.goods_total, p.custom_fee
+		p.goods_total, p.custom_fee,
+		(SELECT COALESCE(sum(rf.amount), 0) FROM refunds AS rf WHERE rf.order_id = o.id),
+
+		(
+			SELECT jsonb_build_object('score', f.score, 'reasons', f.reasons, 'flagged_at', f.created_at)
+			FROM fraud_flags AS f WHERE f.order_id = o.id
+		)
 
 	FROM orders AS o
 	INNER JOIN delivery AS d ON o.delivery_id = d.id
 	INNER JOIN payment AS p ON o.payment_id = p.id
+	WHERE o.deleted_at IS NULL
+`
+
+	selectOrdersQuery = `
+	SELECT
+		id, order_uid, track_number, entry,
+		delivery_id, payment_id, locale, internal_signature,
+		customer_id, delivery_service, shardkey, sm_id,
+		date_created, oof_shard
+	FROM orders
+	WHERE deleted_at IS NULL
 `
 
+	// orders секционирована, поэтому уникальность order_uid проверяется по order_keys
 	insertOrderQuery = `
-	INSERT INTO orders (
+	WITH existing AS (
+		SELECT id FROM order_keys WHERE order_uid = $1
+	), inserted AS (
+		INSERT INTO orders (
 			order_uid, track_number, entry,
 			delivery_id, payment_id, locale,
 			internal_signature, customer_id,
 			delivery_service, shardkey,	sm_id,
 			date_created, oof_shard
-		) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		ON CONFLICT (order_uid)
-		DO UPDATE SET order_uid = EXCLUDED.order_uid
-		RETURNING id;
+		)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		WHERE NOT EXISTS (SELECT 1 FROM existing)
+		RETURNING id
+	)
+	SELECT id FROM inserted
+	UNION ALL
+	SELECT id FROM existing;
 	`
 
+	// дата заказа по id, по ней отсекаются секции orders и items
+	orderDateQuery = `(SELECT date_created FROM order_keys WHERE id = $1)`
+
 	insertDeliveryQuery = `
 	INSERT INTO delivery (
-			name, phone, zip, city, address, region, email
-		) VALUES ( $1, $2, $3, $4, $5, $6, $7)
+			name, phone, zip, city, address, region, email,
+			enc_key_id, enc_dek, name_bidx, phone_bidx, email_bidx
+		) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id;
 	`
 
+	updateDeliveryQuery = `
+	UPDATE delivery SET
+			name = $1, phone = $2, zip = $3, city = $4, address = $5, region = $6, email = $7,
+			enc_key_id = $8, enc_dek = $9, name_bidx = $10, phone_bidx = $11, email_bidx = $12
+		WHERE id = $13;
+	`
+
 	insertPaymentQuery = `
 	INSERT INTO payment (
 			transaction,
@@ -60,6 +97,7 @@ const (
 	insertItemQuery = `
 	INSERT INTO items (
 		order_id,
+		order_date_created,
 		chrt_id,
 		track_number,
 		price,
@@ -71,11 +109,14 @@ const (
 		nm_id,
 		brand,
 		status
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	) VALUES (
+		$1, (SELECT date_created FROM order_keys WHERE id = $1),
+		$2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+	)
 	RETURNING id;
 	`
 
-	selectItemsWitoutWhereQuery = `
+	selectItemsQuery = `
 	SELECT
 		id,
 		order_id,
@@ -91,5 +132,71 @@ const (
 		brand,
 		status
 	FROM items
+	WHERE deleted_at IS NULL
+	`
+
+	insertAuditQuery = `
+	INSERT INTO order_audit (
+		order_id,
+		action,
+		actor,
+		diff
+	) VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at;
+	`
+
+	restoreDeliveryQuery = `
+	INSERT INTO delivery (
+			name, phone, zip, city, address, region, email,
+			enc_key_id, enc_dek, name_bidx, phone_bidx, email_bidx, id
+		) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);
+	`
+
+	restorePaymentQuery = `
+	INSERT INTO payment (
+			id,
+			transaction,
+			request_id,
+			currency,
+			provider,
+			amount,
+			payment_dt,
+			bank,
+			delivery_cost,
+			goods_total,
+			custom_fee
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11);
+	`
+
+	restoreOrderQuery = `
+	INSERT INTO orders (
+			id, order_uid, track_number, entry,
+			delivery_id, payment_id, locale,
+			internal_signature, customer_id,
+			delivery_service, shardkey, sm_id,
+			date_created, oof_shard
+		) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+	`
+
+	restoreItemQuery = `
+	INSERT INTO items (
+		id,
+		order_id,
+		order_date_created,
+		chrt_id,
+		track_number,
+		price,
+		rid,
+		name,
+		sale,
+		size,
+		total_price,
+		nm_id,
+		brand,
+		status
+	) VALUES (
+		$1, $2, (SELECT date_created FROM order_keys WHERE id = $2),
+		$3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+	);
 	`
 )
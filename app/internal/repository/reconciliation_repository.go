@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reconcileQuery сравнивает загруженный во временную таблицу settlement_rows
+// реестр с оплатами провайдера, записывает расхождения и возвращает число
+// совпавших транзакций. Оплаты удалённых заказов не сверяются.
+//
+// $1 — id сверки, $2 — провайдер, $3, $4 — payment_dt дня реестра [с, по).
+const reconcileQuery = `
+	WITH file AS (
+		SELECT transaction, count(*) AS n, min(line) AS line,
+			min(amount) AS amount, min(currency) AS currency
+		FROM settlement_rows
+		GROUP BY transaction
+	),
+	pay AS (
+		SELECT p.transaction, count(*) AS n,
+			min(p.amount) AS amount, min(p.currency) AS currency
+		FROM payment AS p
+		WHERE p.provider = $2 AND p.transaction IN (SELECT transaction FROM file)
+			AND EXISTS (SELECT 1 FROM orders AS o WHERE o.payment_id = p.id AND o.deleted_at IS NULL)
+		GROUP BY p.transaction
+	),
+	classified AS (
+		SELECT
+			f.transaction, f.line, f.amount, f.currency,
+			p.amount AS expected_amount, p.currency AS expected_currency,
+			CASE
+				WHEN f.n > 1 OR p.n > 1 THEN 'duplicate'
+				WHEN p.transaction IS NULL THEN 'missing_payment'
+				WHEN p.currency <> f.currency THEN 'currency_mismatch'
+				WHEN p.amount <> f.amount THEN 'amount_mismatch'
+			END AS status
+		FROM file AS f
+		LEFT JOIN pay AS p ON p.transaction = f.transaction
+	),
+	inserted AS (
+		INSERT INTO reconciliation_results (
+			run_id, transaction, status,
+			expected_amount, settled_amount, expected_currency, settled_currency, line
+		)
+		SELECT $1, transaction, status, expected_amount, amount, expected_currency, currency, line
+		FROM classified
+		WHERE status IS NOT NULL
+		UNION ALL
+		SELECT $1, p.transaction, 'missing_settlement', p.amount, NULL, p.currency, NULL, NULL
+		FROM payment AS p
+		WHERE p.provider = $2 AND p.payment_dt >= $3 AND p.payment_dt < $4
+			AND EXISTS (SELECT 1 FROM orders AS o WHERE o.payment_id = p.id AND o.deleted_at IS NULL)
+			AND NOT EXISTS (SELECT 1 FROM file AS f WHERE f.transaction = p.transaction)
+		RETURNING 1
+	)
+	SELECT count(*) FROM classified WHERE status IS NULL;
+`
+
+const (
+	// selectDiscrepanciesQuery — число расхождений сверки $1 по видам.
+	selectDiscrepanciesQuery = `
+	SELECT COALESCE(jsonb_object_agg(s.status, s.n), '{}'::JSONB)
+	FROM (
+		SELECT status, count(*) AS n
+		FROM reconciliation_results
+		WHERE run_id = $1
+		GROUP BY status
+	) AS s
+`
+
+	selectReconciliationRunsQuery = `
+	SELECT id, provider, settlement_date, file_name, rows, matched, created_at
+	FROM reconciliation_runs
+`
+)
+
+// SettlementSource отдаёт строки реестра по одной, не держа файл в памяти.
+type SettlementSource interface {
+	Next() bool
+	Row() *models.SettlementRow
+	Err() error
+}
+
+type ReconciliationRepository interface {
+	// Reconcile загружает реестр src и сверяет его с оплатами run.Provider.
+	// Заполняет у run id, число строк, совпадений и расхождений.
+	Reconcile(ctx context.Context, run *models.ReconciliationRun, src SettlementSource) error
+	// ListRuns возвращает сверки, новые первыми.
+	ListRuns(ctx context.Context, limit, offset int) ([]*models.ReconciliationRun, error)
+	GetRun(ctx context.Context, id int64) (*models.ReconciliationRun, error)
+	// ListResults возвращает расхождения сверки, при непустом status — только этого вида.
+	ListResults(ctx context.Context, runID int64, status string, limit, offset int) ([]*models.ReconciliationResult, error)
+}
+
+type reconciliationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReconciliationRepository(db *pgxpool.Pool) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+// settlementCopySource передаёт строки реестра в CopyFrom.
+type settlementCopySource struct {
+	src SettlementSource
+}
+
+func (s settlementCopySource) Next() bool { return s.src.Next() }
+
+func (s settlementCopySource) Values() ([]any, error) {
+	row := s.src.Row()
+	return []any{row.Line, row.Transaction, row.Amount, row.Currency}, nil
+}
+
+func (s settlementCopySource) Err() error { return s.src.Err() }
+
+func (r *reconciliationRepository) Reconcile(ctx context.Context, run *models.ReconciliationRun, src SettlementSource) (err error) {
+	if run == nil || src == nil {
+		return ErrNilValue
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, `
+		CREATE TEMP TABLE settlement_rows (
+			line BIGINT NOT NULL,
+			transaction TEXT NOT NULL,
+			amount NUMERIC(18,4) NOT NULL,
+			currency VARCHAR(3) NOT NULL
+		) ON COMMIT DROP;
+	`)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	run.Rows, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"settlement_rows"},
+		[]string{"line", "transaction", "amount", "currency"},
+		settlementCopySource{src: src},
+	)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	for _, query := range []string{
+		`CREATE INDEX ON settlement_rows (transaction);`,
+		`ANALYZE settlement_rows;`,
+	} {
+		if _, err = tx.Exec(ctx, query); err != nil {
+			return wrapDBError(err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO reconciliation_runs (provider, settlement_date, file_name, rows)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`, run.Provider, run.SettlementDate, run.FileName, run.Rows).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	from := run.SettlementDate.Unix()
+	to := run.SettlementDate.AddDate(0, 0, 1).Unix()
+	err = tx.QueryRow(ctx, reconcileQuery, run.ID, run.Provider, from, to).Scan(&run.Matched)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE reconciliation_runs SET matched = $2 WHERE id = $1;`, run.ID, run.Matched)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = tx.QueryRow(ctx, selectDiscrepanciesQuery, run.ID).Scan(&run.Discrepancies)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+func (r *reconciliationRepository) ListRuns(ctx context.Context, limit, offset int) ([]*models.ReconciliationRun, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	rows, err := r.db.Query(ctx, selectReconciliationRunsQuery+`
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2;
+	`, limit, offset)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	runs, err := pgx.CollectRows(rows, scanReconciliationRun)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	if len(runs) == 0 {
+		return runs, nil
+	}
+
+	// расхождения по всем сверкам страницы одним пакетом
+	batch := &pgx.Batch{}
+	for _, run := range runs {
+		batch.Queue(selectDiscrepanciesQuery, run.ID)
+	}
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for _, run := range runs {
+		if err := br.QueryRow().Scan(&run.Discrepancies); err != nil {
+			return nil, wrapDBError(err)
+		}
+	}
+
+	return runs, nil
+}
+
+func (r *reconciliationRepository) GetRun(ctx context.Context, id int64) (*models.ReconciliationRun, error) {
+	batch := &pgx.Batch{}
+	batch.Queue(selectReconciliationRunsQuery+`WHERE id = $1;`, id)
+	batch.Queue(selectDiscrepanciesQuery, id)
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	run, err := pgx.CollectExactlyOneRow(rows, scanReconciliationRun)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	if err := br.QueryRow().Scan(&run.Discrepancies); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return run, nil
+}
+
+func (r *reconciliationRepository) ListResults(
+	ctx context.Context,
+	runID int64,
+	status string,
+	limit, offset int,
+) ([]*models.ReconciliationResult, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			id, run_id, transaction, status,
+			expected_amount, settled_amount, expected_currency, settled_currency, line
+		FROM reconciliation_results
+		WHERE run_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY status, id
+		LIMIT $3 OFFSET $4;
+	`, runID, status, limit, offset)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.ReconciliationResult, error) {
+		res := new(models.ReconciliationResult)
+		err := row.Scan(
+			&res.ID, &res.RunID, &res.Transaction, &res.Status,
+			&res.ExpectedAmount, &res.SettledAmount, &res.ExpectedCurrency, &res.SettledCurrency, &res.Line,
+		)
+		return res, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return results, nil
+}
+
+func scanReconciliationRun(row pgx.CollectableRow) (*models.ReconciliationRun, error) {
+	run := new(models.ReconciliationRun)
+	err := row.Scan(
+		&run.ID, &run.Provider, &run.SettlementDate, &run.FileName,
+		&run.Rows, &run.Matched, &run.CreatedAt,
+	)
+	return run, err
+}
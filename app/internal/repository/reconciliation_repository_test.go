@@ -0,0 +1,111 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/reconcile"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciliationRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewReconciliationRepository(db)
+
+	date := time.Date(2003, time.October, 5, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics recon%'`)
+		require.NoError(t, err)
+		require.NoError(t, repository.NewRollupRepository(db).Rebuild(t.Context(), date, date.AddDate(0, 0, 1)))
+	})
+
+	// у всех заказов оплата 400 RUB
+	for _, uid := range []string{"matched", "amount", "currency", "duplicate", "missing", "deleted", "deleted settled"} {
+		eo := analyticsOrder("analytics recon "+uid, "RUB", "alpha", date.Add(time.Hour),
+			analyticsItem("A", 1, 0, 300))
+		require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+		// оплаты удалённых заказов не ждут реестра и не сверяются с ним
+		if strings.HasPrefix(uid, "deleted") {
+			require.NoError(t, eoRepo.DeleteExtendedOrder(t.Context(), eo.Order.ID))
+		}
+	}
+
+	src, err := reconcile.NewReader(strings.NewReader(`transaction,amount,currency
+analytics recon matched,400,RUB
+analytics recon amount,399.99,RUB
+analytics recon currency,400,USD
+analytics recon duplicate,400,RUB
+analytics recon duplicate,400,RUB
+analytics recon unknown,1,RUB
+analytics recon deleted settled,400,RUB
+`))
+	require.NoError(t, err)
+
+	run := &models.ReconciliationRun{Provider: "wbpay", SettlementDate: date, FileName: "wbpay.csv"}
+	require.NoError(t, repo.Reconcile(t.Context(), run, src))
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM reconciliation_runs WHERE id = $1`, run.ID)
+		require.NoError(t, err)
+	})
+
+	want := map[string]int64{
+		models.ReconAmountMismatch:    1,
+		models.ReconCurrencyMismatch:  1,
+		models.ReconDuplicate:         1,
+		models.ReconMissingPayment:    2,
+		models.ReconMissingSettlement: 1,
+	}
+
+	t.Run("Reconcile", func(t *testing.T) {
+		assert.NotZero(t, run.ID)
+		assert.Equal(t, int64(7), run.Rows)
+		assert.Equal(t, int64(1), run.Matched)
+		assert.Equal(t, want, run.Discrepancies)
+	})
+
+	t.Run("Get Run", func(t *testing.T) {
+		got, err := repo.GetRun(t.Context(), run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.Discrepancies)
+		assert.Equal(t, "wbpay.csv", got.FileName)
+
+		runs, err := repo.ListRuns(t.Context(), 1, 0)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, run.ID, runs[0].ID)
+
+		_, err = repo.GetRun(t.Context(), -1)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("List Results", func(t *testing.T) {
+		results, err := repo.ListResults(t.Context(), run.ID, "", 100, 0)
+		require.NoError(t, err)
+		assert.Len(t, results, 5)
+
+		results, err = repo.ListResults(t.Context(), run.ID, models.ReconAmountMismatch, 100, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		res := results[0]
+		assert.Equal(t, "analytics recon amount", res.Transaction)
+		assert.Equal(t, money.FromInt(400), *res.ExpectedAmount)
+		assert.Equal(t, money.MustParse("399.99"), *res.SettledAmount)
+		assert.Equal(t, int64(3), *res.Line)
+
+		results, err = repo.ListResults(t.Context(), run.ID, models.ReconMissingSettlement, 100, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "analytics recon missing", results[0].Transaction)
+		assert.Nil(t, results[0].SettledAmount)
+		assert.Nil(t, results[0].Line)
+	})
+}
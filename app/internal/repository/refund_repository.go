@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"test-task/internal/audit"
+	"test-task/internal/currency"
+	"test-task/internal/models"
+	"test-task/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRefundExceedsPayment = errors.New("refunds exceed payment amount")
+	ErrInvalidRefund        = errors.New("invalid refund amount")
+	ErrInvalidReturn        = errors.New("items do not belong to order")
+)
+
+type RefundRepository interface {
+	// CreateRefund оформляет возврат денег по заказу refund.OrderID.
+	// Сумма всех возвратов не может превышать сумму оплаты.
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+	// CreateReturn оформляет возврат позиций заказа orderID
+	// и, если запрошено, возврат денег за них.
+	CreateReturn(ctx context.Context, orderID int64, req *models.ReturnRequest) (*models.OrderReturn, error)
+	// GetByOrderID возвращает возвраты по заказу.
+	GetByOrderID(ctx context.Context, orderID int64) (*models.OrderRefunds, error)
+}
+
+type refundRepository struct {
+	db    *pgxpool.Pool
+	audit AuditRepository
+}
+
+func NewRefundRepository(db *pgxpool.Pool) RefundRepository {
+	return &refundRepository{
+		db:    db,
+		audit: NewAuditRepository(db),
+	}
+}
+
+// paymentState — оплата заказа и сумма уже оформленных возвратов.
+type paymentState struct {
+	amount   money.Amount
+	refunded money.Amount
+	currency string
+}
+
+// lockPayment блокирует оплату заказа до конца транзакции tx,
+// чтобы параллельные возвраты не превысили её сумму.
+func lockPayment(ctx context.Context, tx pgx.Tx, orderID int64) (*paymentState, error) {
+	state := new(paymentState)
+
+	err := tx.QueryRow(ctx, `
+		SELECT p.amount, p.currency
+		FROM orders AS o
+		INNER JOIN payment AS p ON p.id = o.payment_id
+		WHERE o.id = $1 AND o.date_created = `+orderDateQuery+` AND o.deleted_at IS NULL
+		FOR UPDATE OF p;
+	`, orderID).Scan(&state.amount, &state.currency)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(sum(amount), 0) FROM refunds WHERE order_id = $1;
+	`, orderID).Scan(&state.refunded)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return state, nil
+}
+
+// checkRefund проверяет, что amount можно вернуть по оплате state.
+func (state *paymentState) checkRefund(amount money.Amount) error {
+	if amount.Sign() <= 0 {
+		return ErrInvalidRefund
+	}
+	if cur, ok := currency.Lookup(state.currency); ok && amount.Places() > cur.MinorUnits {
+		return ErrInvalidRefund
+	}
+	if state.refunded.Add(amount).Cmp(state.amount) > 0 {
+		return ErrRefundExceedsPayment
+	}
+	return nil
+}
+
+func insertRefund(ctx context.Context, tx pgx.Tx, refund *models.Refund) error {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO refunds (order_id, amount, reason)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;
+	`, refund.OrderID, refund.Amount, refund.Reason).Scan(&refund.ID, &refund.CreatedAt)
+	return wrapDBError(err)
+}
+
+func (r *refundRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	if refund == nil {
+		return ErrNilValue
+	}
+
+	return r.withOrder(ctx, refund.OrderID, models.AuditActionRefund, func(tx pgx.Tx, state *paymentState) (any, error) {
+		if err := state.checkRefund(refund.Amount); err != nil {
+			return nil, err
+		}
+		if err := insertRefund(ctx, tx, refund); err != nil {
+			return nil, err
+		}
+		return refund, nil
+	})
+}
+
+func (r *refundRepository) CreateReturn(ctx context.Context, orderID int64, req *models.ReturnRequest) (*models.OrderReturn, error) {
+	if req == nil {
+		return nil, ErrNilValue
+	}
+
+	result := &models.OrderReturn{Returns: make([]*models.Return, 0, len(req.ItemIDs))}
+
+	err := r.withOrder(ctx, orderID, models.AuditActionReturn, func(tx pgx.Tx, state *paymentState) (any, error) {
+		rows, err := tx.Query(ctx, `
+			SELECT i.id, i.total_price, EXISTS (SELECT 1 FROM returns AS rt WHERE rt.item_id = i.id)
+			FROM items AS i
+			WHERE i.order_id = $1 AND i.order_date_created = `+orderDateQuery+`
+				AND i.deleted_at IS NULL AND i.id = ANY($2);
+		`, orderID, req.ItemIDs)
+		if err != nil {
+			return nil, wrapDBError(err)
+		}
+
+		var total money.Amount
+		found := make(map[int64]bool, len(req.ItemIDs))
+		for rows.Next() {
+			var (
+				id       int64
+				price    money.Amount
+				returned bool
+			)
+			if err := rows.Scan(&id, &price, &returned); err != nil {
+				rows.Close()
+				return nil, wrapDBError(err)
+			}
+			if returned {
+				rows.Close()
+				return nil, ErrDuplicate
+			}
+			found[id] = true
+			total = total.Add(price)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, wrapDBError(err)
+		}
+
+		for _, id := range req.ItemIDs {
+			if _, ok := found[id]; !ok {
+				return nil, ErrInvalidReturn
+			}
+		}
+
+		var refundID *int64
+		if req.Refund {
+			if err := state.checkRefund(total); err != nil {
+				return nil, err
+			}
+			result.Refund = &models.Refund{OrderID: orderID, Amount: total, Reason: req.Reason}
+			if err := insertRefund(ctx, tx, result.Refund); err != nil {
+				return nil, err
+			}
+			refundID = &result.Refund.ID
+		}
+
+		for _, id := range req.ItemIDs {
+			if !found[id] {
+				continue
+			}
+			// повторы id в запросе возвращаются один раз
+			found[id] = false
+
+			ret := &models.Return{OrderID: orderID, ItemID: id, RefundID: refundID, Reason: req.Reason}
+			err := tx.QueryRow(ctx, `
+				INSERT INTO returns (order_id, item_id, refund_id, reason)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, created_at;
+			`, ret.OrderID, ret.ItemID, ret.RefundID, ret.Reason).Scan(&ret.ID, &ret.CreatedAt)
+			if err != nil {
+				return nil, wrapDBError(err)
+			}
+			result.Returns = append(result.Returns, ret)
+		}
+
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// withOrder выполняет fn в транзакции с заблокированной оплатой заказа,
+// пересчитывает дневные агрегаты и пишет в аудит то, что вернула fn.
+func (r *refundRepository) withOrder(
+	ctx context.Context,
+	orderID int64,
+	action string,
+	fn func(tx pgx.Tx, state *paymentState) (any, error),
+) (err error) {
+	if orderID <= 0 {
+		return ErrInvalidID
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	state, err := lockPayment(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	err = applyRollups(ctx, tx, []int64{orderID}, -1)
+	if err != nil {
+		return err
+	}
+
+	after, err := fn(tx, state)
+	if err != nil {
+		return err
+	}
+
+	err = applyRollups(ctx, tx, []int64{orderID}, 1)
+	if err != nil {
+		return err
+	}
+
+	diff, err := audit.Diff(nil, after)
+	if err != nil {
+		return err
+	}
+	err = r.audit.Create(ctx, tx, &models.AuditEntry{
+		OrderID: orderID,
+		Action:  action,
+		Actor:   audit.ActorFromContext(ctx),
+		Diff:    diff,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
+
+func (r *refundRepository) GetByOrderID(ctx context.Context, orderID int64) (*models.OrderRefunds, error) {
+	refunds := &models.OrderRefunds{}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT p.amount
+		FROM orders AS o
+		INNER JOIN payment AS p ON p.id = o.payment_id
+		WHERE o.id = $1 AND o.date_created = `+orderDateQuery+` AND o.deleted_at IS NULL;
+	`, orderID)
+	batch.Queue(`
+		SELECT id, order_id, amount, reason, created_at
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY created_at, id;
+	`, orderID)
+	batch.Queue(`
+		SELECT id, order_id, item_id, refund_id, reason, created_at
+		FROM returns
+		WHERE order_id = $1
+		ORDER BY created_at, id;
+	`, orderID)
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	if err := br.QueryRow().Scan(&refunds.Amount); err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	refunds.Refunds, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Refund, error) {
+		rf := new(models.Refund)
+		err := row.Scan(&rf.ID, &rf.OrderID, &rf.Amount, &rf.Reason, &rf.CreatedAt)
+		return rf, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	rows, err = br.Query()
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	refunds.Returns, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Return, error) {
+		rt := new(models.Return)
+		err := row.Scan(&rt.ID, &rt.OrderID, &rt.ItemID, &rt.RefundID, &rt.Reason, &rt.CreatedAt)
+		return rt, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	for _, rf := range refunds.Refunds {
+		refunds.Refunded = refunds.Refunded.Add(rf.Amount)
+	}
+	refunds.NetAmount = refunds.Amount.Sub(refunds.Refunded)
+
+	return refunds, nil
+}
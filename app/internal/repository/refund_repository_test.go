@@ -0,0 +1,111 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefundRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	analytics := repository.NewAnalyticsRepository(db)
+	repo := repository.NewRefundRepository(db)
+
+	date := time.Date(2003, time.September, 1, 12, 0, 0, 0, time.UTC)
+	filter := models.AnalyticsFilter{
+		From:     date,
+		To:       date.AddDate(0, 0, 1),
+		Currency: "RUB",
+		Base:     "RUB",
+	}
+
+	// 300 + 600 + 100 доставка
+	eo := analyticsOrder("analytics refund", "RUB", "alpha", date,
+		analyticsItem("A", 1, 0, 300), analyticsItem("B", 2, 0, 600))
+	require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics refund%'`)
+		require.NoError(t, err)
+		_, err = db.Exec(t.Context(), `DELETE FROM returns WHERE order_id = $1`, eo.Order.ID)
+		require.NoError(t, err)
+		_, err = db.Exec(t.Context(), `DELETE FROM refunds WHERE order_id = $1`, eo.Order.ID)
+		require.NoError(t, err)
+		require.NoError(t, repository.NewRollupRepository(db).Rebuild(t.Context(), filter.From, filter.To))
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		refund := &models.Refund{OrderID: eo.Order.ID, Amount: money.FromInt(100), Reason: "delivery"}
+		require.NoError(t, repo.CreateRefund(t.Context(), refund))
+		assert.NotZero(t, refund.ID)
+
+		got, err := eoRepo.GetExtendedOrder(t.Context(), eo.Order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, money.FromInt(100), got.Payment.Refunded)
+		assert.Equal(t, money.FromInt(900), got.Payment.NetAmount())
+
+		stats, err := analytics.Basket(t.Context(), filter)
+		require.NoError(t, err)
+		assert.Equal(t, money.FromInt(900), stats.AvgAmount)
+	})
+
+	t.Run("Refund exceeds payment", func(t *testing.T) {
+		err := repo.CreateRefund(t.Context(), &models.Refund{OrderID: eo.Order.ID, Amount: money.FromInt(901)})
+		assert.ErrorIs(t, err, repository.ErrRefundExceedsPayment)
+
+		err = repo.CreateRefund(t.Context(), &models.Refund{OrderID: eo.Order.ID, Amount: money.MustParse("0.001")})
+		assert.ErrorIs(t, err, repository.ErrInvalidRefund)
+
+		err = repo.CreateRefund(t.Context(), &models.Refund{OrderID: -1, Amount: money.FromInt(1)})
+		assert.ErrorIs(t, err, repository.ErrInvalidID)
+	})
+
+	t.Run("Return", func(t *testing.T) {
+		item := eo.Items[1]
+		ret, err := repo.CreateReturn(t.Context(), eo.Order.ID, &models.ReturnRequest{
+			ItemIDs: []int64{item.ID},
+			Refund:  true,
+		})
+		require.NoError(t, err)
+		require.Len(t, ret.Returns, 1)
+		require.NotNil(t, ret.Refund)
+		assert.Equal(t, money.FromInt(600), ret.Refund.Amount)
+		assert.Equal(t, ret.Refund.ID, *ret.Returns[0].RefundID)
+
+		_, err = repo.CreateReturn(t.Context(), eo.Order.ID, &models.ReturnRequest{ItemIDs: []int64{item.ID}})
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		_, err = repo.CreateReturn(t.Context(), eo.Order.ID, &models.ReturnRequest{ItemIDs: []int64{-5}})
+		assert.ErrorIs(t, err, repository.ErrInvalidReturn)
+
+		ranks, err := analytics.TopBrands(t.Context(), filter, 10)
+		require.NoError(t, err)
+		require.Len(t, ranks, 1)
+		assert.Equal(t, "A", ranks[0].Key)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		refunds, err := repo.GetByOrderID(t.Context(), eo.Order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, money.FromInt(1000), refunds.Amount)
+		assert.Equal(t, money.FromInt(700), refunds.Refunded)
+		assert.Equal(t, money.FromInt(300), refunds.NetAmount)
+		assert.Len(t, refunds.Refunds, 2)
+		assert.Len(t, refunds.Returns, 1)
+	})
+
+	t.Run("Update below refunds", func(t *testing.T) {
+		updated := analyticsOrder("analytics refund", "RUB", "alpha", date, analyticsItem("A", 1, 0, 100))
+		updated.Order.ID = eo.Order.ID
+		err := eoRepo.UpdateExtendedOrder(t.Context(), updated)
+		assert.ErrorIs(t, err, repository.ErrRefundExceedsPayment)
+	})
+}
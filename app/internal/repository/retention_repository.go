@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// purgeLockName — имя advisory-блокировки, которая не даёт нескольким
+// репликам чистить одни и те же заказы одновременно.
+const purgeLockName = "orders_purge"
+
+type RetentionRepository interface {
+	// PurgeBatch окончательно удаляет до limit заказов, созданных раньше
+	// createdBefore или мягко удалённых раньше deletedBefore, вместе с
+	// позициями, доставкой и оплатой. Возвращает id удалённых заказов.
+	PurgeBatch(ctx context.Context, createdBefore, deletedBefore time.Time, limit int) ([]int64, error)
+}
+
+type retentionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRetentionRepository(db *pgxpool.Pool) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+func (r *retentionRepository) PurgeBatch(
+	ctx context.Context,
+	createdBefore, deletedBefore time.Time,
+	limit int,
+) ([]int64, error) {
+	if limit <= 0 {
+		return nil, ErrNilValue
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	var locked bool
+	err = tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext($1));`, purgeLockName).Scan(&locked)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	if !locked {
+		err = ErrLockNotAcquired
+		return nil, err
+	}
+
+	query := `
+		SELECT id FROM orders
+		WHERE date_created < $1 OR deleted_at < $2
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED;
+	`
+
+	rows, err := tx.Query(ctx, query, createdBefore, deletedBefore, limit)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	var ids []int64
+	ids, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	err = hardDeleteOrders(ctx, tx, ids, models.AuditActionPurge)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return ids, nil
+}
+
+// hardDeleteOrders окончательно удаляет заказы ids вместе с позициями,
+// доставкой и оплатой и отмечает это в order_audit.
+func hardDeleteOrders(ctx context.Context, tx pgx.Tx, ids []int64, action string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// мягко удалённые заказы в агрегатах уже не учтены
+	if err := applyRollups(ctx, tx, ids, -1); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		DELETE FROM orders
+		WHERE id = ANY($1)
+		RETURNING delivery_id, payment_id;
+	`, ids)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	deliveryIDs := make([]int64, 0, len(ids))
+	paymentIDs := make([]int64, 0, len(ids))
+	for rows.Next() {
+		var deliveryID, paymentID int64
+		if err := rows.Scan(&deliveryID, &paymentID); err != nil {
+			rows.Close()
+			return wrapDBError(err)
+		}
+		deliveryIDs = append(deliveryIDs, deliveryID)
+		paymentIDs = append(paymentIDs, paymentID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return wrapDBError(err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM delivery WHERE id = ANY($1);`, deliveryIDs); err != nil {
+		return wrapDBError(err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM payment WHERE id = ANY($1);`, paymentIDs); err != nil {
+		return wrapDBError(err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO order_audit (order_id, action, actor, diff)
+		SELECT unnest($1::BIGINT[]), $2, $3, '{}'::JSONB;
+	`, ids, action, audit.ActorFromContext(ctx)); err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionRepository_PurgeBatch(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewRetentionRepository(db)
+
+	eo := &models.ExtendedOrder{
+		Order: models.Order{
+			OrderUID:        "retention test",
+			TrackNumber:     "2634",
+			Entry:           "142",
+			Locale:          "ru",
+			CustomerID:      "test",
+			DeliveryService: "test",
+			ShardKey:        "test",
+			SMID:            2,
+			DateCreated:     time.Date(1999, time.January, 1, 0, 0, 0, 0, time.UTC),
+			OOFShard:        "test",
+		},
+		Payment: models.Payment{
+			Transaction:  "test",
+			Currency:     "RUB",
+			Provider:     "alfa",
+			Amount:       money.FromInt(1000),
+			PaymentDate:  90872534,
+			Bank:         "tbank",
+			DeliveryCost: money.FromInt(325),
+			GoodsTotal:   money.FromInt(32),
+		},
+		Delivery: models.Delivery{
+			Name:    "test",
+			Phone:   "+7926",
+			Zip:     "1542",
+			City:    "Moscow",
+			Address: "Lenina",
+			Region:  "Moscow",
+			Email:   "test@emal.com",
+		},
+		Items: []*models.Item{
+			{
+				ChrtID:      324,
+				TrackNumber: "test",
+				Price:       money.FromInt(200),
+				RID:         "test",
+				Name:        "test",
+				Sale:        20,
+				Size:        "test",
+				TotalPrice:  money.FromInt(200),
+				NMID:        12,
+				Brand:       "test",
+				Status:      1,
+			},
+		},
+	}
+
+	require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+
+	cutoff := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	ids, err := repo.PurgeBatch(t.Context(), cutoff, time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{eo.Order.ID}, ids)
+
+	_, err = eoRepo.Delivery().Get(t.Context(), nil, eo.Delivery.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = eoRepo.Payment().Get(t.Context(), nil, eo.Payment.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	var items int
+	err = db.QueryRow(t.Context(), `SELECT count(*) FROM items WHERE order_id = $1`, eo.Order.ID).Scan(&items)
+	require.NoError(t, err)
+	assert.Zero(t, items)
+
+	entries, err := eoRepo.Audit().GetByOrderID(t.Context(), nil, eo.Order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AuditActionPurge, entries[len(entries)-1].Action)
+
+	ids, err = repo.PurgeBatch(t.Context(), cutoff, time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// rollupOrdersQuery и rollupItemsQuery прибавляют к дневным агрегатам
+// неудалённые заказы, отобранные условием %[1]s, с множителем %[2]s.
+// Агрегаты разбиты ещё и по дню оплаты (UTC): по нему отчёты выбирают
+// курс, как и пересчёт отдельного заказа.
+// Выручка учитывается за вычетом возвратов денег, возвращённые позиции
+// не учитываются.
+// Строки вставляются в порядке ключа, чтобы параллельные транзакции
+// не блокировали друг друга крест-накрест.
+const (
+	// paymentDayQuery — день оплаты p в UTC, как в fx.Converter.
+	paymentDayQuery = `(to_timestamp(p.payment_dt) AT TIME ZONE 'UTC')::DATE`
+
+	rollupOrdersQuery = `
+	INSERT INTO orders_daily_rollup AS r (
+		day, delivery_service, provider, bank, currency, payment_day, orders, items, revenue
+	)
+	SELECT
+		o.date_created::DATE, o.delivery_service, p.provider, p.bank, p.currency, ` + paymentDayQuery + `,
+		%[2]s * count(*), %[2]s * COALESCE(sum(ic.items), 0), %[2]s * sum(p.amount - rf.amount)
+	FROM orders AS o
+	INNER JOIN payment AS p ON p.id = o.payment_id
+	CROSS JOIN LATERAL (
+		SELECT count(*) AS items FROM items AS i
+		WHERE i.order_id = o.id AND i.order_date_created = o.date_created AND i.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM returns AS rt WHERE rt.item_id = i.id)
+	) AS ic
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(sum(amount), 0) AS amount FROM refunds WHERE order_id = o.id
+	) AS rf
+	WHERE o.deleted_at IS NULL AND %[1]s
+	GROUP BY 1, 2, 3, 4, 5, 6
+	ORDER BY 1, 2, 3, 4, 5, 6
+	ON CONFLICT (day, delivery_service, provider, bank, currency, payment_day) DO UPDATE SET
+		orders = r.orders + EXCLUDED.orders,
+		items = r.items + EXCLUDED.items,
+		revenue = r.revenue + EXCLUDED.revenue;
+	`
+
+	rollupItemsQuery = `
+	INSERT INTO items_daily_rollup AS r (
+		day, brand, nm_id, sale_bucket, currency, payment_day, quantity, revenue
+	)
+	SELECT
+		o.date_created::DATE, i.brand, i.nm_id, (i.sale / 10) * 10, p.currency, ` + paymentDayQuery + `,
+		%[2]s * count(*), %[2]s * sum(i.total_price)
+	FROM orders AS o
+	INNER JOIN payment AS p ON p.id = o.payment_id
+	INNER JOIN items AS i ON i.order_id = o.id AND i.order_date_created = o.date_created
+	WHERE o.deleted_at IS NULL AND i.deleted_at IS NULL AND %[1]s
+		AND NOT EXISTS (SELECT 1 FROM returns AS rt WHERE rt.item_id = i.id)
+	GROUP BY 1, 2, 3, 4, 5, 6
+	ORDER BY 1, 2, 3, 4, 5, 6
+	ON CONFLICT (day, brand, nm_id, sale_bucket, currency, payment_day) DO UPDATE SET
+		quantity = r.quantity + EXCLUDED.quantity,
+		revenue = r.revenue + EXCLUDED.revenue;
+	`
+)
+
+// applyRollups прибавляет (sign = 1) или вычитает (sign = -1) текущее
+// состояние неудалённых заказов ids из дневных агрегатов в транзакции tx.
+// Изменение заказа — вычитание до него и прибавление после.
+func applyRollups(ctx context.Context, tx pgx.Tx, ids []int64, sign int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, query := range []string{rollupOrdersQuery, rollupItemsQuery} {
+		batch.Queue(fmt.Sprintf(query, "o.id = ANY($1)", "$2::INT"), ids, sign)
+	}
+
+	return wrapDBError(tx.SendBatch(ctx, batch).Close())
+}
+
+type RollupRepository interface {
+	// Rebuild пересчитывает дневные агрегаты за дни [from, to) по текущим заказам.
+	Rebuild(ctx context.Context, from, to time.Time) error
+}
+
+type rollupRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRollupRepository(db *pgxpool.Pool) RollupRepository {
+	return &rollupRepository{db: db}
+}
+
+func (r *rollupRepository) Rebuild(ctx context.Context, from, to time.Time) error {
+	if !from.Before(to) {
+		return ErrInvalidFilter
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// Блокировка не пускает транзакции заказов к агрегатам до конца пересчёта,
+	// чтобы их изменения не потерялись и не учлись дважды.
+	_, err = tx.Exec(ctx, `LOCK TABLE orders_daily_rollup, items_daily_rollup IN SHARE ROW EXCLUSIVE MODE;`)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM orders_daily_rollup WHERE day >= $1::DATE AND day < $2::DATE;`, from, to)
+	batch.Queue(`DELETE FROM items_daily_rollup WHERE day >= $1::DATE AND day < $2::DATE;`, from, to)
+	for _, query := range []string{rollupOrdersQuery, rollupItemsQuery} {
+		batch.Queue(fmt.Sprintf(query, "o.date_created >= $1::DATE AND o.date_created < $2::DATE", "1"), from, to)
+	}
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	return nil
+}
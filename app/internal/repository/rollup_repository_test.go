@@ -0,0 +1,87 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/money"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollupRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	analytics := repository.NewAnalyticsRepository(db)
+	repo := repository.NewRollupRepository(db)
+
+	date := time.Date(2003, time.June, 10, 12, 0, 0, 0, time.UTC)
+	filter := models.AnalyticsFilter{
+		From:     date.AddDate(0, 0, -1),
+		To:       date.AddDate(0, 0, 1),
+		Currency: "RUB",
+		Base:     "RUB",
+	}
+
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics rollup%'`)
+		require.NoError(t, err)
+		require.NoError(t, repo.Rebuild(t.Context(), filter.From, filter.To))
+	})
+
+	basket := func(t *testing.T) *models.BasketStats {
+		stats, err := analytics.Basket(t.Context(), filter)
+		require.NoError(t, err)
+		return stats
+	}
+
+	eo := analyticsOrder("analytics rollup", "RUB", "alpha", date,
+		analyticsItem("A", 1, 0, 300), analyticsItem("B", 2, 0, 600))
+	require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+
+	stats := basket(t)
+	assert.Equal(t, int64(1), stats.Orders)
+	assert.Equal(t, int64(2), stats.Items)
+	assert.Equal(t, money.FromInt(1000), stats.AvgAmount)
+
+	t.Run("Update", func(t *testing.T) {
+		updated := analyticsOrder("analytics rollup", "RUB", "alpha", date,
+			analyticsItem("A", 1, 0, 300))
+		updated.ID = eo.ID
+		require.NoError(t, eoRepo.UpdateExtendedOrder(t.Context(), updated))
+
+		stats := basket(t)
+		assert.Equal(t, int64(1), stats.Orders)
+		assert.Equal(t, int64(1), stats.Items)
+		assert.Equal(t, money.FromInt(400), stats.AvgAmount)
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		_, err := db.Exec(t.Context(), `
+			UPDATE orders_daily_rollup SET orders = 0, items = 0, revenue = 0
+			WHERE day = $1::DATE;
+		`, date)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Rebuild(t.Context(), filter.From, filter.To))
+
+		stats := basket(t)
+		assert.Equal(t, int64(1), stats.Orders)
+		assert.Equal(t, int64(1), stats.Items)
+
+		assert.ErrorIs(t, repo.Rebuild(t.Context(), filter.To, filter.From), repository.ErrInvalidFilter)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, eoRepo.DeleteExtendedOrder(t.Context(), eo.ID))
+
+		stats := basket(t)
+		assert.Zero(t, stats.Orders)
+		assert.Zero(t, stats.Items)
+	})
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"test-task/internal/keyring"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ScrubRepository заменяет персональные данные доставки, записанные
+// открыто до включения шифрования, в истории изменений и телах событий
+// вебхуков. Значения заменяются началом слепых индексов, как в новых
+// записях аудита, поэтому в истории видно, что поле изменилось.
+type ScrubRepository interface {
+	// ScrubAudit обрабатывает до limit записей order_audit с id больше afterID
+	// и возвращает id последней и число обработанных записей.
+	ScrubAudit(ctx context.Context, afterID int64, limit int) (int64, int, error)
+	// ScrubWebhookPayloads обрабатывает до limit доставок вебхуков с id
+	// больше afterID и возвращает id последней и число обработанных доставок.
+	ScrubWebhookPayloads(ctx context.Context, afterID int64, limit int) (int64, int, error)
+}
+
+type scrubRepository struct {
+	db   *pgxpool.Pool
+	keys *keyring.Keyring
+}
+
+func NewScrubRepository(db *pgxpool.Pool, keys *keyring.Keyring) ScrubRepository {
+	return &scrubRepository{db: db, keys: keys}
+}
+
+func (r *scrubRepository) ScrubAudit(ctx context.Context, afterID int64, limit int) (int64, int, error) {
+	return r.scrub(ctx, `
+		SELECT id, diff::TEXT FROM order_audit
+		WHERE id > $1
+			AND diff ?| ARRAY['$', '$.delivery.name', '$.delivery.phone', '$.delivery.email', '$.delivery.address']
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE;
+	`, `UPDATE order_audit SET diff = $2::JSONB WHERE id = $1;`, afterID, limit, r.scrubDiff)
+}
+
+func (r *scrubRepository) ScrubWebhookPayloads(ctx context.Context, afterID int64, limit int) (int64, int, error) {
+	return r.scrub(ctx, `
+		SELECT id, payload FROM webhook_deliveries
+		WHERE id > $1 AND event_type IN ('order.created', 'order.updated')
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE;
+	`, `UPDATE webhook_deliveries SET payload = $2 WHERE id = $1;`, afterID, limit, r.scrubPayload)
+}
+
+// scrub читает строки запросом selectQuery, заменяет значения функцией fn
+// и записывает изменённые запросом updateQuery в одной транзакции.
+func (r *scrubRepository) scrub(
+	ctx context.Context,
+	selectQuery, updateQuery string,
+	afterID int64,
+	limit int,
+	fn func(data []byte) ([]byte, bool, error),
+) (lastID int64, n int, err error) {
+	if r.keys == nil {
+		return 0, 0, ErrNoKeyring
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	type scrubRow struct {
+		id   int64
+		data string
+	}
+	rows, err := tx.Query(ctx, selectQuery, afterID, limit)
+	if err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+	found, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (scrubRow, error) {
+		var rw scrubRow
+		err := row.Scan(&rw.id, &rw.data)
+		return rw, err
+	})
+	if err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+	if len(found) == 0 {
+		return afterID, 0, tx.Rollback(ctx)
+	}
+
+	batch := &pgx.Batch{}
+	for _, rw := range found {
+		scrubbed, changed, err := fn([]byte(rw.data))
+		if err != nil {
+			return 0, 0, err
+		}
+		if changed {
+			batch.Queue(updateQuery, rw.id, string(scrubbed))
+		}
+	}
+
+	if batch.Len() > 0 {
+		err = tx.SendBatch(ctx, batch).Close()
+		if err != nil {
+			return 0, 0, wrapDBError(err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, 0, wrapDBError(err)
+	}
+
+	return found[len(found)-1].id, len(found), nil
+}
+
+// scrubDiff заменяет поля доставки в diff записи аудита: изменения
+// отдельных полей и целые заказы при создании и удалении.
+func (r *scrubRepository) scrubDiff(data []byte) ([]byte, bool, error) {
+	var diff map[string]map[string]any
+	if err := decodeJSON(data, &diff); err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	for path, change := range diff {
+		for _, side := range []string{"before", "after"} {
+			if path == "$" {
+				changed = r.scrubOrder(change[side]) || changed
+			} else if field, ok := strings.CutPrefix(path, "$.delivery."); ok {
+				if v, ok := r.scrubValue(field, change[side]); ok {
+					change[side] = v
+					changed = true
+				}
+			}
+		}
+	}
+	if !changed {
+		return data, false, nil
+	}
+
+	out, err := json.Marshal(diff)
+	return out, true, err
+}
+
+// scrubPayload заменяет поля доставки заказа в теле события вебхука.
+func (r *scrubRepository) scrubPayload(data []byte) ([]byte, bool, error) {
+	var payload map[string]any
+	if err := decodeJSON(data, &payload); err != nil {
+		return nil, false, err
+	}
+
+	if !r.scrubOrder(payload["data"]) {
+		return data, false, nil
+	}
+
+	out, err := json.Marshal(payload)
+	return out, true, err
+}
+
+// scrubOrder заменяет поля доставки в JSON-представлении заказа.
+func (r *scrubRepository) scrubOrder(order any) bool {
+	o, ok := order.(map[string]any)
+	if !ok {
+		return false
+	}
+	delivery, ok := o["delivery"].(map[string]any)
+	if !ok {
+		return false
+	}
+
+	changed := false
+	for _, field := range []string{"name", "phone", "address", "email"} {
+		if v, ok := r.scrubValue(field, delivery[field]); ok {
+			delivery[field] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// scrubValue возвращает начало слепого индекса открытого значения поля.
+// Пустые и уже заменённые значения не меняются.
+func (r *scrubRepository) scrubValue(field string, v any) (string, bool) {
+	switch field {
+	case "name", "phone", "address", "email":
+	default:
+		return "", false
+	}
+
+	s, ok := v.(string)
+	if !ok || strings.HasPrefix(s, "hmac:") {
+		return "", false
+	}
+	idx := blindIndex(r.keys, field, s)
+	if idx == nil {
+		return "", false
+	}
+	return auditMarker(idx), true
+}
+
+// decodeJSON разбирает JSON, оставляя числа json.Number, чтобы денежные
+// суммы записались обратно без округления.
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
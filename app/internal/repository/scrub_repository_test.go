@@ -0,0 +1,96 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubRepository(t *testing.T) {
+	const orderID = -42
+
+	var auditID, subID, deliveryID int64
+	err := db.QueryRow(t.Context(), `
+		INSERT INTO order_audit (order_id, action, actor, diff)
+		VALUES ($1, 'update', '{"type":"api"}', $2)
+		RETURNING id;
+	`, orderID, `{
+		"$.delivery.name": {"before": "Test Testov", "after": "Ivan Ivanov"},
+		"$.delivery.city": {"before": "Moscow", "after": "Tver"},
+		"$.payment.amount": {"before": 1817.5, "after": 1817.25}
+	}`).Scan(&auditID)
+	require.NoError(t, err)
+
+	err = db.QueryRow(t.Context(), `
+		INSERT INTO webhook_subscriptions (url, events, secret)
+		VALUES ('https://scrub.example', '{order.created}', 'secret')
+		RETURNING id;
+	`).Scan(&subID)
+	require.NoError(t, err)
+
+	err = db.QueryRow(t.Context(), `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, order_id, payload)
+		VALUES ($1, 'order.created', $2, $3)
+		RETURNING id;
+	`, subID, orderID, `{"type":"order.created","order_id":-42,"data":{"delivery":{"name":"Test Testov","phone":"+9720000000","city":"Moscow"}}}`).Scan(&deliveryID)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM order_audit WHERE order_id = $1`, orderID)
+		require.NoError(t, err)
+		_, err = db.Exec(t.Context(), `DELETE FROM webhook_subscriptions WHERE id = $1`, subID)
+		require.NoError(t, err)
+	})
+
+	_, _, err = repository.NewScrubRepository(db, nil).ScrubAudit(t.Context(), 0, 100)
+	assert.ErrorIs(t, err, repository.ErrNoKeyring)
+
+	repo := repository.NewScrubRepository(db, testKeyring(t, "k1"))
+
+	for _, scrub := range []func() (int64, int, error){
+		func() (int64, int, error) { return repo.ScrubAudit(t.Context(), auditID-1, 100) },
+		func() (int64, int, error) { return repo.ScrubWebhookPayloads(t.Context(), deliveryID-1, 100) },
+	} {
+		lastID, n, err := scrub()
+		require.NoError(t, err)
+		assert.Positive(t, n)
+		assert.Positive(t, lastID)
+	}
+
+	var diff string
+	err = db.QueryRow(t.Context(), `SELECT diff::TEXT FROM order_audit WHERE id = $1`, auditID).Scan(&diff)
+	require.NoError(t, err)
+	assert.NotContains(t, diff, "Test Testov")
+	assert.NotContains(t, diff, "Ivan Ivanov")
+	assert.Contains(t, diff, "Tver")
+	assert.Contains(t, diff, "1817.25")
+
+	var changes map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(diff), &changes))
+	assert.True(t, strings.HasPrefix(changes["$.delivery.name"]["before"].(string), "hmac:"))
+	assert.True(t, strings.HasPrefix(changes["$.delivery.name"]["after"].(string), "hmac:"))
+
+	var payload string
+	err = db.QueryRow(t.Context(), `SELECT payload FROM webhook_deliveries WHERE id = $1`, deliveryID).Scan(&payload)
+	require.NoError(t, err)
+	assert.NotContains(t, payload, "Test Testov")
+	assert.NotContains(t, payload, "+9720000000")
+	assert.Contains(t, payload, "Moscow")
+	assert.Contains(t, payload, `"name":"hmac:`)
+
+	// повторный запуск ничего не меняет
+	var again string
+	_, _, err = repo.ScrubAudit(t.Context(), auditID-1, 100)
+	require.NoError(t, err)
+	err = db.QueryRow(t.Context(), `SELECT diff::TEXT FROM order_audit WHERE id = $1`, auditID).Scan(&again)
+	require.NoError(t, err)
+	assert.Equal(t, diff, again)
+}
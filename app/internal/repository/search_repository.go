@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"test-task/internal/keyring"
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// searchQuery находит поля позиций и доставки, совпавшие с запросом
+// полнотекстово, подстрокой или по сходству триграмм, и ранжирует заказы
+// по лучшему совпадению.
+//
+// $1 — текст запроса, $2 — шаблон LIKE, $3 — искать ли по персональным
+// данным, $4, $5 — limit и offset, $6–$8 — слепые индексы запроса как
+// имени, email и телефона. Зашифрованные персональные данные находятся
+// только по точному совпадению со слепым индексом, значением совпадения
+// тогда служит сам запрос; адрес в них не ищется.
+const searchQuery = `
+	WITH q AS (
+		SELECT $1::TEXT AS text, websearch_to_tsquery('simple', $1::TEXT) AS tsq
+	),
+	hits AS (
+		SELECT o.id, o.date_created, f.field, f.value
+		FROM q, items AS i
+		INNER JOIN orders AS o
+			ON o.id = i.order_id AND o.date_created = i.order_date_created
+		CROSS JOIN LATERAL (
+			VALUES ('item.name', i.name), ('item.brand', i.brand)
+		) AS f(field, value)
+		WHERE i.deleted_at IS NULL AND o.deleted_at IS NULL
+			AND (
+				i.search_vector @@ q.tsq
+				OR i.name ILIKE $2 OR i.brand ILIKE $2
+				OR q.text <% i.name OR q.text <% i.brand
+			)
+
+		UNION ALL
+
+		SELECT o.id, o.date_created, f.field, f.value
+		FROM q, delivery AS d
+		INNER JOIN orders AS o ON o.delivery_id = d.id
+		CROSS JOIN LATERAL (
+			VALUES
+				('delivery.city', d.city, FALSE),
+				('delivery.region', d.region, FALSE),
+				('delivery.name', CASE
+					WHEN d.enc_key_id IS NULL THEN d.name
+					WHEN d.name_bidx = $6 THEN q.text END, TRUE),
+				('delivery.address', CASE WHEN d.enc_key_id IS NULL THEN d.address END, TRUE),
+				('delivery.email', CASE
+					WHEN d.enc_key_id IS NULL THEN d.email
+					WHEN d.email_bidx = $7 THEN q.text END, TRUE),
+				('delivery.phone', CASE
+					WHEN d.enc_key_id IS NULL THEN d.phone
+					WHEN d.phone_bidx = $8 THEN q.text END, TRUE)
+		) AS f(field, value, private)
+		WHERE o.deleted_at IS NULL
+			AND (NOT f.private OR $3::BOOLEAN)
+			AND (
+				d.search_vector @@ q.tsq
+				OR d.city ILIKE $2 OR d.region ILIKE $2
+				OR q.text <% d.city OR q.text <% d.region
+				OR ($3::BOOLEAN AND (
+					d.name_bidx = $6 OR d.email_bidx = $7 OR d.phone_bidx = $8
+					OR (d.enc_key_id IS NULL AND (
+						d.name ILIKE $2 OR d.address ILIKE $2
+						OR d.email ILIKE $2 OR d.phone ILIKE $2
+						OR q.text <% d.name OR q.text <% d.address
+						OR q.text <% d.email OR q.text <% d.phone
+					))
+				))
+			)
+	),
+	matches AS (
+		SELECT DISTINCT
+			h.id, h.date_created, h.field, h.value,
+			greatest(
+				ts_rank(to_tsvector('simple', h.value), q.tsq),
+				word_similarity(q.text, h.value),
+				CASE WHEN h.value ILIKE $2 THEN 0.5 ELSE 0 END
+			)::FLOAT8 AS rank
+		FROM hits AS h, q
+		WHERE to_tsvector('simple', h.value) @@ q.tsq
+			OR h.value ILIKE $2
+			OR q.text <% h.value
+	),
+	ranked AS (
+		SELECT
+			id, date_created,
+			max(rank) AS rank,
+			array_agg(field ORDER BY rank DESC, field, value) AS fields,
+			array_agg(value ORDER BY rank DESC, field, value) AS vals
+		FROM matches
+		GROUP BY id, date_created
+		ORDER BY rank DESC, date_created DESC, id DESC
+		LIMIT $4 OFFSET $5
+	)
+	SELECT
+		o.id, o.order_uid, o.track_number, o.entry, COALESCE(o.customer_id, ''),
+		o.delivery_service, o.date_created, p.currency, p.amount,
+		(
+			SELECT count(*) FROM items AS i
+			WHERE i.order_id = o.id AND i.order_date_created = o.date_created
+				AND i.deleted_at IS NULL
+		),
+		r.rank, r.fields, r.vals
+	FROM ranked AS r
+	INNER JOIN orders AS o ON o.id = r.id AND o.date_created = r.date_created
+	INNER JOIN payment AS p ON p.id = o.payment_id
+	ORDER BY r.rank DESC, r.date_created DESC, r.id DESC;
+`
+
+type SearchRepository interface {
+	// Search возвращает заказы, совпавшие с запросом, по убыванию релевантности.
+	// Highlight у совпавших полей не заполняется.
+	Search(ctx context.Context, q models.SearchQuery) ([]*models.SearchHit, error)
+}
+
+type searchRepository struct {
+	db   *pgxpool.Pool
+	keys *keyring.Keyring
+}
+
+func NewSearchRepository(db *pgxpool.Pool, keys *keyring.Keyring) SearchRepository {
+	return &searchRepository{db: db, keys: keys}
+}
+
+func (r *searchRepository) Search(ctx context.Context, q models.SearchQuery) ([]*models.SearchHit, error) {
+	text := strings.TrimSpace(q.Text)
+	if text == "" || q.Limit < 0 || q.Offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	rows, err := r.db.Query(ctx, searchQuery,
+		text, likePattern(text), q.Private, q.Limit, q.Offset,
+		blindIndex(r.keys, "name", text), blindIndex(r.keys, "email", text), blindIndex(r.keys, "phone", text),
+	)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	hits, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SearchHit, error) {
+		hit := new(models.SearchHit)
+		var fields, values []string
+
+		err := row.Scan(
+			&hit.Order.ID, &hit.Order.OrderUID, &hit.Order.TrackNumber, &hit.Order.Entry, &hit.Order.CustomerID,
+			&hit.Order.DeliveryService, &hit.Order.DateCreated, &hit.Order.Currency, &hit.Order.Amount,
+			&hit.Order.Items,
+			&hit.Rank, &fields, &values,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		hit.Matches = make([]*models.SearchMatch, 0, len(fields))
+		for i := range fields {
+			hit.Matches = append(hit.Matches, &models.SearchMatch{Field: fields[i], Value: values[i]})
+		}
+
+		return hit, nil
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	return hits, nil
+}
+
+// likePattern — шаблон ILIKE для поиска подстроки text.
+func likePattern(text string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(text) + "%"
+}
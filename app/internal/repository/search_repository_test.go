@@ -0,0 +1,66 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRepository(t *testing.T) {
+	eoRepo := repository.NewExtendedOrderRepository(db, nil)
+	repo := repository.NewSearchRepository(db, nil)
+
+	date := time.Date(2003, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+	eo := analyticsOrder("analytics search", "RUB", "alpha", date,
+		analyticsItem("Zyxwbrand", 1, 0, 300))
+	eo.Delivery.Name = "Kirill Searchtestov"
+	require.NoError(t, eoRepo.CreateExtendedOrder(t.Context(), eo))
+	t.Cleanup(func() {
+		_, err := db.Exec(t.Context(), `DELETE FROM orders WHERE order_uid LIKE 'analytics search%'`)
+		require.NoError(t, err)
+		require.NoError(t, repository.NewRollupRepository(db).Rebuild(t.Context(), date, date.AddDate(0, 0, 1)))
+	})
+
+	search := func(t *testing.T, text string, private bool) []*models.SearchHit {
+		hits, err := repo.Search(t.Context(), models.SearchQuery{Text: text, Limit: 10, Private: private})
+		require.NoError(t, err)
+		return hits
+	}
+
+	t.Run("Brand", func(t *testing.T) {
+		hits := search(t, "zyxwbrand", false)
+		require.Len(t, hits, 1)
+		assert.Equal(t, eo.Order.ID, hits[0].Order.ID)
+		assert.Equal(t, int64(1), hits[0].Order.Items)
+		assert.Equal(t, eo.Payment.Amount, hits[0].Order.Amount)
+		assert.Equal(t, []*models.SearchMatch{{Field: "item.brand", Value: "Zyxwbrand"}}, hits[0].Matches)
+	})
+
+	t.Run("Partial", func(t *testing.T) {
+		hits := search(t, "xwbra", false)
+		require.Len(t, hits, 1)
+		assert.Equal(t, eo.Order.ID, hits[0].Order.ID)
+	})
+
+	t.Run("Private", func(t *testing.T) {
+		assert.Empty(t, search(t, "Searchtestov", false))
+
+		hits := search(t, "Searchtestov", true)
+		require.Len(t, hits, 1)
+		assert.Equal(t, "delivery.name", hits[0].Matches[0].Field)
+	})
+
+	t.Run("Empty query", func(t *testing.T) {
+		_, err := repo.Search(t.Context(), models.SearchQuery{Text: " ", Limit: 10})
+		assert.ErrorIs(t, err, repository.ErrInvalidFilter)
+	})
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"test-task/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	selectWebhookSubscriptionsQuery = `
+	SELECT id, url, events, secret, max_attempts, backoff_seconds, active, created_at, updated_at
+	FROM webhook_subscriptions
+`
+
+	selectWebhookDeliveriesQuery = `
+	SELECT
+		id, subscription_id, event_type, order_id, payload, status, attempts,
+		response_status, last_error, created_at, updated_at, delivered_at
+	FROM webhook_deliveries
+`
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	// UpdateSubscription заменяет подписку целиком, пустой Secret оставляет прежний.
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+
+	// EnqueueEvent создаёт ожидающие доставки события для активных подписок
+	// на его тип и возвращает их число.
+	EnqueueEvent(ctx context.Context, eventType string, orderID int64, payload []byte) (int64, error)
+	// ClaimDeliveries переводит в отправку до limit доставок: ожидающих и
+	// зависших в отправке дольше lease, например после остановки реплики.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	// UpdateDelivery сохраняет статус, число попыток и результат последней из них.
+	UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, status string, limit, offset int) ([]*models.WebhookDelivery, error)
+	// Redeliver создаёт ожидающую доставку с телом доставки id.
+	Redeliver(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if sub == nil {
+		return ErrNilValue
+	}
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO webhook_subscriptions (url, events, secret, max_attempts, backoff_seconds, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at;
+	`, sub.URL, sub.Events, sub.Secret, sub.MaxAttempts, sub.BackoffSeconds, sub.Active).Scan(
+		&sub.ID, &sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if sub == nil {
+		return ErrNilValue
+	}
+
+	err := r.db.QueryRow(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $2, events = $3, secret = COALESCE(NULLIF($4, ''), secret),
+			max_attempts = $5, backoff_seconds = $6, active = $7, updated_at = now()
+		WHERE id = $1
+		RETURNING created_at, updated_at;
+	`, sub.ID, sub.URL, sub.Events, sub.Secret, sub.MaxAttempts, sub.BackoffSeconds, sub.Active).Scan(
+		&sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1;`, id)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx, selectWebhookSubscriptionsQuery+`WHERE id = $1;`, id)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	sub, err := pgx.CollectExactlyOneRow(rows, scanWebhookSubscription)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	return sub, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx, selectWebhookSubscriptionsQuery+`ORDER BY id;`)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	subs, err := pgx.CollectRows(rows, scanWebhookSubscription)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	return subs, nil
+}
+
+func (r *webhookRepository) EnqueueEvent(ctx context.Context, eventType string, orderID int64, payload []byte) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, order_id, payload)
+		SELECT id, $1, $2, $3
+		FROM webhook_subscriptions
+		WHERE active AND $1 = ANY(events);
+	`, eventType, orderID, string(payload))
+	if err != nil {
+		return 0, wrapDBError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *webhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET status = 'sending', updated_at = now()
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' OR (status = 'sending' AND updated_at < now() - $2::INTERVAL)
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT
+			c.id, c.subscription_id, c.event_type, c.order_id, c.payload, c.status, c.attempts,
+			c.response_status, c.last_error, c.created_at, c.updated_at, c.delivered_at,
+
+			s.id, s.url, s.events, s.secret, s.max_attempts, s.backoff_seconds, s.active,
+			s.created_at, s.updated_at
+		FROM claimed AS c
+		INNER JOIN webhook_subscriptions AS s ON s.id = c.subscription_id
+		ORDER BY c.id;
+	`, limit, lease)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.WebhookDelivery, error) {
+		d := &models.WebhookDelivery{Subscription: new(models.WebhookSubscription)}
+		s := d.Subscription
+		var payload string
+		err := row.Scan(
+			&d.ID, &d.SubscriptionID, &d.EventType, &d.OrderID, &payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &d.DeliveredAt,
+
+			&s.ID, &s.URL, &s.Events, &s.Secret, &s.MaxAttempts, &s.BackoffSeconds, &s.Active,
+			&s.CreatedAt, &s.UpdatedAt,
+		)
+		d.Payload = []byte(payload)
+		return d, err
+	})
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	if d == nil {
+		return ErrNilValue
+	}
+
+	err := r.db.QueryRow(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, last_error = $5,
+			delivered_at = $6, updated_at = now()
+		WHERE id = $1
+		RETURNING updated_at;
+	`, d.ID, d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.DeliveredAt).Scan(&d.UpdatedAt)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(
+	ctx context.Context,
+	subscriptionID int64,
+	status string,
+	limit, offset int,
+) ([]*models.WebhookDelivery, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	rows, err := r.db.Query(ctx, selectWebhookDeliveriesQuery+`
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4;
+	`, subscriptionID, status, limit, offset)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	deliveries, err := pgx.CollectRows(rows, scanWebhookDelivery)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) Redeliver(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, order_id, payload)
+		SELECT subscription_id, event_type, order_id, payload
+		FROM webhook_deliveries
+		WHERE id = $1
+		RETURNING
+			id, subscription_id, event_type, order_id, payload, status, attempts,
+			response_status, last_error, created_at, updated_at, delivered_at;
+	`, id)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+
+	d, err := pgx.CollectExactlyOneRow(rows, scanWebhookDelivery)
+	if err != nil {
+		return nil, wrapDBError(err)
+	}
+	return d, nil
+}
+
+func scanWebhookSubscription(row pgx.CollectableRow) (*models.WebhookSubscription, error) {
+	sub := new(models.WebhookSubscription)
+	err := row.Scan(
+		&sub.ID, &sub.URL, &sub.Events, &sub.Secret, &sub.MaxAttempts, &sub.BackoffSeconds,
+		&sub.Active, &sub.CreatedAt, &sub.UpdatedAt,
+	)
+	return sub, err
+}
+
+func scanWebhookDelivery(row pgx.CollectableRow) (*models.WebhookDelivery, error) {
+	d := new(models.WebhookDelivery)
+	var payload string
+	err := row.Scan(
+		&d.ID, &d.SubscriptionID, &d.EventType, &d.OrderID, &payload, &d.Status, &d.Attempts,
+		&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &d.DeliveredAt,
+	)
+	d.Payload = []byte(payload)
+	return d, err
+}
@@ -0,0 +1,112 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	repo := repository.NewWebhookRepository(db)
+
+	sub := &models.WebhookSubscription{
+		URL:            "https://partner.example/hooks",
+		Events:         []string{"order.created", "order.deleted"},
+		Secret:         "0123456789abcdef",
+		MaxAttempts:    3,
+		BackoffSeconds: 1,
+		Active:         true,
+	}
+	require.NoError(t, repo.CreateSubscription(t.Context(), sub))
+	t.Cleanup(func() {
+		// доставки удаляются каскадно
+		_, err := db.Exec(t.Context(), `DELETE FROM webhook_subscriptions WHERE id = $1`, sub.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Subscription", func(t *testing.T) {
+		got, err := repo.GetSubscription(t.Context(), sub.ID)
+		require.NoError(t, err)
+		assert.Equal(t, sub.Events, got.Events)
+		assert.Equal(t, sub.Secret, got.Secret)
+
+		update := *sub
+		update.Secret = ""
+		update.MaxAttempts = 4
+		require.NoError(t, repo.UpdateSubscription(t.Context(), &update))
+
+		got, err = repo.GetSubscription(t.Context(), sub.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 4, got.MaxAttempts)
+		assert.Equal(t, sub.Secret, got.Secret)
+
+		subs, err := repo.ListSubscriptions(t.Context())
+		require.NoError(t, err)
+		assert.NotEmpty(t, subs)
+
+		_, err = repo.GetSubscription(t.Context(), -1)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteSubscription(t.Context(), -1), repository.ErrNotFound)
+	})
+
+	var delivery *models.WebhookDelivery
+
+	t.Run("Enqueue And Claim", func(t *testing.T) {
+		n, err := repo.EnqueueEvent(t.Context(), "order.updated", 1, []byte(`{}`))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		payload := `{"type":"order.created","order_id":1}`
+		n, err = repo.EnqueueEvent(t.Context(), "order.created", 1, []byte(payload))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		claimed, err := repo.ClaimDeliveries(t.Context(), 100, time.Hour)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		delivery = claimed[0]
+		assert.Equal(t, models.WebhookSending, delivery.Status)
+		assert.Equal(t, payload, string(delivery.Payload))
+		require.NotNil(t, delivery.Subscription)
+		assert.Equal(t, sub.URL, delivery.Subscription.URL)
+		assert.Equal(t, sub.Secret, delivery.Subscription.Secret)
+
+		// уже взятая доставка не выдаётся повторно до истечения lease
+		claimed, err = repo.ClaimDeliveries(t.Context(), 100, time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+	})
+
+	t.Run("Update And Redeliver", func(t *testing.T) {
+		status := 500
+		delivery.Status = models.WebhookFailed
+		delivery.Attempts = 3
+		delivery.ResponseStatus = &status
+		delivery.LastError = "unexpected response status 500"
+		require.NoError(t, repo.UpdateDelivery(t.Context(), delivery))
+
+		deliveries, err := repo.ListDeliveries(t.Context(), sub.ID, models.WebhookFailed, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Equal(t, 500, *deliveries[0].ResponseStatus)
+
+		again, err := repo.Redeliver(t.Context(), delivery.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, delivery.ID, again.ID)
+		assert.Equal(t, models.WebhookPending, again.Status)
+		assert.Zero(t, again.Attempts)
+		assert.Equal(t, string(delivery.Payload), string(again.Payload))
+
+		_, err = repo.Redeliver(t.Context(), -1)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
@@ -2,8 +2,7 @@ package retry
 
 import (
 	"context"
-	"fmt"
-
+	"errors"
 	"time"
 )
 
@@ -48,7 +47,7 @@ func (r *retrier) Do(ctx context.Context, f AttemptFunc) error {
 		}
 
 		if r.isRetryable != nil && !r.isRetryable(err) {
-			return fmt.Errorf("unretryable error: %w", err)
+			return &attemptError{reason: "unretryable error", err: err}
 		}
 
 		select {
@@ -58,7 +57,31 @@ func (r *retrier) Do(ctx context.Context, f AttemptFunc) error {
 		}
 	}
 
-	return fmt.Errorf("all attempts failed: %w", err)
+	return &attemptError{reason: "all attempts failed", err: err}
+}
+
+// attemptError — ошибка последней попытки с причиной остановки повторов.
+type attemptError struct {
+	reason string
+	err    error
+}
+
+func (e *attemptError) Error() string {
+	return e.reason + ": " + e.err.Error()
+}
+
+func (e *attemptError) Unwrap() error {
+	return e.err
+}
+
+// Cause возвращает ошибку последней попытки без пометки Do, чтобы её текст
+// можно было показать клиенту. Прочие ошибки возвращаются как есть.
+func Cause(err error) error {
+	var ae *attemptError
+	if errors.As(err, &ae) {
+		return ae.err
+	}
+	return err
 }
 
 func defaultAttempts() int {
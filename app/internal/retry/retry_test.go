@@ -138,3 +138,19 @@ func TestRetrier_Do(t *testing.T) {
 		})
 	}
 }
+
+func TestCause(t *testing.T) {
+	r := New(WithMaxAttempts(2), WithBackoff(FixedBackoff{Interval: time.Millisecond}), WithIsRetryableFunc(func(err error) bool {
+		return !errors.Is(err, errCustom)
+	}))
+
+	err := r.Do(context.Background(), func(int) error { return errAlwaysFail })
+	assert.EqualError(t, err, "all attempts failed: always fail")
+	assert.Equal(t, errAlwaysFail, Cause(err))
+
+	err = r.Do(context.Background(), func(int) error { return errCustom })
+	assert.EqualError(t, err, "unretryable error: custom error")
+	assert.Equal(t, errCustom, Cause(err))
+
+	assert.Equal(t, errCustom, Cause(errCustom))
+}
@@ -0,0 +1,74 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+)
+
+// Terms разбивает запрос на слова без учёта регистра, отбрасывая
+// знаки препинания и операторы websearch ("", -, or).
+func Terms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '@' && r != '.' && r != '+'
+	})
+
+	terms := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		w = strings.Trim(w, ".")
+		if w == "" || w == "or" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+// Highlight оборачивает в <mark></mark> вхождения слов запроса в value,
+// экранируя остальной текст как HTML.
+// Если ни одно слово не входит в value целиком (совпадение по сходству,
+// например с опечаткой), выделяется всё значение.
+func Highlight(value, query string) string {
+	lower := []rune(strings.ToLower(value))
+	runes := []rune(value)
+	// ToLower в редких случаях меняет число рун — тогда выделяем значение целиком.
+	if len(lower) != len(runes) {
+		return markOpen + html.EscapeString(value) + markClose
+	}
+
+	marked := make([]bool, len(runes))
+	found := false
+	for _, term := range Terms(query) {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == term {
+				for j := i; j < i+len(t); j++ {
+					marked[j] = true
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return markOpen + html.EscapeString(value) + markClose
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(markOpen)
+		}
+		b.WriteString(html.EscapeString(string(r)))
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString(markClose)
+		}
+	}
+	return b.String()
+}
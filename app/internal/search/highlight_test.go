@@ -0,0 +1,36 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"moscow", "lenina"}, Terms(`"Moscow" or -Lenina moscow`))
+	assert.Equal(t, []string{"test@mail.ru", "+7926"}, Terms("test@mail.ru, +7926."))
+	assert.Empty(t, Terms(" - "))
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		query string
+		want  string
+	}{
+		{"word", "Moscow", "moscow", "<mark>Moscow</mark>"},
+		{"part of word", "Ivan Ivanov", "ivan", "<mark>Ivan</mark> <mark>Ivan</mark>ov"},
+		{"several terms", "Kira Petrova", "petr kira", "<mark>Kira</mark> <mark>Petr</mark>ova"},
+		{"overlapping terms", "Vivienne Sabo", "vivi vienne", "<mark>Vivienne</mark> Sabo"},
+		{"cyrillic", "Москва", "моск", "<mark>Моск</mark>ва"},
+		{"fuzzy match", "Moscow", "Moskow", "<mark>Moscow</mark>"},
+		{"html", "<b>Sabo</b>", "sabo", "&lt;b&gt;<mark>Sabo</mark>&lt;/b&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Highlight(tt.value, tt.query))
+		})
+	}
+}
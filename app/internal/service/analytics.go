@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WithAnalytics подключает отчёты по заказам.
+func (s *Service) WithAnalytics(repo repository.AnalyticsRepository) *Service {
+	s.analytics = repo
+	return s
+}
+
+// analyticsFilter подставляет валюту отчёта и базовую валюту курсов.
+// Без пересчёта валют в отчёт попадает выручка только в валюте отчёта.
+func (s *Service) analyticsFilter(f models.AnalyticsFilter) models.AnalyticsFilter {
+	if s.fx != nil {
+		f.Base = s.fx.Base()
+		if f.Currency == "" {
+			f.Currency = f.Base
+		}
+	} else {
+		f.Base = f.Currency
+	}
+	return f
+}
+
+func (s *Service) Revenue(ctx context.Context, f models.AnalyticsFilter, interval string) ([]*models.RevenuePoint, error) {
+	points, err := s.analytics.Revenue(ctx, s.analyticsFilter(f), interval)
+	if err != nil {
+		s.log.Error("failed to build revenue report", zap.Error(err))
+		return nil, err
+	}
+	return points, nil
+}
+
+func (s *Service) TopBrands(ctx context.Context, f models.AnalyticsFilter, limit int) ([]*models.SalesRank, error) {
+	ranks, err := s.analytics.TopBrands(ctx, s.analyticsFilter(f), limit)
+	if err != nil {
+		s.log.Error("failed to build top brands report", zap.Error(err))
+		return nil, err
+	}
+	return ranks, nil
+}
+
+func (s *Service) TopProducts(ctx context.Context, f models.AnalyticsFilter, limit int) ([]*models.SalesRank, error) {
+	ranks, err := s.analytics.TopProducts(ctx, s.analyticsFilter(f), limit)
+	if err != nil {
+		s.log.Error("failed to build top products report", zap.Error(err))
+		return nil, err
+	}
+	return ranks, nil
+}
+
+func (s *Service) Basket(ctx context.Context, f models.AnalyticsFilter) (*models.BasketStats, error) {
+	stats, err := s.analytics.Basket(ctx, s.analyticsFilter(f))
+	if err != nil {
+		s.log.Error("failed to build basket report", zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *Service) RevenueSplit(ctx context.Context, f models.AnalyticsFilter, by string) ([]*models.RevenueShare, error) {
+	shares, err := s.analytics.RevenueSplit(ctx, s.analyticsFilter(f), by)
+	if err != nil {
+		s.log.Error("failed to build revenue split report", zap.Error(err), zap.String("by", by))
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (s *Service) SaleDistribution(ctx context.Context, f models.AnalyticsFilter) ([]*models.SaleBucket, error) {
+	buckets, err := s.analytics.SaleDistribution(ctx, s.analyticsFilter(f))
+	if err != nil {
+		s.log.Error("failed to build sale distribution report", zap.Error(err))
+		return nil, err
+	}
+	return buckets, nil
+}
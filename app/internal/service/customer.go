@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WithCustomers подключает выборки заказов по покупателю.
+func (s *Service) WithCustomers(repo repository.CustomerRepository) *Service {
+	s.customers = repo
+	return s
+}
+
+func (s *Service) GetCustomerOrders(ctx context.Context, customerID string, limit, offset int) (*models.CustomerOrders, error) {
+	page, err := s.customers.ListOrders(ctx, customerID, limit, offset)
+	if err != nil {
+		s.log.Error("failed to get customer orders", zap.String("customer_id", customerID), zap.Error(err))
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *Service) GetCustomerSummary(ctx context.Context, customerID string) (*models.CustomerSummary, error) {
+	summary, err := s.customers.Summary(ctx, customerID)
+	if err != nil {
+		s.log.Error("failed to get customer summary", zap.String("customer_id", customerID), zap.Error(err))
+		return nil, err
+	}
+	return summary, nil
+}
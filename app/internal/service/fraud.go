@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/fraud"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WithFraud подключает список подозрительных заказов и, если engine
+// не nil, проверку заказов при приёме.
+func (s *Service) WithFraud(engine *fraud.Engine, repo repository.FraudRepository) *Service {
+	s.fraud = engine
+	s.flags = repo
+	return s
+}
+
+// ScreenOrder проверяет заказ по правилам до сохранения и записывает оценку
+// в eo.Risk. Если правила не сработали или проверка не подключена, eo.Risk — nil.
+func (s *Service) ScreenOrder(ctx context.Context, eo *models.ExtendedOrder) error {
+	eo.Risk = nil
+	if s.fraud == nil {
+		return nil
+	}
+
+	risk, err := s.fraud.Check(ctx, eo)
+	if err != nil {
+		s.log.Error("failed to screen order", zap.String("order_uid", eo.Order.OrderUID), zap.Error(err))
+		return err
+	}
+
+	if risk != nil {
+		s.log.Warn("order flagged",
+			zap.String("order_uid", eo.Order.OrderUID),
+			zap.Int("score", risk.Score),
+			zap.Int("reasons", len(risk.Reasons)),
+		)
+	}
+	eo.Risk = risk
+
+	return nil
+}
+
+func (s *Service) ListFlaggedOrders(ctx context.Context, minScore, limit, offset int) (*models.FlaggedOrders, error) {
+	page, err := s.flags.ListFlagged(ctx, minScore, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list flagged orders", zap.Error(err))
+		return nil, err
+	}
+	return page, nil
+}
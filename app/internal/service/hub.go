@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"test-task/internal/audit"
+	"test-task/internal/events"
+	"test-task/internal/models"
+	"test-task/internal/redact"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Сообщения подписчикам заказа.
+const (
+	UpdateSnapshot = "snapshot"
+	UpdatePatch    = "patch"
+	UpdateDeleted  = "deleted"
+)
+
+// trackingEvent — причина изменения заказа из-за нового события перевозчика.
+const trackingEvent = "delivery.tracking"
+
+// hubLoadTimeout ограничивает загрузку заказа при рассылке изменений.
+const hubLoadTimeout = 10 * time.Second
+
+// OrderUpdate — сообщение подписчику заказа: снимок заказа целиком
+// при подключении или изменённые поля в формате audit.Diff.
+type OrderUpdate struct {
+	Type    string                `json:"type"`
+	Event   string                `json:"event,omitempty"`
+	Order   *models.ExtendedOrder `json:"order,omitempty"`
+	Changes json.RawMessage       `json:"changes,omitempty"`
+}
+
+// Redact возвращает сообщение со скрытыми по политике полями в снимке
+// и в изменённых полях.
+func (u OrderUpdate) Redact(p *redact.Policy) OrderUpdate {
+	u.Order = p.Order(u.Order)
+	u.Changes = p.Changes(u.Changes)
+	return u
+}
+
+// OrderSubscription — подписка на изменения одного заказа. Updates
+// закрывается при удалении заказа, отписке или переполнении очереди.
+type OrderSubscription struct {
+	Updates <-chan OrderUpdate
+
+	updates chan OrderUpdate
+	id      int64
+	// last — последний отправленный подписчику заказ, от него считаются изменения
+	last *models.ExtendedOrder
+	// Overflowed — подписка закрыта, потому что подписчик не успевал читать.
+	overflowed bool
+	hub        *orderHub
+}
+
+// orderHub раздаёт изменения заказов подписчикам. Сервис отмечает
+// изменённые заказы, а рассылка идёт в отдельной горутине, чтобы медленные
+// подписчики и загрузка заказа не задерживали запись.
+type orderHub struct {
+	mu     sync.Mutex
+	subs   map[int64]map[*OrderSubscription]struct{}
+	dirty  map[int64]string
+	wake   chan struct{}
+	buffer int
+}
+
+// WithOrderHub включает подписки на изменения заказов с очередью
+// на buffer сообщений у каждого подписчика. Рассылку выполняет RunOrderHub.
+func (s *Service) WithOrderHub(buffer int) *Service {
+	s.hub = &orderHub{
+		subs:   make(map[int64]map[*OrderSubscription]struct{}),
+		dirty:  make(map[int64]string),
+		wake:   make(chan struct{}, 1),
+		buffer: buffer,
+	}
+	return s
+}
+
+// SubscribeOrder подписывает на изменения заказа id. Первым сообщением
+// приходит снимок заказа; ErrNotFound, если заказа нет.
+func (s *Service) SubscribeOrder(ctx context.Context, id int64) (*OrderSubscription, error) {
+	if s.hub == nil {
+		return nil, ErrOrderHubDisabled
+	}
+
+	// Подписка регистрируется до загрузки снимка: изменение, случившееся
+	// в промежутке, будет разослано повторно.
+	sub := s.hub.add(id)
+
+	eo, err := s.GetExtendedOrder(ctx, id)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	// рассылка могла успеть отправить более свежий снимок
+	if sub.last == nil {
+		sub.last = eo
+		s.hub.send(sub, OrderUpdate{Type: UpdateSnapshot, Order: eo})
+	}
+
+	return sub, nil
+}
+
+// Overflowed сообщает, что подписка закрыта из-за переполнения очереди.
+func (sub *OrderSubscription) Overflowed() bool {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+
+	return sub.overflowed
+}
+
+func (sub *OrderSubscription) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+
+	sub.hub.remove(sub)
+}
+
+// RunOrderHub рассылает изменения заказов, пока не отменён ctx.
+func (s *Service) RunOrderHub(ctx context.Context) {
+	if s.hub == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.hub.wake:
+		}
+
+		for id, event := range s.hub.takeDirty() {
+			s.refreshSubscribers(ctx, id, event)
+		}
+	}
+}
+
+// notifyOrder отмечает заказ изменённым, если на него есть подписчики.
+func (s *Service) notifyOrder(id int64, event string) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.markDirty(func(subscribed int64) bool { return subscribed == id }, event)
+}
+
+// notifyTracks отмечает изменёнными заказы подписчиков, у которых
+// есть один из трек-номеров tracks.
+func (s *Service) notifyTracks(tracks []string) {
+	if s.hub == nil || len(tracks) == 0 {
+		return
+	}
+	s.hub.markDirty(func(id int64) bool {
+		eo, ok := s.cache.Get(id)
+		if !ok {
+			// заказа нет в кеше — проверится при загрузке
+			return true
+		}
+		for _, track := range orderTracks(eo) {
+			if slices.Contains(tracks, track) {
+				return true
+			}
+		}
+		return false
+	}, trackingEvent)
+}
+
+func (s *Service) refreshSubscribers(ctx context.Context, id int64, event string) {
+	if event == events.OrderDeleted {
+		s.hub.closeOrder(id, OrderUpdate{Type: UpdateDeleted, Event: event})
+		return
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, hubLoadTimeout)
+	defer cancel()
+
+	eo, err := s.GetExtendedOrder(loadCtx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.hub.closeOrder(id, OrderUpdate{Type: UpdateDeleted, Event: event})
+		return
+	}
+	if err != nil {
+		s.log.Warn("failed to load order for subscribers", zap.Int64("id", id), zap.Error(err))
+		return
+	}
+
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	for sub := range s.hub.subs[id] {
+		if sub.last == nil {
+			sub.last = eo
+			s.hub.send(sub, OrderUpdate{Type: UpdateSnapshot, Order: eo})
+			continue
+		}
+
+		changes, err := audit.Diff(sub.last, eo)
+		if err != nil {
+			s.log.Warn("failed to diff order for subscriber", zap.Int64("id", id), zap.Error(err))
+			continue
+		}
+		if string(changes) == "{}" {
+			continue
+		}
+
+		sub.last = eo
+		s.hub.send(sub, OrderUpdate{Type: UpdatePatch, Event: event, Changes: changes})
+	}
+}
+
+func (h *orderHub) add(id int64) *OrderSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan OrderUpdate, h.buffer)
+	sub := &OrderSubscription{Updates: ch, updates: ch, id: id, hub: h}
+
+	if h.subs[id] == nil {
+		h.subs[id] = make(map[*OrderSubscription]struct{})
+	}
+	h.subs[id][sub] = struct{}{}
+
+	return sub
+}
+
+// send кладёт сообщение в очередь подписчика, а при переполнении отключает его.
+// Вызывается под h.mu.
+func (h *orderHub) send(sub *OrderSubscription, update OrderUpdate) {
+	if _, ok := h.subs[sub.id][sub]; !ok {
+		return
+	}
+
+	select {
+	case sub.updates <- update:
+	default:
+		sub.overflowed = true
+		h.remove(sub)
+	}
+}
+
+// remove вызывается под h.mu.
+func (h *orderHub) remove(sub *OrderSubscription) {
+	subs, ok := h.subs[sub.id]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.id)
+	}
+	close(sub.updates)
+}
+
+func (h *orderHub) closeOrder(id int64, update OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[id] {
+		h.send(sub, update)
+		h.remove(sub)
+	}
+}
+
+func (h *orderHub) markDirty(match func(id int64) bool, event string) {
+	h.mu.Lock()
+	for id := range h.subs {
+		if match(id) {
+			h.dirty[id] = event
+		}
+	}
+	marked := len(h.dirty) > 0
+	h.mu.Unlock()
+
+	if marked {
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *orderHub) takeDirty() map[int64]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dirty := h.dirty
+	h.dirty = make(map[int64]string)
+	return dirty
+}
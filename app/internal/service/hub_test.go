@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-task/internal/mocks"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func nextUpdate(t *testing.T, sub *OrderSubscription) (OrderUpdate, bool) {
+	t.Helper()
+
+	select {
+	case update, ok := <-sub.Updates:
+		return update, ok
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+		return OrderUpdate{}, false
+	}
+}
+
+func TestOrderHub_SnapshotThenPatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop()).WithOrderHub(4)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go service.RunOrderHub(ctx)
+
+	var id int64 = 123
+	before := &models.ExtendedOrder{Order: models.Order{ID: id}, Items: []*models.Item{{Status: 100}}}
+	after := &models.ExtendedOrder{Order: models.Order{ID: id}, Items: []*models.Item{{Status: 202}}}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().GetExtendedOrder(gomock.Any(), id).Return(before, nil),
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), id, 202).Return(nil),
+		mockRepo.EXPECT().GetExtendedOrder(gomock.Any(), id).Return(after, nil),
+	)
+
+	sub, err := service.SubscribeOrder(ctx, id)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	update, ok := nextUpdate(t, sub)
+	require.True(t, ok)
+	assert.Equal(t, UpdateSnapshot, update.Type)
+	assert.Equal(t, before, update.Order)
+
+	require.NoError(t, service.UpdateOrderStatus(ctx, id, 202))
+
+	update, ok = nextUpdate(t, sub)
+	require.True(t, ok)
+	assert.Equal(t, UpdatePatch, update.Type)
+	assert.Nil(t, update.Order)
+	assert.Contains(t, string(update.Changes), "status")
+}
+
+func TestOrderHub_DeleteClosesSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop()).WithOrderHub(4)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go service.RunOrderHub(ctx)
+
+	var id int64 = 123
+	eo := &models.ExtendedOrder{Order: models.Order{ID: id}}
+
+	mockRepo.EXPECT().GetExtendedOrder(gomock.Any(), id).Return(eo, nil)
+	mockRepo.EXPECT().DeleteExtendedOrder(gomock.Any(), id).Return(nil)
+
+	sub, err := service.SubscribeOrder(ctx, id)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	_, ok := nextUpdate(t, sub)
+	require.True(t, ok)
+
+	require.NoError(t, service.DeleteExtendedOrder(ctx, id))
+
+	update, ok := nextUpdate(t, sub)
+	require.True(t, ok)
+	assert.Equal(t, UpdateDeleted, update.Type)
+
+	_, ok = nextUpdate(t, sub)
+	assert.False(t, ok)
+	assert.False(t, sub.Overflowed())
+}
+
+func TestOrderHub_SubscribeNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop()).WithOrderHub(4)
+
+	var id int64 = 123
+	mockRepo.EXPECT().GetExtendedOrder(gomock.Any(), id).Return(nil, repository.ErrNotFound)
+
+	_, err := service.SubscribeOrder(t.Context(), id)
+
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.Empty(t, service.hub.subs)
+}
+
+func TestOrderHub_SlowSubscriberDisconnected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop()).WithOrderHub(1)
+
+	var id int64 = 123
+	eo := &models.ExtendedOrder{Order: models.Order{ID: id}, Items: []*models.Item{{Status: 100}}}
+	changed := &models.ExtendedOrder{Order: models.Order{ID: id}, Items: []*models.Item{{Status: 202}}}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().GetExtendedOrder(gomock.Any(), id).Return(eo, nil),
+		mockRepo.EXPECT().GetExtendedOrder(gomock.Any(), id).Return(changed, nil),
+	)
+
+	sub, err := service.SubscribeOrder(t.Context(), id)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	// снимок не прочитан, очередь заполнена
+	service.refreshSubscribers(t.Context(), id, "order.updated")
+
+	update, ok := <-sub.Updates
+	require.True(t, ok)
+	assert.Equal(t, UpdateSnapshot, update.Type)
+
+	_, ok = <-sub.Updates
+	assert.False(t, ok)
+	assert.True(t, sub.Overflowed())
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WithReconciliation подключает просмотр результатов сверки с реестрами провайдеров.
+func (s *Service) WithReconciliation(repo repository.ReconciliationRepository) *Service {
+	s.recon = repo
+	return s
+}
+
+func (s *Service) ListReconciliationRuns(ctx context.Context, limit, offset int) ([]*models.ReconciliationRun, error) {
+	runs, err := s.recon.ListRuns(ctx, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list reconciliation runs", zap.Error(err))
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (s *Service) GetReconciliationRun(ctx context.Context, id int64) (*models.ReconciliationRun, error) {
+	run, err := s.recon.GetRun(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get reconciliation run", zap.Int64("run_id", id), zap.Error(err))
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *Service) ListReconciliationResults(
+	ctx context.Context,
+	runID int64,
+	status string,
+	limit, offset int,
+) ([]*models.ReconciliationResult, error) {
+	results, err := s.recon.ListResults(ctx, runID, status, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list reconciliation results", zap.Int64("run_id", runID), zap.Error(err))
+		return nil, err
+	}
+	return results, nil
+}
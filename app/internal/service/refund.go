@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/events"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WithRefunds подключает возвраты денег и товаров.
+func (s *Service) WithRefunds(repo repository.RefundRepository) *Service {
+	s.refunds = repo
+	return s
+}
+
+func (s *Service) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	if err := s.refunds.CreateRefund(ctx, refund); err != nil {
+		s.log.Error("failed to create refund", zap.Error(err), zap.Int64("id", refund.OrderID))
+		return err
+	}
+
+	// в заказе изменилась сумма возвратов
+	s.cache.Remove(refund.OrderID)
+	s.publish(events.OrderRefunded, refund.OrderID, refund)
+
+	s.log.Info("refund created", zap.Int64("id", refund.OrderID), zap.Int64("refund_id", refund.ID))
+
+	return nil
+}
+
+func (s *Service) CreateReturn(ctx context.Context, id int64, req *models.ReturnRequest) (*models.OrderReturn, error) {
+	ret, err := s.refunds.CreateReturn(ctx, id, req)
+	if err != nil {
+		s.log.Error("failed to create return", zap.Error(err), zap.Int64("id", id))
+		return nil, err
+	}
+
+	s.cache.Remove(id)
+	s.publish(events.OrderReturned, id, ret)
+
+	s.log.Info("return created", zap.Int64("id", id), zap.Int("items", len(ret.Returns)))
+
+	return ret, nil
+}
+
+func (s *Service) GetOrderRefunds(ctx context.Context, id int64) (*models.OrderRefunds, error) {
+	refunds, err := s.refunds.GetByOrderID(ctx, id)
+	if err != nil {
+		s.log.Error("failed to load refunds", zap.Error(err), zap.Int64("id", id))
+		return nil, err
+	}
+	return refunds, nil
+}
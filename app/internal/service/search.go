@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+	"test-task/internal/search"
+
+	"go.uber.org/zap"
+)
+
+// WithSearch подключает поиск по заказам.
+func (s *Service) WithSearch(repo repository.SearchRepository) *Service {
+	s.search = repo
+	return s
+}
+
+func (s *Service) Search(ctx context.Context, q models.SearchQuery) ([]*models.SearchHit, error) {
+	hits, err := s.search.Search(ctx, q)
+	if err != nil {
+		s.log.Error("failed to search orders", zap.Error(err), zap.Bool("private", q.Private))
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		for _, m := range hit.Matches {
+			m.Highlight = search.Highlight(m.Value, q.Text)
+		}
+	}
+
+	return hits, nil
+}
@@ -2,20 +2,48 @@ package service
 
 import (
 	"context"
+	"errors"
 	"test-task/internal/cache"
+	"test-task/internal/events"
+	"test-task/internal/fraud"
+	"test-task/internal/fx"
 	"test-task/internal/models"
 	"test-task/internal/repository"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
+var ErrFXDisabled = errors.New("currency conversion is not configured")
+
+var ErrOrderHubDisabled = errors.New("order subscriptions are not configured")
+
+// listPageSize — размер страницы при чтении заказов в EachOrder.
+const listPageSize = 100
+
 type Service struct {
 	db *pgxpool.Pool
 
-	repo repository.ExtendedOrderRepository
+	repo      repository.ExtendedOrderRepository
+	fx        *fx.Converter
+	analytics repository.AnalyticsRepository
+	customers repository.CustomerRepository
+	search    repository.SearchRepository
+	tracking  repository.DeliveryEventRepository
+	refunds   repository.RefundRepository
+	recon     repository.ReconciliationRepository
+	fraud     *fraud.Engine
+	flags     repository.FraudRepository
+	events    *events.Bus
+	webhooks  repository.WebhookRepository
+	hub       *orderHub
 
 	cache *cache.Cache[int64, *models.ExtendedOrder]
+	// tracks — id заказов по трек-номеру
+	tracks *cache.Cache[string, []int64]
+	// latest — последнее событие доставки по трек-номеру, nil — событий нет
+	latest *cache.Cache[string, *models.DeliveryEvent]
 
 	log *zap.Logger
 }
@@ -27,11 +55,40 @@ func NewService(
 	log *zap.Logger,
 ) *Service {
 	return &Service{
-		db:    db,
-		repo:  repo,
-		cache: cache.New[int64, *models.ExtendedOrder](orderCacheSize),
-		log:   log,
+		db:     db,
+		repo:   repo,
+		cache:  cache.New[int64, *models.ExtendedOrder](orderCacheSize),
+		tracks: cache.New[string, []int64](orderCacheSize),
+		latest: cache.New[string, *models.DeliveryEvent](orderCacheSize),
+		log:    log,
+	}
+}
+
+// WithEvents включает публикацию событий об изменениях заказов.
+func (s *Service) WithEvents(bus *events.Bus) *Service {
+	s.events = bus
+	return s
+}
+
+// publish сообщает подписчикам об уже сохранённом изменении заказа.
+func (s *Service) publish(eventType string, id int64, data any) {
+	s.notifyOrder(id, eventType)
+
+	if s.events == nil {
+		return
 	}
+	s.events.Publish(events.Event{
+		Type:       eventType,
+		OrderID:    id,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+}
+
+// WithFX включает пересчёт сумм заказов в другие валюты.
+func (s *Service) WithFX(converter *fx.Converter) *Service {
+	s.fx = converter
+	return s
 }
 
 func (s *Service) LoadRecentOrdersToCache(ctx context.Context, limit int) error {
@@ -57,14 +114,74 @@ func (s *Service) CreateExtendedOrder(ctx context.Context, eo *models.ExtendedOr
 		return err
 	}
 
-	s.cache.Add(eo.Order.ID, eo)
+	// заказ с тем же order_uid мог уже лежать в кеше в прежнем состоянии
+	s.cache.Remove(eo.Order.ID)
+	s.forgetTracks(eo)
+	s.publish(events.OrderCreated, eo.Order.ID, eo)
 
-	s.log.Info("order created and cached", zap.Int64("id", eo.Order.ID), zap.String("order_uid", eo.Order.OrderUID))
+	s.log.Info("order created", zap.Int64("id", eo.Order.ID), zap.String("order_uid", eo.Order.OrderUID))
 
 	return nil
 }
 
+// GetExtendedOrder возвращает заказ из кеша или базы. Если подключены
+// события перевозчиков, в delivery.tracking добавляется последнее из них.
 func (s *Service) GetExtendedOrder(ctx context.Context, id int64) (*models.ExtendedOrder, error) {
+	eo, err := s.getExtendedOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withTracking(ctx, eo)
+}
+
+func (s *Service) GetExtendedOrderByUID(ctx context.Context, uid string) (*models.ExtendedOrder, error) {
+	eo, err := s.repo.GetExtendedOrderByUID(ctx, uid)
+	if err != nil {
+		s.log.Error("failed to load order by uid", zap.Error(err), zap.String("order_uid", uid))
+		return nil, err
+	}
+
+	return s.withTracking(ctx, eo)
+}
+
+// EachOrder постранично читает заказы по фильтру f и передаёт их в fn,
+// пока не наберётся f.Limit заказов (0 — все) или fn не вернёт ошибку.
+func (s *Service) EachOrder(ctx context.Context, f models.OrderListFilter, fn func(*models.ExtendedOrder) error) error {
+	total := f.Limit
+	for sent := 0; total == 0 || sent < total; {
+		f.Limit = listPageSize
+		if total > 0 {
+			f.Limit = min(listPageSize, total-sent)
+		}
+
+		page, err := s.repo.ListExtendedOrders(ctx, f)
+		if err != nil {
+			s.log.Error("failed to list orders", zap.Error(err), zap.Int64("after_id", f.AfterID))
+			return err
+		}
+
+		tracked, err := s.withTrackingAll(ctx, page)
+		if err != nil {
+			return err
+		}
+		for _, eo := range tracked {
+			if err := fn(eo); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < f.Limit {
+			return nil
+		}
+		f.AfterID = page[len(page)-1].Order.ID
+		sent += len(page)
+	}
+
+	return nil
+}
+
+func (s *Service) getExtendedOrder(ctx context.Context, id int64) (*models.ExtendedOrder, error) {
 	if eo, ok := s.cache.Get(id); ok {
 		s.log.Info("order loaded from cache", zap.Int64("id", id))
 		return eo, nil
@@ -80,3 +197,94 @@ func (s *Service) GetExtendedOrder(ctx context.Context, id int64) (*models.Exten
 
 	return eo, nil
 }
+
+func (s *Service) UpdateExtendedOrder(ctx context.Context, eo *models.ExtendedOrder) error {
+	if err := s.repo.UpdateExtendedOrder(ctx, eo); err != nil {
+		s.log.Error("failed to update order", zap.Error(err), zap.Int64("id", eo.Order.ID))
+		return err
+	}
+
+	s.cache.Remove(eo.Order.ID)
+	s.forgetTracks(eo)
+	s.publish(events.OrderUpdated, eo.Order.ID, eo)
+
+	s.log.Info("order updated", zap.Int64("id", eo.Order.ID))
+
+	return nil
+}
+
+func (s *Service) UpdateOrderStatus(ctx context.Context, id int64, status int) error {
+	if err := s.repo.UpdateOrderStatus(ctx, id, status); err != nil {
+		s.log.Error("failed to update order status", zap.Error(err), zap.Int64("id", id))
+		return err
+	}
+
+	s.cache.Remove(id)
+	s.publish(events.OrderStatusChanged, id, map[string]int{"status": status})
+
+	s.log.Info("order status updated", zap.Int64("id", id), zap.Int("status", status))
+
+	return nil
+}
+
+func (s *Service) DeleteExtendedOrder(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteExtendedOrder(ctx, id); err != nil {
+		s.log.Error("failed to delete order", zap.Error(err), zap.Int64("id", id))
+		return err
+	}
+
+	s.cache.Remove(id)
+	s.publish(events.OrderDeleted, id, nil)
+
+	s.log.Info("order deleted", zap.Int64("id", id))
+
+	return nil
+}
+
+// EvictOrders убирает из кеша заказы, удалённые в обход сервиса.
+func (s *Service) EvictOrders(ids []int64) {
+	for _, id := range ids {
+		s.cache.Remove(id)
+	}
+}
+
+func (s *Service) GetOrderHistory(ctx context.Context, id int64) ([]*models.AuditEntry, error) {
+	entries, err := s.repo.Audit().GetByOrderID(ctx, nil, id)
+	if err != nil {
+		s.log.Error("failed to load order history", zap.Error(err), zap.Int64("id", id))
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ConvertPayment пересчитывает суммы оплаты в валюту currency по курсу на дату
+// оплаты. Пустая currency — базовая валюта из конфигурации.
+func (s *Service) ConvertPayment(ctx context.Context, p models.Payment, currency string) (models.Payment, error) {
+	if s.fx == nil {
+		return models.Payment{}, ErrFXDisabled
+	}
+
+	return s.fx.ConvertPayment(ctx, p, currency)
+}
+
+// GetExtendedOrderInCurrency возвращает копию заказа с суммами в валюте currency.
+// Закешированный заказ не изменяется.
+func (s *Service) GetExtendedOrderInCurrency(ctx context.Context, id int64, currency string) (*models.ExtendedOrder, error) {
+	if s.fx == nil {
+		return nil, ErrFXDisabled
+	}
+
+	eo, err := s.GetExtendedOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := s.fx.ConvertOrder(ctx, eo, currency)
+	if err != nil {
+		s.log.Warn("failed to convert order", zap.Error(err), zap.Int64("id", id), zap.String("currency", currency))
+		return nil, err
+	}
+
+	return converted, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"test-task/internal/mocks"
 	"test-task/internal/models"
+	"test-task/internal/repository"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,39 @@ func TestService_Create(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestService_CreateExistingDropsCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	var id int64 = 123
+	cached := &models.ExtendedOrder{Order: models.Order{ID: id, OrderUID: "uid"}}
+	reingested := &models.ExtendedOrder{Order: models.Order{ID: id, OrderUID: "uid"}, Items: []*models.Item{{ID: 1}}}
+	stored := &models.ExtendedOrder{Order: models.Order{ID: id, OrderUID: "uid"}, Items: []*models.Item{{ID: 1}, {ID: 2}}}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			GetLastExtendedOrders(gomock.Any(), 1).
+			Return([]*models.ExtendedOrder{cached}, nil),
+		mockRepo.EXPECT().
+			CreateExtendedOrder(gomock.Any(), reingested).
+			Return(nil),
+		mockRepo.EXPECT().
+			GetExtendedOrder(gomock.Any(), id).
+			Return(stored, nil),
+	)
+
+	assert.NoError(t, service.LoadRecentOrdersToCache(t.Context(), 1))
+	assert.NoError(t, service.CreateExtendedOrder(t.Context(), reingested))
+
+	eo, err := service.GetExtendedOrder(t.Context(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, eo)
+}
+
 func TestService_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -105,3 +139,139 @@ func TestService_LoadCache(t *testing.T) {
 		assert.Equal(t, eo, val, "Expected order to match cache value")
 	}
 }
+
+func TestService_UpdateStatusInvalidatesCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	var id int64 = 123
+	cached := &models.ExtendedOrder{Order: models.Order{ID: id}}
+	service.cache.Add(id, cached)
+
+	mockRepo.EXPECT().
+		UpdateOrderStatus(gomock.Any(), id, 202).
+		Return(nil)
+
+	err := service.UpdateOrderStatus(t.Context(), id, 202)
+
+	assert.NoError(t, err)
+
+	_, ok := service.cache.Get(id)
+	assert.False(t, ok, "Expected order to be evicted from cache")
+}
+
+func TestService_DeleteKeepsCacheOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	var id int64 = 123
+	cached := &models.ExtendedOrder{Order: models.Order{ID: id}}
+	service.cache.Add(id, cached)
+
+	mockRepo.EXPECT().
+		DeleteExtendedOrder(gomock.Any(), id).
+		Return(repository.ErrNotFound)
+
+	err := service.DeleteExtendedOrder(t.Context(), id)
+
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, ok := service.cache.Get(id)
+	assert.True(t, ok)
+}
+
+func TestService_GetInCurrencyWithoutFX(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	_, err := service.GetExtendedOrderInCurrency(t.Context(), 123, "EUR")
+
+	assert.ErrorIs(t, err, ErrFXDisabled)
+}
+
+func TestService_GetByTrackFromCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	item := &models.Item{TrackNumber: "WBIL1"}
+	eo := &models.ExtendedOrder{
+		Order: models.Order{ID: 1, TrackNumber: "WBIL"},
+		Items: []*models.Item{item, {TrackNumber: "WBIL2"}},
+	}
+
+	mockRepo.EXPECT().
+		GetExtendedOrdersByTrack(gomock.Any(), "WBIL1", maxTrackOrders).
+		Return([]*models.ExtendedOrder{eo}, nil).
+		Times(1)
+
+	for range 2 {
+		tracked, err := service.GetOrdersByTrack(t.Context(), "WBIL1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.TrackedOrder{{Order: eo, Items: []*models.Item{item}}}, tracked)
+	}
+}
+
+func TestService_GetByTrackAfterCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	first := &models.ExtendedOrder{Order: models.Order{ID: 1, TrackNumber: "WBIL"}}
+	second := &models.ExtendedOrder{Order: models.Order{ID: 2, TrackNumber: "WBIL"}}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			GetExtendedOrdersByTrack(gomock.Any(), "WBIL", maxTrackOrders).
+			Return([]*models.ExtendedOrder{first}, nil),
+		mockRepo.EXPECT().
+			CreateExtendedOrder(gomock.Any(), second).
+			Return(nil),
+		mockRepo.EXPECT().
+			GetExtendedOrdersByTrack(gomock.Any(), "WBIL", maxTrackOrders).
+			Return([]*models.ExtendedOrder{second, first}, nil),
+	)
+
+	_, err := service.GetOrdersByTrack(t.Context(), "WBIL")
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.CreateExtendedOrder(t.Context(), second))
+
+	tracked, err := service.GetOrdersByTrack(t.Context(), "WBIL")
+	assert.NoError(t, err)
+	assert.Len(t, tracked, 2)
+}
+
+func TestService_GetByTrackNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+
+	mockRepo.EXPECT().
+		GetExtendedOrdersByTrack(gomock.Any(), "none", maxTrackOrders).
+		Return([]*models.ExtendedOrder{}, nil)
+
+	_, err := service.GetOrdersByTrack(t.Context(), "none")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// maxTrackOrders ограничивает число заказов с одним трек-номером в ответе.
+const maxTrackOrders = 100
+
+// GetOrdersByTrack ищет заказы по трек-номеру заказа или позиции.
+// Если все найденные ранее заказы ещё лежат в кеше и по-прежнему
+// относятся к трек-номеру, ответ собирается из кеша без запроса к базе.
+func (s *Service) GetOrdersByTrack(ctx context.Context, track string) ([]*models.TrackedOrder, error) {
+	if ids, ok := s.tracks.Get(track); ok {
+		if tracked, ok := s.cachedTrack(track, ids); ok {
+			s.log.Info("track loaded from cache", zap.String("track_number", track))
+			return tracked, nil
+		}
+		s.tracks.Remove(track)
+	}
+
+	eos, err := s.repo.GetExtendedOrdersByTrack(ctx, track, maxTrackOrders)
+	if err != nil {
+		s.log.Error("failed to load orders by track", zap.Error(err), zap.String("track_number", track))
+		return nil, err
+	}
+	if len(eos) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	tracked := make([]*models.TrackedOrder, 0, len(eos))
+	ids := make([]int64, 0, len(eos))
+	for _, eo := range eos {
+		if t, ok := models.MatchTrack(eo, track); ok {
+			tracked = append(tracked, t)
+		}
+		ids = append(ids, eo.Order.ID)
+		s.cache.Add(eo.Order.ID, eo)
+	}
+	s.tracks.Add(track, ids)
+
+	s.log.Info("track loaded from db", zap.String("track_number", track), zap.Int("orders", len(eos)))
+
+	return tracked, nil
+}
+
+func (s *Service) cachedTrack(track string, ids []int64) ([]*models.TrackedOrder, bool) {
+	tracked := make([]*models.TrackedOrder, 0, len(ids))
+	for _, id := range ids {
+		eo, ok := s.cache.Get(id)
+		if !ok {
+			return nil, false
+		}
+		t, ok := models.MatchTrack(eo, track)
+		if !ok {
+			return nil, false
+		}
+		tracked = append(tracked, t)
+	}
+	return tracked, true
+}
+
+// forgetTracks убирает из кеша трек-номера заказа eo: к ним мог
+// добавиться новый заказ, которого нет в закешированном списке.
+func (s *Service) forgetTracks(eo *models.ExtendedOrder) {
+	s.tracks.Remove(eo.Order.TrackNumber)
+	for _, item := range eo.Items {
+		s.tracks.Remove(item.TrackNumber)
+	}
+}
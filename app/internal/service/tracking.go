@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+var ErrTrackingDisabled = errors.New("delivery tracking is not configured")
+
+// WithTracking подключает события перевозчиков: хронологию доставки
+// и последнее состояние в ответе с заказом.
+func (s *Service) WithTracking(repo repository.DeliveryEventRepository) *Service {
+	s.tracking = repo
+	return s
+}
+
+func (s *Service) AddDeliveryEvents(ctx context.Context, events []*models.DeliveryEvent) error {
+	if s.tracking == nil {
+		return ErrTrackingDisabled
+	}
+
+	added, err := s.tracking.AddEvents(ctx, events)
+	if err != nil {
+		s.log.Error("failed to save delivery events", zap.Error(err))
+		return err
+	}
+
+	s.log.Info("delivery events saved", zap.Int("received", len(events)), zap.Int("added", added))
+
+	if added > 0 {
+		tracks := make([]string, 0, len(events))
+		for _, e := range events {
+			tracks = append(tracks, e.TrackNumber)
+			s.latest.Remove(e.TrackNumber)
+		}
+		s.notifyTracks(tracks)
+	}
+
+	return nil
+}
+
+// GetOrderTracking возвращает события по трек-номерам заказа и его позиций
+// в порядке времени.
+func (s *Service) GetOrderTracking(ctx context.Context, id int64) ([]*models.DeliveryEvent, error) {
+	if s.tracking == nil {
+		return nil, ErrTrackingDisabled
+	}
+
+	eo, err := s.getExtendedOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.tracking.Timeline(ctx, orderTracks(eo))
+	if err != nil {
+		s.log.Error("failed to load delivery events", zap.Error(err), zap.Int64("id", id))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// withTracking возвращает копию заказа с последним событием доставки.
+// Закешированный заказ не изменяется.
+func (s *Service) withTracking(ctx context.Context, eo *models.ExtendedOrder) (*models.ExtendedOrder, error) {
+	tracked, err := s.withTrackingAll(ctx, []*models.ExtendedOrder{eo})
+	if err != nil {
+		return nil, err
+	}
+	return tracked[0], nil
+}
+
+// withTrackingAll — withTracking для нескольких заказов одним запросом.
+// Последние события берутся из кеша по трек-номерам, в базе читаются
+// только недостающие; AddDeliveryEvents убирает из кеша изменившиеся.
+func (s *Service) withTrackingAll(ctx context.Context, eos []*models.ExtendedOrder) ([]*models.ExtendedOrder, error) {
+	if s.tracking == nil || len(eos) == 0 {
+		return eos, nil
+	}
+
+	latest := make(map[string]*models.DeliveryEvent)
+	var missing []string
+	for _, eo := range eos {
+		for _, track := range orderTracks(eo) {
+			if _, ok := latest[track]; ok || slices.Contains(missing, track) {
+				continue
+			}
+			if e, ok := s.latest.Get(track); ok {
+				latest[track] = e
+			} else {
+				missing = append(missing, track)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		loaded, err := s.tracking.LatestByTrack(ctx, missing)
+		if err != nil {
+			s.log.Error("failed to load delivery state", zap.Error(err), zap.Int("orders", len(eos)))
+			return nil, err
+		}
+		for _, track := range missing {
+			latest[track] = loaded[track]
+			s.latest.Add(track, loaded[track])
+		}
+	}
+
+	tracked := make([]*models.ExtendedOrder, 0, len(eos))
+	for _, eo := range eos {
+		var event *models.DeliveryEvent
+		for _, track := range orderTracks(eo) {
+			if e := latest[track]; e != nil && (event == nil || laterEvent(e, event)) {
+				event = e
+			}
+		}
+		if event == nil {
+			tracked = append(tracked, eo)
+			continue
+		}
+
+		t := *eo
+		t.Delivery.Tracking = event
+		tracked = append(tracked, &t)
+	}
+
+	return tracked, nil
+}
+
+// laterEvent сравнивает события в порядке хронологии доставки.
+func laterEvent(a, b *models.DeliveryEvent) bool {
+	if !a.OccurredAt.Equal(b.OccurredAt) {
+		return a.OccurredAt.After(b.OccurredAt)
+	}
+	return a.ID > b.ID
+}
+
+func orderTracks(eo *models.ExtendedOrder) []string {
+	tracks := []string{eo.Order.TrackNumber}
+	seen := map[string]bool{eo.Order.TrackNumber: true}
+	for _, item := range eo.Items {
+		if !seen[item.TrackNumber] {
+			seen[item.TrackNumber] = true
+			tracks = append(tracks, item.TrackNumber)
+		}
+	}
+	return tracks
+}
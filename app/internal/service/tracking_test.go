@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-task/internal/mocks"
+	"test-task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// fakeEvents хранит события в памяти, отбирая их по трек-номерам.
+type fakeEvents struct {
+	events []*models.DeliveryEvent
+	tracks []string
+	calls  int
+}
+
+func (f *fakeEvents) AddEvents(_ context.Context, events []*models.DeliveryEvent) (int, error) {
+	f.events = append(f.events, events...)
+	return len(events), nil
+}
+
+func (f *fakeEvents) Timeline(_ context.Context, tracks []string) ([]*models.DeliveryEvent, error) {
+	f.tracks = tracks
+	var events []*models.DeliveryEvent
+	for _, e := range f.events {
+		for _, track := range tracks {
+			if e.TrackNumber == track {
+				events = append(events, e)
+			}
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeEvents) LatestByTrack(ctx context.Context, tracks []string) (map[string]*models.DeliveryEvent, error) {
+	f.calls++
+	events, _ := f.Timeline(ctx, tracks)
+	latest := make(map[string]*models.DeliveryEvent)
+	for _, e := range events {
+		if l, ok := latest[e.TrackNumber]; !ok || laterEvent(e, l) {
+			latest[e.TrackNumber] = e
+		}
+	}
+	return latest, nil
+}
+
+func TestService_GetWithTracking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewService(nil, mocks.NewMockExtendedOrderRepository(ctrl), 10, zap.NewNop())
+	events := &fakeEvents{}
+	service.WithTracking(events)
+
+	eo := &models.ExtendedOrder{
+		Order: models.Order{ID: 1, TrackNumber: "WBIL"},
+		Items: []*models.Item{{TrackNumber: "WBIL"}, {TrackNumber: "WBIL2"}},
+	}
+	service.cache.Add(eo.Order.ID, eo)
+
+	got, err := service.GetExtendedOrder(t.Context(), eo.Order.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Delivery.Tracking)
+
+	event := &models.DeliveryEvent{
+		TrackNumber: "WBIL2",
+		Carrier:     "cdek",
+		Status:      "in_transit",
+		OccurredAt:  time.Date(2025, time.January, 2, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, service.AddDeliveryEvents(t.Context(), []*models.DeliveryEvent{event}))
+
+	got, err = service.GetExtendedOrder(t.Context(), eo.Order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, event, got.Delivery.Tracking)
+	// из базы перечитан только трек-номер с новым событием
+	assert.Equal(t, []string{"WBIL2"}, events.tracks)
+	assert.Equal(t, 2, events.calls)
+
+	got, err = service.GetExtendedOrder(t.Context(), eo.Order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, event, got.Delivery.Tracking)
+	assert.Equal(t, 2, events.calls, "tracking served from cache")
+
+	cached, _ := service.cache.Get(eo.Order.ID)
+	assert.Nil(t, cached.Delivery.Tracking)
+
+	timeline, err := service.GetOrderTracking(t.Context(), eo.Order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.DeliveryEvent{event}, timeline)
+}
+
+func TestService_TrackingDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewService(nil, mocks.NewMockExtendedOrderRepository(ctrl), 10, zap.NewNop())
+
+	_, err := service.GetOrderTracking(t.Context(), 1)
+	assert.ErrorIs(t, err, ErrTrackingDisabled)
+}
+
+func TestService_EachOrderTracking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExtendedOrderRepository(ctrl)
+	service := NewService(nil, mockRepo, 10, zap.NewNop())
+	events := &fakeEvents{}
+	service.WithTracking(events)
+
+	at := func(h int) time.Time { return time.Date(2025, time.January, 2, h, 0, 0, 0, time.UTC) }
+	events.events = []*models.DeliveryEvent{
+		{ID: 1, TrackNumber: "WBIL", Status: "accepted", OccurredAt: at(8)},
+		{ID: 2, TrackNumber: "WBIL2", Status: "in_transit", OccurredAt: at(10)},
+		{ID: 3, TrackNumber: "WBIL", Status: "in_transit", OccurredAt: at(9)},
+	}
+
+	page := []*models.ExtendedOrder{
+		{Order: models.Order{ID: 1, TrackNumber: "WBIL"}, Items: []*models.Item{{TrackNumber: "WBIL2"}}},
+		{Order: models.Order{ID: 2, TrackNumber: "WBIL"}},
+		{Order: models.Order{ID: 3, TrackNumber: "NONE"}},
+	}
+	mockRepo.EXPECT().ListExtendedOrders(gomock.Any(), gomock.Any()).Return(page, nil)
+
+	var got []*models.ExtendedOrder
+	err := service.EachOrder(t.Context(), models.OrderListFilter{}, func(eo *models.ExtendedOrder) error {
+		got = append(got, eo)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, got, 3)
+	assert.Equal(t, int64(2), got[0].Delivery.Tracking.ID)
+	assert.Equal(t, int64(3), got[1].Delivery.Tracking.ID)
+	assert.Nil(t, got[2].Delivery.Tracking)
+	assert.Equal(t, 1, events.calls, "one tracking query per page")
+}
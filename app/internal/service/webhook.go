@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+
+	"test-task/internal/models"
+	"test-task/internal/repository"
+	"test-task/internal/webhook"
+
+	"go.uber.org/zap"
+)
+
+// WithWebhooks подключает управление подписками на вебхуки. Отправкой
+// занимается webhook.Dispatcher, подписанный на события сервиса.
+func (s *Service) WithWebhooks(repo repository.WebhookRepository) *Service {
+	s.webhooks = repo
+	return s
+}
+
+// CreateWebhook создаёт подписку, при пустом секрете генерирует его.
+// Секрет возвращается только здесь.
+func (s *Service) CreateWebhook(ctx context.Context, sub *models.WebhookSubscription) error {
+	if sub.Secret == "" {
+		sub.Secret = webhook.NewSecret()
+	}
+
+	if err := s.webhooks.CreateSubscription(ctx, sub); err != nil {
+		s.log.Error("failed to create webhook", zap.Error(err))
+		return err
+	}
+
+	s.log.Info("webhook created", zap.Int64("webhook_id", sub.ID), zap.Strings("events", sub.Events))
+
+	return nil
+}
+
+func (s *Service) UpdateWebhook(ctx context.Context, sub *models.WebhookSubscription) error {
+	if err := s.webhooks.UpdateSubscription(ctx, sub); err != nil {
+		s.log.Error("failed to update webhook", zap.Int64("webhook_id", sub.ID), zap.Error(err))
+		return err
+	}
+
+	sub.Secret = ""
+	s.log.Info("webhook updated", zap.Int64("webhook_id", sub.ID))
+
+	return nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	if err := s.webhooks.DeleteSubscription(ctx, id); err != nil {
+		s.log.Error("failed to delete webhook", zap.Int64("webhook_id", id), zap.Error(err))
+		return err
+	}
+
+	s.log.Info("webhook deleted", zap.Int64("webhook_id", id))
+
+	return nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	sub, err := s.webhooks.GetSubscription(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get webhook", zap.Int64("webhook_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	subs, err := s.webhooks.ListSubscriptions(ctx)
+	if err != nil {
+		s.log.Error("failed to list webhooks", zap.Error(err))
+		return nil, err
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+func (s *Service) ListWebhookDeliveries(
+	ctx context.Context,
+	id int64,
+	status string,
+	limit, offset int,
+) ([]*models.WebhookDelivery, error) {
+	deliveries, err := s.webhooks.ListDeliveries(ctx, id, status, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list webhook deliveries", zap.Int64("webhook_id", id), zap.Error(err))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook ставит в очередь новую доставку с телом доставки id.
+func (s *Service) RedeliverWebhook(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	d, err := s.webhooks.Redeliver(ctx, id)
+	if err != nil {
+		s.log.Error("failed to redeliver webhook", zap.Int64("delivery_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	s.log.Info("webhook redelivery queued", zap.Int64("delivery_id", id), zap.Int64("new_delivery_id", d.ID))
+
+	return d, nil
+}
@@ -0,0 +1,126 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"test-task/internal/events"
+	"test-task/internal/models"
+)
+
+// Event — новый заказ в потоке. ID растут монотонно.
+type Event struct {
+	ID    uint64
+	Order models.OrderSummary
+}
+
+// Subscription — подписка клиента. Events закрывается, если клиент
+// не успевает читать и его буфер переполнился.
+type Subscription struct {
+	Events <-chan Event
+
+	events chan Event
+	broker *Broker
+}
+
+// Broker рассылает сводки новых заказов подписчикам и хранит последние
+// события в кольцевом буфере для продолжения с Last-Event-ID.
+type Broker struct {
+	mu     sync.Mutex
+	ring   []Event
+	start  int // индекс самого старого события в ring
+	size   int
+	nextID uint64
+
+	subs       map[*Subscription]struct{}
+	clientSize int
+}
+
+// NewBroker создаёт брокер с буфером на bufferSize последних событий
+// и очередью на clientSize событий для каждого клиента.
+func NewBroker(bufferSize, clientSize int) *Broker {
+	return &Broker{
+		ring: make([]Event, bufferSize),
+		// ID начинаются с текущего времени, чтобы Last-Event-ID, полученный
+		// до перезапуска, был меньше новых ID
+		nextID:     uint64(time.Now().UnixMicro()),
+		subs:       make(map[*Subscription]struct{}),
+		clientSize: clientSize,
+	}
+}
+
+// Handle — обработчик events.Bus, публикует созданные заказы.
+func (b *Broker) Handle(e events.Event) {
+	if e.Type != events.OrderCreated {
+		return
+	}
+	if eo, ok := e.Data.(*models.ExtendedOrder); ok {
+		b.Publish(models.Summarize(eo))
+	}
+}
+
+// Publish добавляет событие в буфер и раздаёт подписчикам, не блокируясь:
+// подписчик с переполненной очередью отключается.
+func (b *Broker) Publish(order models.OrderSummary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := Event{ID: b.nextID, Order: order}
+	b.nextID++
+
+	if len(b.ring) > 0 {
+		if b.size < len(b.ring) {
+			b.ring[(b.start+b.size)%len(b.ring)] = e
+			b.size++
+		} else {
+			b.ring[b.start] = e
+			b.start = (b.start + 1) % len(b.ring)
+		}
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.events <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe подписывает клиента и возвращает события из буфера с ID больше
+// lastID. Нулевой lastID — только новые события.
+func (b *Broker) Subscribe(lastID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	if lastID > 0 {
+		for i := 0; i < b.size; i++ {
+			e := b.ring[(b.start+i)%len(b.ring)]
+			if e.ID > lastID {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, b.clientSize)
+	sub := &Subscription{Events: ch, events: ch, broker: b}
+	b.subs[sub] = struct{}{}
+
+	return sub, backlog
+}
+
+// Close отписывает клиента.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.events)
+	}
+}
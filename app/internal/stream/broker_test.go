@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"testing"
+
+	"test-task/internal/events"
+	"test-task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uids(events []Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, e.Order.OrderUID)
+	}
+	return out
+}
+
+func TestBrokerResume(t *testing.T) {
+	b := NewBroker(3, 10)
+
+	sub, backlog := b.Subscribe(0)
+	defer sub.Close()
+	assert.Empty(t, backlog)
+
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		b.Publish(models.OrderSummary{OrderUID: uid})
+	}
+
+	var got []Event
+	for range 4 {
+		got = append(got, <-sub.Events)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, uids(got))
+	assert.Equal(t, got[0].ID+3, got[3].ID)
+
+	// в буфере остались b, c, d
+	_, backlog = b.Subscribe(got[1].ID)
+	assert.Equal(t, []string{"c", "d"}, uids(backlog))
+
+	_, backlog = b.Subscribe(got[0].ID - 1)
+	assert.Equal(t, []string{"b", "c", "d"}, uids(backlog))
+
+	_, backlog = b.Subscribe(got[3].ID)
+	assert.Empty(t, backlog)
+}
+
+func TestBrokerSlowClient(t *testing.T) {
+	b := NewBroker(10, 2)
+
+	slow, _ := b.Subscribe(0)
+	fast, _ := b.Subscribe(0)
+	defer fast.Close()
+
+	for _, uid := range []string{"a", "b", "c"} {
+		b.Publish(models.OrderSummary{OrderUID: uid})
+		<-fast.Events
+	}
+
+	// медленный клиент отключён после переполнения, публикация не блокировалась
+	var got []Event
+	for e := range slow.Events {
+		got = append(got, e)
+	}
+	assert.Equal(t, []string{"a", "b"}, uids(got))
+
+	// повторное закрытие безопасно
+	slow.Close()
+}
+
+func TestBrokerHandle(t *testing.T) {
+	b := NewBroker(10, 10)
+	sub, _ := b.Subscribe(0)
+	defer sub.Close()
+
+	eo := &models.ExtendedOrder{Order: models.Order{ID: 1, OrderUID: "a", Entry: "WBIL"}}
+	b.Handle(events.Event{Type: events.OrderUpdated, Data: eo})
+	b.Handle(events.Event{Type: events.OrderCreated, Data: eo})
+
+	e := <-sub.Events
+	require.Equal(t, "a", e.Order.OrderUID)
+	assert.Equal(t, "WBIL", e.Order.Entry)
+	assert.Empty(t, sub.Events)
+}
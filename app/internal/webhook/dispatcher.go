@@ -0,0 +1,311 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"test-task/internal/auth"
+	"test-task/internal/events"
+	"test-task/internal/models"
+	"test-task/internal/redact"
+	"test-task/internal/repository"
+	"test-task/internal/retry"
+
+	"go.uber.org/zap"
+)
+
+// MaxBackoff ограничивает паузу между попытками доставки.
+const MaxBackoff = time.Hour
+
+type Config struct {
+	// Workers — число одновременных доставок.
+	Workers int
+	// Interval — период опроса ожидающих доставок, в том числе переотправок.
+	Interval time.Duration
+	// Timeout — таймаут одного запроса.
+	Timeout time.Duration
+	// Lease — через сколько после последней попытки доставка, зависшая
+	// в отправке, снова берётся в работу. Должен быть больше MaxBackoff.
+	Lease time.Duration
+	// QueueSize — размер очереди событий на запись в базу.
+	QueueSize int
+}
+
+// Dispatcher записывает события заказов в доставки подписчикам и отправляет
+// их POST-запросами с подписью HMAC-SHA256. Доставки берутся из базы
+// с SKIP LOCKED, поэтому несколько реплик не отправляют одну доставку дважды.
+type Dispatcher struct {
+	repo     repository.WebhookRepository
+	client   *http.Client
+	cfg      Config
+	redactor *redact.Redactor
+	queue    chan events.Event
+	wake     chan struct{}
+	log      *zap.Logger
+	now      func() time.Time
+}
+
+func New(repo repository.WebhookRepository, cfg Config, log *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		queue:  make(chan events.Event, cfg.QueueSize),
+		wake:   make(chan struct{}, 1),
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+// WithRedactor задаёт правила скрытия полей заказа в событиях. Подписчики
+// получают заказ как клиент с ролью viewer; без правил персональные данные
+// получателя скрываются по умолчанию.
+func (d *Dispatcher) WithRedactor(r *redact.Redactor) *Dispatcher {
+	d.redactor = r
+	return d
+}
+
+// Handle ставит событие в очередь на запись и не блокирует публикующего.
+// При переполненной очереди событие теряется с ошибкой в логе.
+func (d *Dispatcher) Handle(e events.Event) {
+	select {
+	case d.queue <- e:
+	default:
+		d.log.Error("webhook queue is full, event dropped",
+			zap.String("type", e.Type),
+			zap.Int64("order_id", e.OrderID),
+		)
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.enqueueLoop(ctx)
+	}()
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	sem := make(chan struct{}, d.cfg.Workers)
+
+	for {
+		if err := d.dispatch(ctx, sem, &wg); err != nil && ctx.Err() == nil {
+			d.log.Error("error on claiming webhook deliveries", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			d.log.Info("webhook dispatcher stopped by context")
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) enqueueLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			d.enqueue(ctx, e)
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, e events.Event) {
+	// тело события хранится в webhook_deliveries, персональные данные
+	// скрываются до записи
+	if eo, ok := e.Data.(*models.ExtendedOrder); ok {
+		e.Data = d.redactor.Role(auth.RoleViewer).Order(eo)
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		d.log.Error("error on encoding webhook event", zap.String("type", e.Type), zap.Error(err))
+		return
+	}
+
+	var n int64
+	if err := retry.Do(ctx, 3, func(attempt int) error {
+		n, err = d.repo.EnqueueEvent(ctx, e.Type, e.OrderID, payload)
+		return err
+	}); err != nil {
+		d.log.Error("error on enqueueing webhook event",
+			zap.String("type", e.Type),
+			zap.Int64("order_id", e.OrderID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if n > 0 {
+		d.notify()
+	}
+}
+
+// notify будит цикл отправки, не дожидаясь тикера.
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch забирает столько доставок, сколько свободно обработчиков.
+func (d *Dispatcher) dispatch(ctx context.Context, sem chan struct{}, wg *sync.WaitGroup) error {
+	free := cap(sem) - len(sem)
+	if free == 0 {
+		return nil
+	}
+
+	deliveries, err := d.repo.ClaimDeliveries(ctx, free, d.cfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	for _, dl := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+				d.notify()
+			}()
+			d.deliver(ctx, dl)
+		}()
+	}
+
+	return nil
+}
+
+// deliver отправляет доставку с повторами по настройкам подписки
+// и сохраняет результат каждой попытки.
+func (d *Dispatcher) deliver(ctx context.Context, dl *models.WebhookDelivery) {
+	sub := dl.Subscription
+
+	// доставка могла зависнуть в отправке с частью попыток
+	remaining := sub.MaxAttempts - dl.Attempts
+	if remaining <= 0 {
+		dl.Status = models.WebhookFailed
+		d.save(ctx, dl)
+		return
+	}
+
+	r := retry.New(
+		retry.WithMaxAttempts(remaining),
+		retry.WithBackoff(retry.ExponentialBackoff{
+			Base:   time.Duration(sub.BackoffSeconds) * time.Second,
+			Factor: 2,
+			Max:    MaxBackoff,
+			Jitter: 0.1,
+		}),
+		retry.WithIsRetryableFunc(isRetryable),
+	)
+
+	err := r.Do(ctx, func(attempt int) error {
+		status, err := d.send(ctx, dl)
+
+		dl.Attempts++
+		dl.ResponseStatus = status
+		dl.LastError = ""
+		if err != nil {
+			dl.LastError = err.Error()
+		} else {
+			now := d.now().UTC()
+			dl.Status = models.WebhookDelivered
+			dl.DeliveredAt = &now
+		}
+		d.save(ctx, dl)
+
+		return err
+	})
+	if err == nil {
+		return
+	}
+
+	// при остановке доставка остаётся в отправке и после Lease
+	// достаётся следующему запуску
+	if ctx.Err() != nil {
+		return
+	}
+
+	dl.Status = models.WebhookFailed
+	d.save(ctx, dl)
+
+	d.log.Warn("webhook delivery failed",
+		zap.Int64("delivery_id", dl.ID),
+		zap.Int64("subscription_id", dl.SubscriptionID),
+		zap.Int("attempts", dl.Attempts),
+		zap.Error(err),
+	)
+}
+
+func (d *Dispatcher) save(ctx context.Context, dl *models.WebhookDelivery) {
+	if err := d.repo.UpdateDelivery(ctx, dl); err != nil {
+		d.log.Error("error on saving webhook delivery", zap.Int64("delivery_id", dl.ID), zap.Error(err))
+	}
+}
+
+// statusError — ответ получателя не 2xx.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected response status %d", e.code)
+}
+
+// isRetryable не повторяет ответы 4xx, кроме 408 и 429: запрос не изменится.
+func isRetryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) && se.code >= 400 && se.code < 500 {
+		return se.code == http.StatusRequestTimeout || se.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// send выполняет одну попытку и возвращает код ответа, если он получен.
+func (d *Dispatcher) send(ctx context.Context, dl *models.WebhookDelivery) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.Subscription.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, dl.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(dl.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(dl.Subscription.Secret, timestamp, dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// тело ответа не нужно, но соединение переиспользуется только после вычитывания
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, &statusError{code: status}
+	}
+	return &status, nil
+}
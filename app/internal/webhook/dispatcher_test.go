@@ -0,0 +1,251 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test-task/internal/events"
+	"test-task/internal/models"
+	"test-task/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const secret = "0123456789abcdef"
+
+// fakeRepo хранит доставки в памяти. Подписка одна.
+type fakeRepo struct {
+	repository.WebhookRepository
+
+	mu         sync.Mutex
+	sub        *models.WebhookSubscription
+	deliveries []*models.WebhookDelivery
+	updates    []models.WebhookDelivery
+}
+
+func (r *fakeRepo) EnqueueEvent(ctx context.Context, eventType string, orderID int64, payload []byte) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = append(r.deliveries, &models.WebhookDelivery{
+		ID:             int64(len(r.deliveries) + 1),
+		SubscriptionID: r.sub.ID,
+		EventType:      eventType,
+		OrderID:        orderID,
+		Payload:        payload,
+		Status:         models.WebhookPending,
+	})
+	return 1, nil
+}
+
+func (r *fakeRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed []*models.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == models.WebhookPending && len(claimed) < limit {
+			d.Status = models.WebhookSending
+			claimed = append(claimed, &models.WebhookDelivery{
+				ID:           d.ID,
+				EventType:    d.EventType,
+				Payload:      d.Payload,
+				Status:       d.Status,
+				Subscription: r.sub,
+			})
+		}
+	}
+	return claimed, nil
+}
+
+func (r *fakeRepo) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.updates = append(r.updates, *d)
+	for _, stored := range r.deliveries {
+		if stored.ID == d.ID {
+			stored.Status = d.Status
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) last() models.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.updates[len(r.updates)-1]
+}
+
+func newDispatcher(repo *fakeRepo) *Dispatcher {
+	return New(repo, Config{
+		Workers:   2,
+		Interval:  time.Hour,
+		Timeout:   time.Second,
+		Lease:     2 * time.Hour,
+		QueueSize: 10,
+	}, zap.NewNop())
+}
+
+// server отвечает кодами codes по очереди, последним — на все остальные запросы.
+func server(t *testing.T, codes ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		assert.True(t, Verify(secret, timestamp, body, r.Header.Get(HeaderSignature)))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		n := int(calls.Add(1))
+		w.WriteHeader(codes[min(n, len(codes))-1])
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func delivery(url string, maxAttempts int) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:        1,
+		EventType: events.OrderCreated,
+		Payload:   []byte(`{"type":"order.created","order_id":1}`),
+		Status:    models.WebhookSending,
+		Subscription: &models.WebhookSubscription{
+			ID:          1,
+			URL:         url,
+			Secret:      secret,
+			MaxAttempts: maxAttempts,
+		},
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name        string
+		codes       []int
+		maxAttempts int
+		status      string
+		attempts    int
+		response    int
+	}{
+		{"delivered", []int{http.StatusOK}, 3, models.WebhookDelivered, 1, http.StatusOK},
+		{"retried", []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent}, 5, models.WebhookDelivered, 3, http.StatusNoContent},
+		{"too many requests", []int{http.StatusTooManyRequests, http.StatusOK}, 5, models.WebhookDelivered, 2, http.StatusOK},
+		{"client error", []int{http.StatusBadRequest}, 5, models.WebhookFailed, 1, http.StatusBadRequest},
+		{"attempts exhausted", []int{http.StatusServiceUnavailable}, 2, models.WebhookFailed, 2, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := server(t, tt.codes...)
+			repo := &fakeRepo{}
+
+			newDispatcher(repo).deliver(t.Context(), delivery(srv.URL, tt.maxAttempts))
+
+			got := repo.last()
+			assert.Equal(t, tt.status, got.Status)
+			assert.Equal(t, tt.attempts, got.Attempts)
+			assert.Equal(t, tt.attempts, int(calls.Load()))
+			require.NotNil(t, got.ResponseStatus)
+			assert.Equal(t, tt.response, *got.ResponseStatus)
+			assert.Equal(t, tt.status == models.WebhookDelivered, got.DeliveredAt != nil)
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv, _ := server(t, http.StatusOK)
+		srv.Close()
+		repo := &fakeRepo{}
+
+		newDispatcher(repo).deliver(t.Context(), delivery(srv.URL, 1))
+
+		got := repo.last()
+		assert.Equal(t, models.WebhookFailed, got.Status)
+		assert.Nil(t, got.ResponseStatus)
+		assert.NotEmpty(t, got.LastError)
+	})
+
+	t.Run("attempts left from lease", func(t *testing.T) {
+		srv, calls := server(t, http.StatusOK)
+		repo := &fakeRepo{}
+
+		dl := delivery(srv.URL, 2)
+		dl.Attempts = 2
+		newDispatcher(repo).deliver(t.Context(), dl)
+
+		assert.Equal(t, models.WebhookFailed, repo.last().Status)
+		assert.Zero(t, calls.Load())
+	})
+}
+
+func TestRun(t *testing.T) {
+	srv, calls := server(t, http.StatusOK)
+	repo := &fakeRepo{sub: &models.WebhookSubscription{ID: 1, URL: srv.URL, Secret: secret, MaxAttempts: 1}}
+	d := newDispatcher(repo)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	bus := events.NewBus()
+	bus.Subscribe(d.Handle)
+	bus.Publish(events.Event{Type: events.OrderDeleted, OrderID: 7})
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return len(repo.updates) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	got := repo.last()
+	assert.Equal(t, models.WebhookDelivered, got.Status)
+	assert.JSONEq(t, `{"type":"order.deleted","order_id":7,"occurred_at":"0001-01-01T00:00:00Z"}`, string(got.Payload))
+}
+
+func TestDispatcher_EnqueueRedactsOrder(t *testing.T) {
+	repo := &fakeRepo{sub: &models.WebhookSubscription{ID: 1}}
+	d := newDispatcher(repo)
+
+	eo := &models.ExtendedOrder{
+		Order:    models.Order{ID: 7, OrderUID: "b563feb7b2b84b6test"},
+		Delivery: models.Delivery{Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin"},
+	}
+	d.enqueue(t.Context(), events.Event{Type: events.OrderCreated, OrderID: 7, Data: eo})
+
+	require.Len(t, repo.deliveries, 1)
+	payload := string(repo.deliveries[0].Payload)
+	assert.NotContains(t, payload, "Test Testov")
+	assert.NotContains(t, payload, "+9720000000")
+	assert.Contains(t, payload, "Kiryat Mozkin")
+	assert.Equal(t, "Test Testov", eo.Delivery.Name, "event data must not change")
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"order.created"}`)
+	signature := Sign(secret, 1700000000, body)
+
+	assert.True(t, Verify(secret, 1700000000, body, signature))
+	assert.False(t, Verify(secret, 1700000001, body, signature))
+	assert.False(t, Verify("other", 1700000000, body, signature))
+	assert.False(t, Verify(secret, 1700000000, []byte(`{}`), signature))
+	assert.Len(t, NewSecret(), 64)
+}
@@ -0,0 +1,2 @@
+DROP TABLE items_daily_rollup;
+DROP TABLE orders_daily_rollup;
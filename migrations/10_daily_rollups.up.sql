@@ -0,0 +1,51 @@
+-- Дневные агрегаты неудалённых заказов для аналитики. Обновляются в той же
+-- транзакции, что и заказы; суммы — в валюте оплаты.
+CREATE TABLE orders_daily_rollup (
+    day DATE NOT NULL,
+    delivery_service TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    bank TEXT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    orders BIGINT NOT NULL DEFAULT 0,
+    items BIGINT NOT NULL DEFAULT 0,
+    revenue NUMERIC(20,4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, delivery_service, provider, bank, currency)
+);
+
+CREATE TABLE items_daily_rollup (
+    day DATE NOT NULL,
+    brand TEXT NOT NULL,
+    nm_id INT NOT NULL,
+    -- нижняя граница скидки с шагом 10%: 0, 10, ..., 90
+    sale_bucket INT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    quantity BIGINT NOT NULL DEFAULT 0,
+    revenue NUMERIC(20,4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, brand, nm_id, sale_bucket, currency)
+);
+
+CREATE INDEX items_daily_rollup_nm_id_idx ON items_daily_rollup (nm_id, day);
+
+-- Начальное заполнение по уже существующим заказам.
+INSERT INTO orders_daily_rollup (day, delivery_service, provider, bank, currency, orders, items, revenue)
+SELECT
+    o.date_created::DATE, o.delivery_service, p.provider, p.bank, p.currency,
+    count(*), COALESCE(sum(ic.items), 0), sum(p.amount)
+FROM orders AS o
+INNER JOIN payment AS p ON p.id = o.payment_id
+CROSS JOIN LATERAL (
+    SELECT count(*) AS items FROM items AS i
+    WHERE i.order_id = o.id AND i.order_date_created = o.date_created AND i.deleted_at IS NULL
+) AS ic
+WHERE o.deleted_at IS NULL
+GROUP BY 1, 2, 3, 4, 5;
+
+INSERT INTO items_daily_rollup (day, brand, nm_id, sale_bucket, currency, quantity, revenue)
+SELECT
+    o.date_created::DATE, i.brand, i.nm_id, (i.sale / 10) * 10, p.currency,
+    count(*), sum(i.total_price)
+FROM orders AS o
+INNER JOIN payment AS p ON p.id = o.payment_id
+INNER JOIN items AS i ON i.order_id = o.id AND i.order_date_created = o.date_created
+WHERE o.deleted_at IS NULL AND i.deleted_at IS NULL
+GROUP BY 1, 2, 3, 4, 5;
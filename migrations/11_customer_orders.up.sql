@@ -0,0 +1,2 @@
+CREATE INDEX orders_customer_id_idx ON orders (customer_id, date_created DESC, id DESC)
+    WHERE deleted_at IS NULL;
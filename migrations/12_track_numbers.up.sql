@@ -0,0 +1,2 @@
+CREATE INDEX orders_track_number_idx ON orders (track_number) WHERE deleted_at IS NULL;
+CREATE INDEX items_track_number_idx ON items (track_number) WHERE deleted_at IS NULL;
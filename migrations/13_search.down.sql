@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS orders_delivery_id_idx;
+DROP INDEX IF EXISTS delivery_phone_trgm_idx;
+DROP INDEX IF EXISTS delivery_email_trgm_idx;
+DROP INDEX IF EXISTS delivery_address_trgm_idx;
+DROP INDEX IF EXISTS delivery_name_trgm_idx;
+DROP INDEX IF EXISTS delivery_city_trgm_idx;
+DROP INDEX IF EXISTS items_brand_trgm_idx;
+DROP INDEX IF EXISTS items_name_trgm_idx;
+
+ALTER TABLE delivery DROP COLUMN IF EXISTS private_search_vector;
+ALTER TABLE delivery DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
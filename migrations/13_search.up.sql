@@ -0,0 +1,27 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Полнотекстовый поиск: позиции по названию и бренду, доставка по городу.
+-- Персональные данные получателя вынесены в отдельный вектор, чтобы искать
+-- по ним только для привилегированных запросов.
+ALTER TABLE items ADD COLUMN search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', name || ' ' || brand)) STORED;
+
+ALTER TABLE delivery ADD COLUMN search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', city || ' ' || region)) STORED;
+
+ALTER TABLE delivery ADD COLUMN private_search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', name || ' ' || address || ' ' || email || ' ' || phone)) STORED;
+
+CREATE INDEX items_search_vector_idx ON items USING GIN (search_vector);
+CREATE INDEX delivery_search_vector_idx ON delivery USING GIN (search_vector);
+CREATE INDEX delivery_private_search_vector_idx ON delivery USING GIN (private_search_vector);
+
+-- Нечёткий поиск по частям слов и с опечатками.
+CREATE INDEX items_name_trgm_idx ON items USING GIN (name gin_trgm_ops);
+CREATE INDEX items_brand_trgm_idx ON items USING GIN (brand gin_trgm_ops);
+CREATE INDEX delivery_city_trgm_idx ON delivery USING GIN (city gin_trgm_ops);
+CREATE INDEX delivery_name_trgm_idx ON delivery USING GIN (name gin_trgm_ops);
+CREATE INDEX delivery_address_trgm_idx ON delivery USING GIN (address gin_trgm_ops);
+CREATE INDEX delivery_email_trgm_idx ON delivery USING GIN (email gin_trgm_ops);
+CREATE INDEX delivery_phone_trgm_idx ON delivery USING GIN (phone gin_trgm_ops);
+CREATE INDEX orders_delivery_id_idx ON orders (delivery_id);
@@ -0,0 +1,15 @@
+-- События перевозчиков связываются с заказами по трек-номеру заказа
+-- или позиций при чтении, поэтому могут прийти раньше самого заказа.
+CREATE TABLE delivery_events (
+    id BIGSERIAL PRIMARY KEY,
+    track_number TEXT NOT NULL,
+    carrier VARCHAR(32) NOT NULL,
+    status VARCHAR(64) NOT NULL,
+    location VARCHAR(255) NOT NULL DEFAULT '',
+    occurred_at TIMESTAMPTZ NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- повторно доставленные сообщения Kafka не дублируют события
+    UNIQUE (track_number, carrier, status, occurred_at)
+);
+
+CREATE INDEX delivery_events_track_number_idx ON delivery_events (track_number, occurred_at DESC);
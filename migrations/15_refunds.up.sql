@@ -0,0 +1,23 @@
+-- Возвраты денег и товаров — учётные записи: они остаются после окончательного
+-- удаления заказа, а восстановленный из архива заказ с тем же id снова их видит.
+-- Поэтому order_id и item_id не ссылаются на orders и items.
+CREATE TABLE refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL,
+    amount NUMERIC(18,4) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refunds_order_id_idx ON refunds (order_id);
+
+CREATE TABLE returns (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL,
+    item_id BIGINT NOT NULL UNIQUE,
+    refund_id BIGINT REFERENCES refunds(id),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX returns_order_id_idx ON returns (order_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS payment_provider_payment_dt_idx;
+DROP INDEX IF EXISTS payment_transaction_idx;
+DROP TABLE IF EXISTS reconciliation_results;
+DROP TABLE IF EXISTS reconciliation_runs;
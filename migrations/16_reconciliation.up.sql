@@ -0,0 +1,26 @@
+CREATE TABLE reconciliation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    provider TEXT NOT NULL,
+    settlement_date DATE NOT NULL,
+    file_name TEXT NOT NULL,
+    rows BIGINT NOT NULL DEFAULT 0,
+    matched BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Совпавшие транзакции не сохраняются, только расхождения.
+CREATE TABLE reconciliation_results (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    transaction TEXT NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    expected_amount NUMERIC(18,4),
+    settled_amount NUMERIC(18,4),
+    expected_currency VARCHAR(3),
+    settled_currency VARCHAR(3),
+    line BIGINT
+);
+
+CREATE INDEX reconciliation_results_run_id_idx ON reconciliation_results (run_id, status, id);
+CREATE INDEX payment_transaction_idx ON payment (transaction);
+CREATE INDEX payment_provider_payment_dt_idx ON payment (provider, payment_dt);
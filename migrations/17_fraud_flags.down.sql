@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS delivery_phone_idx;
+DROP INDEX IF EXISTS delivery_email_idx;
+DROP TABLE IF EXISTS fraud_flags;
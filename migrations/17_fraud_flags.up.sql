@@ -0,0 +1,15 @@
+-- Оценка риска заказа, выставленная правилами при приёме из Kafka. Она не
+-- удаляется вместе с заказом, чтобы восстановленный из архива заказ с тем же
+-- id снова её видел, поэтому order_id не ссылается на orders.
+CREATE TABLE fraud_flags (
+    order_id BIGINT PRIMARY KEY,
+    score INT NOT NULL CHECK (score > 0),
+    reasons JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX fraud_flags_created_at_idx ON fraud_flags (created_at DESC, order_id DESC);
+
+-- Поиск других покупателей с тем же email или телефоном доставки.
+CREATE INDEX delivery_email_idx ON delivery (email);
+CREATE INDEX delivery_phone_idx ON delivery (phone);
@@ -0,0 +1,30 @@
+-- Зашифрованные строки перед откатом нужно расшифровать: шифртекст
+-- не помещается в прежние типы колонок.
+DROP INDEX IF EXISTS delivery_enc_key_id_idx;
+DROP INDEX IF EXISTS delivery_email_bidx_idx;
+DROP INDEX IF EXISTS delivery_phone_bidx_idx;
+DROP INDEX IF EXISTS delivery_name_bidx_idx;
+
+ALTER TABLE delivery
+    DROP CONSTRAINT IF EXISTS delivery_enc_dek_check,
+    DROP COLUMN IF EXISTS email_bidx,
+    DROP COLUMN IF EXISTS phone_bidx,
+    DROP COLUMN IF EXISTS name_bidx,
+    DROP COLUMN IF EXISTS enc_dek,
+    DROP COLUMN IF EXISTS enc_key_id;
+
+ALTER TABLE delivery
+    ALTER COLUMN email TYPE VARCHAR(255),
+    ALTER COLUMN phone TYPE VARCHAR(20);
+
+ALTER TABLE delivery ADD COLUMN private_search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', name || ' ' || address || ' ' || email || ' ' || phone)) STORED;
+
+CREATE INDEX delivery_private_search_vector_idx ON delivery USING GIN (private_search_vector);
+
+CREATE INDEX delivery_name_trgm_idx ON delivery USING GIN (name gin_trgm_ops);
+CREATE INDEX delivery_address_trgm_idx ON delivery USING GIN (address gin_trgm_ops);
+CREATE INDEX delivery_email_trgm_idx ON delivery USING GIN (email gin_trgm_ops);
+CREATE INDEX delivery_phone_trgm_idx ON delivery USING GIN (phone gin_trgm_ops);
+CREATE INDEX delivery_email_idx ON delivery (email);
+CREATE INDEX delivery_phone_idx ON delivery (phone);
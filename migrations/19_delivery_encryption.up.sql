@@ -0,0 +1,35 @@
+-- Шифрование персональных данных доставки. Имя, телефон, адрес и email
+-- хранятся в base64 шифртекста AES-GCM, ключ строки enc_dek обёрнут ключом
+-- enc_key_id из keyring. Строки без enc_key_id хранятся открыто.
+
+-- Вектор и индексы строились по открытым персональным данным: по шифртексту
+-- они бесполезны, а для ещё не перешифрованных строк хранят открытые значения.
+ALTER TABLE delivery DROP COLUMN private_search_vector;
+
+DROP INDEX IF EXISTS delivery_name_trgm_idx;
+DROP INDEX IF EXISTS delivery_address_trgm_idx;
+DROP INDEX IF EXISTS delivery_email_trgm_idx;
+DROP INDEX IF EXISTS delivery_phone_trgm_idx;
+DROP INDEX IF EXISTS delivery_email_idx;
+DROP INDEX IF EXISTS delivery_phone_idx;
+
+-- Шифртекст длиннее исходных значений.
+ALTER TABLE delivery
+    ALTER COLUMN phone TYPE TEXT,
+    ALTER COLUMN email TYPE TEXT;
+
+ALTER TABLE delivery
+    ADD COLUMN enc_key_id TEXT,
+    ADD COLUMN enc_dek BYTEA,
+    ADD COLUMN name_bidx BYTEA,
+    ADD COLUMN phone_bidx BYTEA,
+    ADD COLUMN email_bidx BYTEA,
+    ADD CONSTRAINT delivery_enc_dek_check CHECK ((enc_key_id IS NULL) = (enc_dek IS NULL));
+
+-- Слепые индексы — HMAC нормализованных значений для поиска точных совпадений.
+CREATE INDEX delivery_name_bidx_idx ON delivery (name_bidx);
+CREATE INDEX delivery_phone_bidx_idx ON delivery (phone_bidx);
+CREATE INDEX delivery_email_bidx_idx ON delivery (email_bidx);
+
+-- Перешифровка выбирает строки не основным ключом.
+CREATE INDEX delivery_enc_key_id_idx ON delivery (enc_key_id);
@@ -0,0 +1,36 @@
+-- Строки с одним днём заказа и разными днями оплаты сливаются.
+CREATE TABLE orders_daily_rollup_merged AS
+SELECT day, delivery_service, provider, bank, currency,
+    sum(orders) AS orders, sum(items) AS items, sum(revenue) AS revenue
+FROM orders_daily_rollup
+GROUP BY 1, 2, 3, 4, 5;
+
+CREATE TABLE items_daily_rollup_merged AS
+SELECT day, brand, nm_id, sale_bucket, currency,
+    sum(quantity) AS quantity, sum(revenue) AS revenue
+FROM items_daily_rollup
+GROUP BY 1, 2, 3, 4, 5;
+
+DELETE FROM orders_daily_rollup;
+DELETE FROM items_daily_rollup;
+
+ALTER TABLE orders_daily_rollup
+    DROP CONSTRAINT orders_daily_rollup_pkey,
+    DROP COLUMN payment_day,
+    ADD PRIMARY KEY (day, delivery_service, provider, bank, currency);
+
+ALTER TABLE items_daily_rollup
+    DROP CONSTRAINT items_daily_rollup_pkey,
+    DROP COLUMN payment_day,
+    ADD PRIMARY KEY (day, brand, nm_id, sale_bucket, currency);
+
+INSERT INTO orders_daily_rollup (day, delivery_service, provider, bank, currency, orders, items, revenue)
+SELECT day, delivery_service, provider, bank, currency, orders, items, revenue
+FROM orders_daily_rollup_merged;
+
+INSERT INTO items_daily_rollup (day, brand, nm_id, sale_bucket, currency, quantity, revenue)
+SELECT day, brand, nm_id, sale_bucket, currency, quantity, revenue
+FROM items_daily_rollup_merged;
+
+DROP TABLE orders_daily_rollup_merged;
+DROP TABLE items_daily_rollup_merged;
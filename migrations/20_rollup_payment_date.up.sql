@@ -0,0 +1,48 @@
+-- Отчёты пересчитывают выручку по курсу на день оплаты, как и чтение
+-- заказа, поэтому агрегаты разбиваются ещё и по дню оплаты (UTC).
+ALTER TABLE orders_daily_rollup ADD COLUMN payment_day DATE;
+ALTER TABLE items_daily_rollup ADD COLUMN payment_day DATE;
+
+-- День оплаты не выводится из прежних строк, агрегаты пересчитываются заново.
+DELETE FROM orders_daily_rollup;
+DELETE FROM items_daily_rollup;
+
+ALTER TABLE orders_daily_rollup
+    ALTER COLUMN payment_day SET NOT NULL,
+    DROP CONSTRAINT orders_daily_rollup_pkey,
+    ADD PRIMARY KEY (day, delivery_service, provider, bank, currency, payment_day);
+
+ALTER TABLE items_daily_rollup
+    ALTER COLUMN payment_day SET NOT NULL,
+    DROP CONSTRAINT items_daily_rollup_pkey,
+    ADD PRIMARY KEY (day, brand, nm_id, sale_bucket, currency, payment_day);
+
+INSERT INTO orders_daily_rollup (day, delivery_service, provider, bank, currency, payment_day, orders, items, revenue)
+SELECT
+    o.date_created::DATE, o.delivery_service, p.provider, p.bank, p.currency,
+    (to_timestamp(p.payment_dt) AT TIME ZONE 'UTC')::DATE,
+    count(*), COALESCE(sum(ic.items), 0), sum(p.amount - rf.amount)
+FROM orders AS o
+INNER JOIN payment AS p ON p.id = o.payment_id
+CROSS JOIN LATERAL (
+    SELECT count(*) AS items FROM items AS i
+    WHERE i.order_id = o.id AND i.order_date_created = o.date_created AND i.deleted_at IS NULL
+        AND NOT EXISTS (SELECT 1 FROM returns AS rt WHERE rt.item_id = i.id)
+) AS ic
+CROSS JOIN LATERAL (
+    SELECT COALESCE(sum(amount), 0) AS amount FROM refunds WHERE order_id = o.id
+) AS rf
+WHERE o.deleted_at IS NULL
+GROUP BY 1, 2, 3, 4, 5, 6;
+
+INSERT INTO items_daily_rollup (day, brand, nm_id, sale_bucket, currency, payment_day, quantity, revenue)
+SELECT
+    o.date_created::DATE, i.brand, i.nm_id, (i.sale / 10) * 10, p.currency,
+    (to_timestamp(p.payment_dt) AT TIME ZONE 'UTC')::DATE,
+    count(*), sum(i.total_price)
+FROM orders AS o
+INNER JOIN payment AS p ON p.id = o.payment_id
+INNER JOIN items AS i ON i.order_id = o.id AND i.order_date_created = o.date_created
+WHERE o.deleted_at IS NULL AND i.deleted_at IS NULL
+    AND NOT EXISTS (SELECT 1 FROM returns AS rt WHERE rt.item_id = i.id)
+GROUP BY 1, 2, 3, 4, 5, 6;
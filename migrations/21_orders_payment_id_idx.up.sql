@@ -0,0 +1,2 @@
+-- Сверка с реестром провайдера пропускает оплаты удалённых заказов.
+CREATE INDEX orders_payment_id_idx ON orders (payment_id);
@@ -0,0 +1,10 @@
+CREATE TABLE order_audit (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor JSONB NOT NULL,
+    diff JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX order_audit_order_id_idx ON order_audit (order_id, created_at);
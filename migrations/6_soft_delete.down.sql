@@ -0,0 +1,6 @@
+DROP INDEX items_order_id_idx;
+DROP INDEX orders_deleted_at_idx;
+DROP INDEX orders_date_created_idx;
+
+ALTER TABLE items DROP COLUMN deleted_at;
+ALTER TABLE orders DROP COLUMN deleted_at;
@@ -0,0 +1,6 @@
+ALTER TABLE orders ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE items ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX orders_date_created_idx ON orders (date_created);
+CREATE INDEX orders_deleted_at_idx ON orders (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX items_order_id_idx ON items (order_id);
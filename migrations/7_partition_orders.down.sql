@@ -0,0 +1,76 @@
+ALTER TABLE items RENAME TO items_partitioned;
+ALTER TABLE orders RENAME TO orders_partitioned;
+
+ALTER INDEX orders_pkey RENAME TO orders_partitioned_pkey;
+ALTER INDEX items_pkey RENAME TO items_partitioned_pkey;
+
+DROP INDEX orders_date_created_idx;
+DROP INDEX orders_deleted_at_idx;
+DROP INDEX items_order_id_idx;
+
+CREATE TABLE orders (
+    id BIGINT PRIMARY KEY DEFAULT nextval('orders_id_seq'),
+    order_uid TEXT UNIQUE NOT NULL,
+    track_number TEXT NOT NULL,
+    entry TEXT NOT NULL,
+    delivery_id BIGINT REFERENCES delivery(id),
+    payment_id BIGINT REFERENCES payment(id),
+    locale VARCHAR(5) NOT NULL,
+    internal_signature TEXT,
+    customer_id TEXT,
+    delivery_service TEXT NOT NULL,
+    shardkey VARCHAR(10) NOT NULL,
+    sm_id INT,
+    date_created TIMESTAMP DEFAULT now(),
+    oof_shard VARCHAR(10) NOT NULL,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE items (
+    id BIGINT PRIMARY KEY DEFAULT nextval('items_id_seq'),
+    order_id BIGINT REFERENCES orders(id) ON DELETE CASCADE,
+    chrt_id INT NOT NULL,
+    track_number TEXT NOT NULL,
+    price NUMERIC(10,2) NOT NULL,
+    rid TEXT NOT NULL,
+    name TEXT NOT NULL,
+    sale INT NOT NULL,
+    size VARCHAR(100) NOT NULL,
+    total_price NUMERIC(10,2) NOT NULL,
+    nm_id INT NOT NULL,
+    brand VARCHAR(100) NOT NULL,
+    status INT NOT NULL,
+    deleted_at TIMESTAMPTZ
+);
+
+INSERT INTO orders (
+    id, order_uid, track_number, entry, delivery_id, payment_id,
+    locale, internal_signature, customer_id, delivery_service,
+    shardkey, sm_id, date_created, oof_shard, deleted_at
+)
+SELECT
+    id, order_uid, track_number, entry, delivery_id, payment_id,
+    locale, internal_signature, customer_id, delivery_service,
+    shardkey, sm_id, date_created, oof_shard, deleted_at
+FROM orders_partitioned;
+
+INSERT INTO items (
+    id, order_id, chrt_id, track_number, price, rid, name,
+    sale, size, total_price, nm_id, brand, status, deleted_at
+)
+SELECT
+    id, order_id, chrt_id, track_number, price, rid, name,
+    sale, size, total_price, nm_id, brand, status, deleted_at
+FROM items_partitioned;
+
+ALTER SEQUENCE orders_id_seq OWNED BY orders.id;
+ALTER SEQUENCE items_id_seq OWNED BY items.id;
+
+DROP TABLE items_partitioned;
+DROP TABLE orders_partitioned;
+DROP TABLE order_keys;
+DROP FUNCTION order_keys_sync();
+
+CREATE INDEX orders_date_created_idx ON orders (date_created);
+CREATE INDEX orders_deleted_at_idx ON orders (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX items_order_id_idx ON items (order_id);
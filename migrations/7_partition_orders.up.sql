@@ -0,0 +1,135 @@
+-- orders и items секционируются по месяцам date_created.
+-- Первичный ключ секционированной таблицы обязан включать ключ секционирования,
+-- поэтому глобальная уникальность order_uid и поиск даты заказа по id
+-- обеспечиваются обычной таблицей order_keys, которую ведёт триггер.
+
+ALTER TABLE items RENAME TO items_old;
+ALTER TABLE orders RENAME TO orders_old;
+
+ALTER INDEX orders_pkey RENAME TO orders_old_pkey;
+ALTER INDEX orders_order_uid_key RENAME TO orders_old_order_uid_key;
+ALTER INDEX items_pkey RENAME TO items_old_pkey;
+
+DROP INDEX orders_date_created_idx;
+DROP INDEX orders_deleted_at_idx;
+DROP INDEX items_order_id_idx;
+
+CREATE TABLE orders (
+    id BIGINT NOT NULL DEFAULT nextval('orders_id_seq'),
+    order_uid TEXT NOT NULL,
+    track_number TEXT NOT NULL,
+    entry TEXT NOT NULL,
+    delivery_id BIGINT REFERENCES delivery(id),
+    payment_id BIGINT REFERENCES payment(id),
+    locale VARCHAR(5) NOT NULL,
+    internal_signature TEXT,
+    customer_id TEXT,
+    delivery_service TEXT NOT NULL,
+    shardkey VARCHAR(10) NOT NULL,
+    sm_id INT,
+    date_created TIMESTAMP NOT NULL DEFAULT now(),
+    oof_shard VARCHAR(10) NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    PRIMARY KEY (id, date_created)
+) PARTITION BY RANGE (date_created);
+
+CREATE TABLE items (
+    id BIGINT NOT NULL DEFAULT nextval('items_id_seq'),
+    order_id BIGINT NOT NULL,
+    order_date_created TIMESTAMP NOT NULL,
+    chrt_id INT NOT NULL,
+    track_number TEXT NOT NULL,
+    price NUMERIC(10,2) NOT NULL,
+    rid TEXT NOT NULL,
+    name TEXT NOT NULL,
+    sale INT NOT NULL,
+    size VARCHAR(100) NOT NULL,
+    total_price NUMERIC(10,2) NOT NULL,
+    nm_id INT NOT NULL,
+    brand VARCHAR(100) NOT NULL,
+    status INT NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    PRIMARY KEY (id, order_date_created),
+    FOREIGN KEY (order_id, order_date_created)
+        REFERENCES orders (id, date_created) ON DELETE CASCADE
+) PARTITION BY RANGE (order_date_created);
+
+CREATE TABLE orders_default PARTITION OF orders DEFAULT;
+CREATE TABLE items_default PARTITION OF items DEFAULT;
+
+CREATE INDEX orders_date_created_idx ON orders (date_created);
+CREATE INDEX orders_deleted_at_idx ON orders (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX items_order_id_idx ON items (order_id);
+
+-- Месячные секции от самого старого заказа до трёх месяцев вперёд.
+-- Дальше будущие секции создаёт приложение.
+DO $$
+DECLARE
+    m DATE := date_trunc('month', LEAST((SELECT min(date_created) FROM orders_old), now()))::DATE;
+    last DATE := (date_trunc('month', now()) + INTERVAL '3 months')::DATE;
+BEGIN
+    WHILE m <= last LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF orders FOR VALUES FROM (%L) TO (%L)',
+            'orders_p' || to_char(m, 'YYYY_MM'), m, (m + INTERVAL '1 month')::DATE
+        );
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF items FOR VALUES FROM (%L) TO (%L)',
+            'items_p' || to_char(m, 'YYYY_MM'), m, (m + INTERVAL '1 month')::DATE
+        );
+        m := (m + INTERVAL '1 month')::DATE;
+    END LOOP;
+END $$;
+
+CREATE TABLE order_keys (
+    id BIGINT PRIMARY KEY,
+    order_uid TEXT UNIQUE NOT NULL,
+    date_created TIMESTAMP NOT NULL
+);
+
+CREATE FUNCTION order_keys_sync() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO order_keys (id, order_uid, date_created)
+        VALUES (NEW.id, NEW.order_uid, NEW.date_created);
+        RETURN NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        UPDATE order_keys SET order_uid = NEW.order_uid WHERE id = NEW.id;
+        RETURN NEW;
+    END IF;
+
+    DELETE FROM order_keys WHERE id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_keys_sync
+    AFTER INSERT OR UPDATE OF order_uid OR DELETE ON orders
+    FOR EACH ROW EXECUTE FUNCTION order_keys_sync();
+
+INSERT INTO orders (
+    id, order_uid, track_number, entry, delivery_id, payment_id,
+    locale, internal_signature, customer_id, delivery_service,
+    shardkey, sm_id, date_created, oof_shard, deleted_at
+)
+SELECT
+    id, order_uid, track_number, entry, delivery_id, payment_id,
+    locale, internal_signature, customer_id, delivery_service,
+    shardkey, sm_id, COALESCE(date_created, now()), oof_shard, deleted_at
+FROM orders_old;
+
+INSERT INTO items (
+    id, order_id, order_date_created, chrt_id, track_number, price,
+    rid, name, sale, size, total_price, nm_id, brand, status, deleted_at
+)
+SELECT
+    i.id, i.order_id, k.date_created, i.chrt_id, i.track_number, i.price,
+    i.rid, i.name, i.sale, i.size, i.total_price, i.nm_id, i.brand, i.status, i.deleted_at
+FROM items_old AS i
+INNER JOIN order_keys AS k ON k.id = i.order_id;
+
+ALTER SEQUENCE orders_id_seq OWNED BY orders.id;
+ALTER SEQUENCE items_id_seq OWNED BY items.id;
+
+DROP TABLE items_old;
+DROP TABLE orders_old;
@@ -0,0 +1,9 @@
+ALTER TABLE payment
+    ALTER COLUMN amount TYPE NUMERIC(10,2),
+    ALTER COLUMN delivery_cost TYPE NUMERIC(10,2),
+    ALTER COLUMN goods_total TYPE NUMERIC(10,2),
+    ALTER COLUMN custom_fee TYPE NUMERIC(10,2);
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE NUMERIC(10,2),
+    ALTER COLUMN total_price TYPE NUMERIC(10,2);
@@ -0,0 +1,11 @@
+-- Точность сумм определяется валютой (KWD — 3 знака, UYW — 4),
+-- поэтому колонки хранят money.Scale знаков.
+ALTER TABLE payment
+    ALTER COLUMN amount TYPE NUMERIC(18,4),
+    ALTER COLUMN delivery_cost TYPE NUMERIC(18,4),
+    ALTER COLUMN goods_total TYPE NUMERIC(18,4),
+    ALTER COLUMN custom_fee TYPE NUMERIC(18,4);
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE NUMERIC(18,4),
+    ALTER COLUMN total_price TYPE NUMERIC(18,4);
@@ -0,0 +1,7 @@
+-- rate — стоимость единицы currency в базовой валюте (fx.base_currency в config.yaml).
+CREATE TABLE fx_rates (
+    currency VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(24,10) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (currency, rate_date)
+);